@@ -0,0 +1,121 @@
+// Command simulate runs an evening through an arranger offline, with players whose true skills are
+// hidden from the arranger and decide the chance of winning every match, and reports how fair the
+// arrangements were: the spread of games played, repeated partners and opponents, and the average
+// skill gap per match.
+//
+// Usage:
+//
+//	simulate -players 18 -courts 3 -rounds 12
+//	simulate -csv players.csv -arranger banded -rating elo -json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+
+	"github.com/yushenli/badminton_match_table/pkg/arranger"
+	"github.com/yushenli/badminton_match_table/pkg/rating"
+)
+
+var playersFlag = flag.Int("players", 16, "The number of synthetic players, ignored when -csv is given")
+var csvFlag = flag.String("csv", "", "A CSV file with one player per line: name, true skill, and optionally category and initial score")
+var skillSpreadFlag = flag.Float64("skill_spread", 2, "The standard deviation of the true skills of synthetic players")
+var misjudgementFlag = flag.Float64("misjudgement", 1, "The standard deviation of the error in the initial scores of synthetic players")
+var roundsFlag = flag.Int("rounds", 10, "The number of rounds to simulate")
+var courtsFlag = flag.Int("courts", 3, "The number of courts")
+var seedFlag = flag.Int64("seed", 1, "The seed of the synthetic players, the arranger and the match outcomes")
+var luckFlag = flag.Float64("luck", 1, "How random the match outcomes are, the larger the more random")
+var arrangerFlag = flag.String("arranger", arranger.DefaultArrangerName, "The name of the arranger")
+var costModelFlag = flag.String("cost_model", "", "The weights of the cost model, in the form parsed by arranger.ParseCostModel")
+var bandingFlag = flag.String("banding", "", "How players are split into score bands, in the form parsed by arranger.ParseBanding")
+var formatFlag = flag.String("format", "", "The format of doubles matches, see arranger.Format")
+var balanceSidesFlag = flag.Bool("balance_sides", false, "Whether to balance the combined scores of doubles sides")
+var ratingFlag = flag.String("rating", rating.DefaultSystemName, "The name of the rating system turning results into scores")
+var jsonFlag = flag.Bool("json", false, "Whether to print the report in JSON")
+
+func main() {
+	flag.Parse()
+	rng := rand.New(rand.NewSource(*seedFlag))
+
+	var players []*simPlayer
+	if *csvFlag != "" {
+		f, err := os.Open(*csvFlag)
+		if err != nil {
+			log.Fatalf("Unable to open %s: %v", *csvFlag, err)
+		}
+		players, err = readPlayers(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Unable to read players from %s: %v", *csvFlag, err)
+		}
+	} else {
+		players = syntheticPlayers(*playersFlag, *skillSpreadFlag, *misjudgementFlag, rng)
+	}
+
+	eventArranger, err := arranger.Lookup(*arrangerFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	costModel, err := arranger.ParseCostModel(*costModelFlag)
+	if err != nil {
+		log.Fatalf("Invalid cost model: %v", err)
+	}
+	banding, err := arranger.ParseBanding(*bandingFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	format, err := arranger.ParseFormat(*formatFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	system, err := rating.Lookup(*ratingFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *luckFlag <= 0 {
+		log.Fatalf("Invalid luck %g, a positive number is expected", *luckFlag)
+	}
+
+	s := session{
+		players:  players,
+		rounds:   *roundsFlag,
+		arranger: eventArranger,
+		input: arranger.Input{
+			CourtCount:   *courtsFlag,
+			Seed:         int(*seedFlag),
+			Cost:         &costModel,
+			Banding:      banding,
+			Format:       format,
+			BalanceSides: *balanceSidesFlag,
+		},
+		rating: system,
+		luck:   *luckFlag,
+	}
+	r, err := s.run(rng)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *jsonFlag {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(r)
+		return
+	}
+	fmt.Printf("Players:                  %d\n", len(players))
+	fmt.Printf("Rounds:                   %d\n", r.Rounds)
+	fmt.Printf("Matches:                  %d\n", r.Matches)
+	fmt.Printf("Court utilisation:        %.1f%%\n", r.CourtUtilisation*100)
+	fmt.Printf("Games per player:         min %d, max %d, mean %.2f, variance %.2f\n",
+		r.GamesMin, r.GamesMax, r.GamesMean, r.GamesVariance)
+	fmt.Printf("Partner repeats:          %d\n", r.PartnerRepeats)
+	fmt.Printf("Opponent repeats:         %d\n", r.OpponentRepeats)
+	fmt.Printf("Average score gap:        %.3f\n", r.AverageScoreGap)
+	fmt.Printf("Average skill gap:        %.3f\n", r.AverageSkillGap)
+	fmt.Printf("Longest sit-out streak:   %d\n", r.MaxSitOutStreak)
+	fmt.Printf("Score/skill correlation:  %.3f\n", r.RankCorrelation)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/yushenli/badminton_match_table/pkg/model"
+)
+
+// simPlayer is a player of the simulation, whose true skill is hidden from the arranger.
+type simPlayer struct {
+	player *model.Player
+	// skill is the true skill of the player on the scale of scores. It decides the chance to win.
+	skill float64
+	// initialScore is the score the organizer gives the player before the first round.
+	initialScore float64
+}
+
+func newSimPlayer(id int, name string, skill, initialScore float64, category string) *simPlayer {
+	return &simPlayer{
+		player: &model.Player{
+			ID:        id,
+			Name:      name,
+			Score:     float32(initialScore),
+			Partners:  make(map[*model.Player]int),
+			Opponents: make(map[*model.Player]int),
+			Category:  category,
+		},
+		skill:        skill,
+		initialScore: initialScore,
+	}
+}
+
+// syntheticPlayers makes count players whose true skills are normally distributed with the given
+// spread. Their initial scores are the skills misjudged by a normally distributed error of the
+// given size, rounded to integers as organizers do. Categories alternate between male and female.
+func syntheticPlayers(count int, spread, misjudgement float64, rng *rand.Rand) []*simPlayer {
+	players := make([]*simPlayer, count)
+	for idx := range players {
+		skill := rng.NormFloat64() * spread
+		initialScore := math.Round(skill + rng.NormFloat64()*misjudgement)
+		category := model.CategoryMale
+		if idx%2 == 1 {
+			category = model.CategoryFemale
+		}
+		players[idx] = newSimPlayer(idx+1, fmt.Sprintf("Player%02d", idx+1), skill, initialScore, category)
+	}
+	return players
+}
+
+// readPlayers reads players in CSV, one per line: name, true skill, and optionally the category
+// and the initial score. The initial score is the rounded skill when not given.
+func readPlayers(r io.Reader) ([]*simPlayer, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	entries, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the players in CSV: %v", err)
+	}
+
+	var players []*simPlayer
+	for idx, entry := range entries {
+		if len(entry) == 0 || (len(entry) == 1 && strings.TrimSpace(entry[0]) == "") {
+			continue
+		}
+		if len(entry) < 2 || len(entry) > 4 {
+			return nil, fmt.Errorf("invalid entry on row %d, 2 to 4 fields are expected: %+v", idx+1, entry)
+		}
+
+		name := strings.TrimSpace(entry[0])
+		skill, err := strconv.ParseFloat(strings.TrimSpace(entry[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid entry on row %d, skill must be a number: %+v", idx+1, entry)
+		}
+		category := model.CategoryUnknown
+		if len(entry) >= 3 {
+			category = strings.ToUpper(strings.TrimSpace(entry[2]))
+			if !model.ValidCategory(category) {
+				return nil, fmt.Errorf("invalid entry on row %d, unknown category %q: %+v", idx+1, entry[2], entry)
+			}
+		}
+		initialScore := math.Round(skill)
+		if len(entry) == 4 {
+			initialScore, err = strconv.ParseFloat(strings.TrimSpace(entry[3]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid entry on row %d, initial score must be a number: %+v", idx+1, entry)
+			}
+		}
+
+		players = append(players, newSimPlayer(len(players)+1, name, skill, initialScore, category))
+	}
+	return players, nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/yushenli/badminton_match_table/pkg/arranger"
+	"github.com/yushenli/badminton_match_table/pkg/metrics"
+	"github.com/yushenli/badminton_match_table/pkg/model"
+	"github.com/yushenli/badminton_match_table/pkg/rating"
+)
+
+// session is an evening to be simulated.
+type session struct {
+	players  []*simPlayer
+	rounds   int
+	arranger arranger.Arranger
+	// input holds the court count, cost model, format and the other settings of the arranger.
+	// Its players are filled in every round.
+	input  arranger.Input
+	rating rating.System
+	// luck is the skill gap between two sides at which the weaker side still wins 1 in 1+e times.
+	// The larger it is, the more random the outcomes are.
+	luck float64
+}
+
+// report holds the fairness metrics of a simulated session.
+type report struct {
+	metrics.Metrics
+	// AverageSkillGap is the average gap between the true skills of the two sides of a match.
+	AverageSkillGap float64 `json:"average_skill_gap"`
+	// RankCorrelation is the Spearman correlation between the final scores and the true skills,
+	// which tells how well the scores used for banding found out the true skills.
+	RankCorrelation float64 `json:"rank_correlation"`
+}
+
+// winChance returns the chance for a side to beat a side whose average true skill is gap lower.
+func winChance(gap, luck float64) float64 {
+	return 1 / (1 + math.Exp(-gap/luck))
+}
+
+// sideSkill returns the average true skill of the players of a side.
+func sideSkill(side model.Side, skills map[*model.Player]float64) float64 {
+	if side.Player2 == nil {
+		return skills[side.Player1]
+	}
+	return (skills[side.Player1] + skills[side.Player2]) / 2
+}
+
+// sideScore returns the average score of the players of a side.
+func sideScore(side model.Side) float64 {
+	if side.Player2 == nil {
+		return float64(side.Player1.Score)
+	}
+	return float64(side.Player1.Score+side.Player2.Score) / 2
+}
+
+// sideIDs returns the IDs of the players of a side.
+func sideIDs(side model.Side) []int {
+	if side.Player2 == nil {
+		return []int{side.Player1.ID}
+	}
+	return []int{side.Player1.ID, side.Player2.ID}
+}
+
+// run simulates the session, deciding the outcome of every match with rng, and reports the metrics.
+func (s session) run(rng *rand.Rand) (report, error) {
+	var players model.PlayerSlice
+	var ids []int
+	skills := make(map[*model.Player]float64)
+	ratings := make(map[int]rating.Rating)
+	for _, p := range s.players {
+		players = append(players, p.player)
+		ids = append(ids, p.player.ID)
+		skills[p.player] = p.skill
+		ratings[p.player.ID] = s.rating.Initial(p.initialScore)
+		p.player.Score = float32(s.rating.Score(ratings[p.player.ID]))
+	}
+
+	var r report
+	var rounds [][]metrics.Match
+	var skillGaps float64
+	for round := 1; round <= s.rounds; round++ {
+		input := s.input
+		input.AllPlayers = players
+		input.Players = players
+		input.Seed = s.input.Seed + round
+		arrangement, err := s.arranger.Arrange(input)
+		if err != nil {
+			return r, fmt.Errorf("failed to arrange round %d: %v", round, err)
+		}
+
+		var matches []metrics.Match
+		var results []rating.Match
+		for idx, match := range arrangement {
+			gap := sideSkill(match.Side1, skills) - sideSkill(match.Side2, skills)
+			skillGaps += math.Abs(gap)
+			matches = append(matches, metrics.Match{
+				Court:      idx + 1,
+				Side1:      sideIDs(match.Side1),
+				Side2:      sideIDs(match.Side2),
+				Side1Score: sideScore(match.Side1),
+				Side2Score: sideScore(match.Side2),
+			})
+			results = append(results, rating.Match{
+				Side1:    sideIDs(match.Side1),
+				Side2:    sideIDs(match.Side2),
+				Side1Won: rng.Float64() < winChance(gap, s.luck),
+			})
+		}
+		rounds = append(rounds, matches)
+
+		arranger.ApplyArrangement(players, arrangement)
+		ratings = s.rating.Update(ratings, results)
+		for _, player := range players {
+			player.Score = float32(s.rating.Score(ratings[player.ID]))
+		}
+	}
+
+	r.Metrics = metrics.Compute(ids, rounds, s.input.CourtCount)
+	if r.Matches > 0 {
+		r.AverageSkillGap = skillGaps / float64(r.Matches)
+	}
+	r.RankCorrelation = rankCorrelation(s.players)
+	return r, nil
+}
+
+// ranks returns the rank of each value from 0, where tied values share their average rank.
+func ranks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for idx := range order {
+		order[idx] = idx
+	}
+	sort.SliceStable(order, func(i, j int) bool { return values[order[i]] < values[order[j]] })
+
+	ret := make([]float64, len(values))
+	for i := 0; i < len(order); {
+		j := i
+		for j+1 < len(order) && values[order[j+1]] == values[order[i]] {
+			j++
+		}
+		for k := i; k <= j; k++ {
+			ret[order[k]] = float64(i+j) / 2
+		}
+		i = j + 1
+	}
+	return ret
+}
+
+// rankCorrelation returns the Spearman correlation between the scores and the true skills of the
+// players, or 0 if either of them are all the same.
+func rankCorrelation(players []*simPlayer) float64 {
+	scores := make([]float64, len(players))
+	skills := make([]float64, len(players))
+	for idx, p := range players {
+		scores[idx] = float64(p.player.Score)
+		skills[idx] = p.skill
+	}
+	x, y := ranks(scores), ranks(skills)
+
+	var meanX, meanY float64
+	for idx := range x {
+		meanX += x[idx]
+		meanY += y[idx]
+	}
+	meanX /= float64(len(x))
+	meanY /= float64(len(y))
+
+	var cov, varX, varY float64
+	for idx := range x {
+		cov += (x[idx] - meanX) * (y[idx] - meanY)
+		varX += (x[idx] - meanX) * (x[idx] - meanX)
+		varY += (y[idx] - meanY) * (y[idx] - meanY)
+	}
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varX*varY)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yushenli/badminton_match_table/pkg/arranger"
+	"github.com/yushenli/badminton_match_table/pkg/rating"
+)
+
+func TestRun(t *testing.T) {
+	cases := []struct {
+		title      string
+		players    int
+		courts     int
+		rating     string
+		maxSitOuts int
+	}{
+		{"AllPlaying", 8, 2, "classic", 0},
+		{"SomeResting", 10, 2, "elo", 1},
+		{"Singles", 7, 3, "glicko2", 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+			eventArranger, _ := arranger.Lookup("")
+			system, _ := rating.Lookup(tc.rating)
+			s := session{
+				players:  syntheticPlayers(tc.players, 2, 1, rng),
+				rounds:   6,
+				arranger: eventArranger,
+				input:    arranger.Input{CourtCount: tc.courts, Seed: 1},
+				rating:   system,
+				luck:     1,
+			}
+			r, err := s.run(rng)
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			if r.Matches != 6*tc.courts {
+				t.Errorf("Unexpected matches, expected %d got %d", 6*tc.courts, r.Matches)
+			}
+			if r.GamesMax-r.GamesMin > 1 {
+				t.Errorf("Unexpected games spread, expected at most 1 got %d-%d", r.GamesMin, r.GamesMax)
+			}
+			if r.MaxSitOutStreak > tc.maxSitOuts {
+				t.Errorf("Unexpected sit-out streak, expected at most %d got %d", tc.maxSitOuts, r.MaxSitOutStreak)
+			}
+		})
+	}
+}
+
+func TestReadPlayers(t *testing.T) {
+	players, err := readPlayers(strings.NewReader("Alice,3.2,f\nBob,-1,M,0\n\n"))
+	if err != nil {
+		t.Errorf("Not expecting error but got %v.", err)
+		return
+	}
+	var got [][]interface{}
+	for _, p := range players {
+		got = append(got, []interface{}{p.player.ID, p.player.Name, p.skill, p.initialScore, p.player.Category})
+	}
+	expected := [][]interface{}{{1, "Alice", 3.2, 3.0, "F"}, {2, "Bob", -1.0, 0.0, "M"}}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("Unexpected players, expected %v got %v", expected, got)
+	}
+
+	for _, input := range []string{"Alice", "Alice,high", "Alice,1,X", "Alice,1,F,low", "Alice,1,F,1,extra"} {
+		if _, err := readPlayers(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected error for %q but got nil.", input)
+		}
+	}
+}
+
+func TestRanks(t *testing.T) {
+	expected := []float64{2, 0, 3.5, 1, 3.5}
+	if got := ranks([]float64{3, 1, 5, 2, 5}); !reflect.DeepEqual(expected, got) {
+		t.Errorf("Unexpected ranks, expected %v got %v", expected, got)
+	}
+}
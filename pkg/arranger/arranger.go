@@ -19,9 +19,20 @@ func SortPlayerSliceByScorePriority(slice model.PlayerSlice) {
 }
 
 // SeparateCompetedPlayers will, for a given subslice of 2N players, find the best combination
-// of N pairs where the total times that the two players within each pair have played with each other
-// if minimized. When multiple solution exists with the same total times, SeparateCompetedPlayers
+// of N pairs where the total times that the two players within each pair have met each other,
+// either as partners or as opponents, is minimized. When multiple solution exists with the same total times, SeparateCompetedPlayers
 // will try to pair the players with the closest scores.
+// It's the same as DefaultCostModel.SeparateCompetedPlayers.
+func SeparateCompetedPlayers(players model.PlayerSlice, start, end int, endFixed bool) error {
+	return DefaultCostModel.SeparateCompetedPlayers(players, start, end, endFixed)
+}
+
+// SeparateCompetedPlayers will, for a given subslice of 2N players, find the best combination
+// of N pairs where the total cost of the pairs is minimized. The cost of a pair is the weighted
+// times the two players have been partners and opponents. When multiple combinations have the
+// same total cost, the one whose scores are reversed the least between consecutive pairs is
+// taken, as the exhaustive search does. The least total cost is found as a min-cost perfect
+// matching in polynomial time, see leastReversedPairs for the tie-break.
 // In the end, the provided PlayerSlice will be rearranged. Every two consecutive players will
 // be considered a pair.
 //
@@ -36,7 +47,17 @@ func SortPlayerSliceByScorePriority(slice model.PlayerSlice) {
 // time of matches against the second last player in the arrangement.
 //
 // Returns error if there are odd number of players between start and end (inclusive).
-func SeparateCompetedPlayers(players model.PlayerSlice, start, end int, endFixed bool) error {
+func (m CostModel) SeparateCompetedPlayers(players model.PlayerSlice, start, end int, endFixed bool) error {
+	return m.separateCompetedPlayers(players, start, end, endFixed, nil)
+}
+
+// neverMeetPenalty is the cost added by separateCompetedPlayers to pair two players who must never
+// meet, so such a pair is only made when there is no other way.
+const neverMeetPenalty = 1e6
+
+// separateCompetedPlayers does the same as SeparateCompetedPlayers, while avoiding pairing up the
+// players who must never meet by the constraints.
+func (m CostModel) separateCompetedPlayers(players model.PlayerSlice, start, end int, endFixed bool, constraints Constraints) error {
 	if start >= end {
 		return fmt.Errorf("start has to be smaller than end, you provided start(%d) and end(%d)", start, end)
 	}
@@ -48,6 +69,208 @@ func SeparateCompetedPlayers(players model.PlayerSlice, start, end int, endFixed
 		return fmt.Errorf("Sub slice to be rearranged must have even number of elements, you provided start(%d) and end(%d)", start, end)
 	}
 
+	sub := players[start : end+1]
+	pairs := leastReversedPairs(sub, func(i, j int) int64 {
+		cost := m.pairCost(sub[i], sub[j])
+		if !constraints.canMeet(sub[i], sub[j]) {
+			cost += neverMeetPenalty
+		}
+		return costUnits(cost)
+	}, endFixed)
+
+	arranged := make(model.PlayerSlice, 0, len(sub))
+	for _, pair := range pairs {
+		arranged = append(arranged, sub[pair[0]], sub[pair[1]])
+	}
+	copy(sub, arranged)
+
+	return nil
+}
+
+// maxPairSearchSteps bounds the search of leastReversedPairs, so a large band is rearranged in
+// reasonable time.
+const maxPairSearchSteps = 5000
+
+// leastReversedPairs splits the players, sorted by score, into pairs of the least total cost as
+// minCostPairs does, and among those pairings takes the one whose scores are reversed the least
+// between consecutive pairs, see reversedScores. The pairings are searched pair by pair in the
+// order of the players, and a pair is only tried when the players left can still be paired at the
+// least total cost, which is checked by a min-cost perfect matching. The search gives up after
+// maxPairSearchSteps with the best pairing found by then.
+func leastReversedPairs(players model.PlayerSlice, cost func(i, j int) int64, lastFixed bool) [][2]int {
+	n := len(players)
+	best := minCostPairs(n, cost, lastFixed)
+	var minCost int64
+	for _, pair := range best {
+		minCost += cost(pair[0], pair[1])
+	}
+	minReversed := reversedScores(players, best)
+
+	used := make([]bool, n)
+	// leftCost returns the least total cost to pair the players who are not used yet.
+	leftCost := func() int64 {
+		var items []int
+		for i, u := range used {
+			if !u {
+				items = append(items, i)
+			}
+		}
+		var total int64
+		itemCost := func(a, b int) int64 { return cost(items[a], items[b]) }
+		for _, pair := range minCostPairs(len(items), itemCost, lastFixed && !used[n-1]) {
+			total += itemCost(pair[0], pair[1])
+		}
+		return total
+	}
+
+	steps := 0
+	var pairs [][2]int
+	var search func(total int64, reversed float32)
+	search = func(total int64, reversed float32) {
+		steps++
+		i := 0
+		for i < n && used[i] {
+			i++
+		}
+		if i == n {
+			if reversed < minReversed {
+				best = append([][2]int(nil), pairs...)
+				minReversed = reversed
+			}
+			return
+		}
+		if len(pairs) > 0 {
+			if last := players[pairs[len(pairs)-1][1]]; players[i].Score > last.Score {
+				reversed += players[i].Score - last.Score
+			}
+		}
+		if reversed >= minReversed {
+			return
+		}
+
+		used[i] = true
+		for j := i + 1; j < n && steps <= maxPairSearchSteps; j++ {
+			// A fixed last player can only be paired in the last pair.
+			if used[j] || (lastFixed && j == n-1 && len(pairs) < n/2-1) {
+				continue
+			}
+			used[j] = true
+			if pairTotal := total + cost(i, j); pairTotal+leftCost() == minCost {
+				pairs = append(pairs, [2]int{i, j})
+				search(pairTotal, reversed)
+				pairs = pairs[:len(pairs)-1]
+			}
+			used[j] = false
+		}
+		used[i] = false
+	}
+	search(0, 0)
+	return best
+}
+
+// reversedScores returns how much the scores are reversed between consecutive pairs, i.e. the sum
+// of how much higher the first player of every pair is than the second player of the pair before.
+func reversedScores(players model.PlayerSlice, pairs [][2]int) float32 {
+	var reversed float32
+	for k := 1; k < len(pairs); k++ {
+		if first, last := players[pairs[k][0]], players[pairs[k-1][1]]; first.Score > last.Score {
+			reversed += first.Score - last.Score
+		}
+	}
+	return reversed
+}
+
+// minCostPairs splits n items into n/2 pairs so that the total cost of all the pairs is minimized,
+// where cost(i, j) is the cost to pair item i and j, with i < j.
+// The returned pairs are ordered by their first items, and the first item of a pair is always the smaller one.
+//
+// When lastFixed is set, the pair containing the last item must be the last pair, i.e. the last item
+// can only be paired with an item after the first items of all the other pairs.
+func minCostPairs(n int, cost func(i, j int) int64, lastFixed bool) [][2]int {
+	if !lastFixed {
+		items := make([]int, n)
+		for i := range items {
+			items[i] = i
+		}
+		pairs, _, _ := perfectMatching(items, cost, nil)
+		return pairs
+	}
+
+	// Try each item to be paired with the last one, and keep the cheapest result.
+	last := n - 1
+	var bestPairs [][2]int
+	var bestCost int64 = math.MaxInt64
+	for q := 0; q < last; q++ {
+		var items []int
+		for i := 0; i < last; i++ {
+			if i != q {
+				items = append(items, i)
+			}
+		}
+		pairs, total, ok := perfectMatching(items, cost, func(i, j int) bool { return i < q || j < q })
+		if !ok {
+			continue
+		}
+		total += cost(q, last)
+		if total < bestCost {
+			bestPairs = append(pairs, [2]int{q, last})
+			bestCost = total
+		}
+	}
+	return bestPairs
+}
+
+// perfectMatching finds the min-cost perfect matching among the given items, where only the pairs
+// permitted by allowed are considered (all pairs are allowed if it's nil).
+// Returns the pairs ordered by their first items, the total cost, and whether a perfect matching exists.
+func perfectMatching(items []int, cost func(i, j int) int64, allowed func(i, j int) bool) ([][2]int, int64, bool) {
+	if len(items) == 0 {
+		return [][2]int{}, 0, true
+	}
+
+	var edges []weightedEdge
+	var maxCost int64
+	for a := range items {
+		for b := a + 1; b < len(items); b++ {
+			if allowed != nil && !allowed(items[a], items[b]) {
+				continue
+			}
+			c := cost(items[a], items[b])
+			if c > maxCost {
+				maxCost = c
+			}
+			edges = append(edges, weightedEdge{a, b, c})
+		}
+	}
+	// Maximizing the total of (maxCost + 1 - cost) among the maximum cardinality matchings
+	// gives the min-cost perfect matching when one exists.
+	for idx := range edges {
+		edges[idx].weight = maxCost + 1 - edges[idx].weight
+	}
+
+	mate := maxWeightMatching(edges, true)
+	if len(mate) != len(items) {
+		return nil, 0, false
+	}
+	var pairs [][2]int
+	var total int64
+	for a, b := range mate {
+		if b < 0 {
+			return nil, 0, false
+		}
+		if a < b {
+			pairs = append(pairs, [2]int{items[a], items[b]})
+			total += cost(items[a], items[b])
+		}
+	}
+	return pairs, total, true
+}
+
+// separateCompetedPlayersExhaustively does the same as SeparateCompetedPlayers by enumerating
+// every possible combination of pairs, with the closest scores measured by how much the scores
+// are reversed between consecutive pairs. It takes exponential time and is kept as a reference
+// for tests and benchmarks.
+func separateCompetedPlayersExhaustively(players model.PlayerSlice, start, end int, endFixed bool) {
 	bestPairs := make([]model.Side, (end-start+1)/2)
 	currentPairs := make([]model.Side, (end-start+1)/2)
 	used := make([]bool, len(players))
@@ -62,8 +285,6 @@ func SeparateCompetedPlayers(players model.PlayerSlice, start, end int, endFixed
 		players[start+i*2] = bestPairs[i].Player1
 		players[start+i*2+1] = bestPairs[i].Player2
 	}
-
-	return nil
 }
 
 func tryArrange(players model.PlayerSlice, bestPairs *[]model.Side, pairs []model.Side, level, start, end int, endFixed bool, used []bool, minTotalMatches *int, minReversedScores *float32) {
@@ -108,9 +329,7 @@ func checkBestArrangement(bestPairs *[]model.Side, pairs []model.Side, minTotalM
 	var lastPlayer2 *model.Player
 
 	for _, pair := range pairs {
-		if matches, ok := pair.Player1.Opponents[pair.Player2]; ok {
-			totalMatches += matches
-		}
+		totalMatches += pair.Player1.TimesMet(pair.Player2)
 		if totalMatches > *minTotalMatches {
 			return
 		}
@@ -129,3 +348,83 @@ func checkBestArrangement(bestPairs *[]model.Side, pairs []model.Side, minTotalM
 		*minReversedScores = reversedScores
 	}
 }
+
+// doublesSplits lists, by the indexes among 4 players, the 3 different ways to split them
+// into 2 sides. The first two indexes form one side and the last two form the other.
+var doublesSplits = [][4]int{
+	{0, 1, 2, 3},
+	{0, 2, 1, 3},
+	{0, 3, 1, 2},
+}
+
+// SplitDoublesSides rearranges, in place, the 4 players starting from start in the given
+// PlayerSlice, so that the first two and the last two become the two sides of a doubles match.
+// It's the same as DefaultCostModel.SplitDoublesSides.
+func SplitDoublesSides(players model.PlayerSlice, start int) error {
+	return DefaultCostModel.SplitDoublesSides(players, start)
+}
+
+// SplitDoublesSides rearranges, in place, the 4 players starting from start in the given
+// PlayerSlice, so that the first two and the last two become the two sides of a doubles match.
+// Among the 3 possible splits, the one with the lowest MatchCost is chosen. With ties, the
+// earlier split in doublesSplits is preferred.
+//
+// The input PlayerSlice is expected to have been sorted by sortPlayerSliceByScorePriority() already.
+func (m CostModel) SplitDoublesSides(players model.PlayerSlice, start int) error {
+	return m.splitDoublesSides(players, start, FormatOpen, nil)
+}
+
+// separatesTeammates returns whether the split puts two teammates among the four players
+// on different sides.
+func separatesTeammates(four [4]*model.Player, split [4]int) bool {
+	for i := 0; i < 2; i++ {
+		for j := 2; j < 4; j++ {
+			if four[split[i]].Teammate() == four[split[j]] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitDoublesSides does the same as SplitDoublesSides, but only considers the splits whose sides
+// fit the given format. If no split fits the format, all splits are considered.
+// Splits which put the two players of a fixed partnership on different sides, or break the
+// constraints, are never chosen. Returns error if every split is ruled out this way.
+func (m CostModel) splitDoublesSides(players model.PlayerSlice, start int, format Format, constraints Constraints) error {
+	if start < 0 || start+3 >= len(players) {
+		return fmt.Errorf("start out of range, PlayerSlice length %d, you provided start(%d)", len(players), start)
+	}
+
+	four := [4]*model.Player{players[start], players[start+1], players[start+2], players[start+3]}
+	bestSplit := -1
+	bestFits := false
+	minCost := math.MaxFloat64
+	for idx, split := range doublesSplits {
+		if !constraints.allowsSplit(four, split) {
+			continue
+		}
+		fits := format.fitsSide(four[split[0]], four[split[1]]) && format.fitsSide(four[split[2]], four[split[3]])
+		if bestFits && !fits {
+			continue
+		}
+		cost := m.MatchCost(model.Match{
+			Side1: model.Side{Player1: four[split[0]], Player2: four[split[1]]},
+			Side2: model.Side{Player1: four[split[2]], Player2: four[split[3]]},
+		}).Total()
+		if bestSplit == -1 || (fits && !bestFits) || cost < minCost {
+			bestSplit = idx
+			bestFits = fits
+			minCost = cost
+		}
+	}
+
+	if bestSplit == -1 {
+		return fmt.Errorf("players %s, %s, %s and %s cannot be split into sides without breaking the constraints %v",
+			four[0].Name, four[1].Name, four[2].Name, four[3].Name, constraints)
+	}
+	for i, j := range doublesSplits[bestSplit] {
+		players[start+i] = four[j]
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package arranger
 
 import (
+	"fmt"
+	"math/rand"
+	"reflect"
 	"testing"
 
 	"github.com/yushenli/badminton_match_table/pkg/model"
@@ -422,3 +425,171 @@ func TestSeparateCompetedPlayers(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitDoublesSides(t *testing.T) {
+	cases := []struct {
+		title     string
+		partners  []map[int]int
+		opponents []map[int]int
+		expected  []string
+	}{
+		{
+			"NonePlayed",
+			[]map[int]int{{}, {}, {}, {}},
+			[]map[int]int{{}, {}, {}, {}},
+			[]string{"Name1", "Name2", "Name3", "Name4"},
+		},
+		{
+			"First2WerePartners",
+			[]map[int]int{{1: 1}, {0: 1}, {}, {}},
+			[]map[int]int{{}, {}, {}, {}},
+			[]string{"Name1", "Name3", "Name2", "Name4"},
+		},
+		{
+			"First2WereOpponents",
+			[]map[int]int{{}, {}, {}, {}},
+			[]map[int]int{{1: 1}, {0: 1}, {}, {}},
+			[]string{"Name1", "Name2", "Name3", "Name4"},
+		},
+		{
+			"OpponentsBreakPartnerTies",
+			[]map[int]int{{1: 1}, {0: 1}, {}, {}},
+			[]map[int]int{{1: 1, 3: 1}, {0: 1, 2: 1}, {1: 1}, {0: 1}},
+			[]string{"Name1", "Name4", "Name2", "Name3"},
+		},
+		{
+			"PartnersOutweighOpponents",
+			[]map[int]int{{1: 1, 2: 1}, {0: 1}, {0: 1}, {}},
+			[]map[int]int{{2: 3, 1: 3}, {0: 3}, {0: 3}, {}},
+			[]string{"Name1", "Name4", "Name2", "Name3"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			var players model.PlayerSlice
+			for i := 0; i < 4; i++ {
+				players = append(players, &model.Player{
+					Name:      fmt.Sprintf("Name%d", i+1),
+					Score:     float32(4 - i),
+					Partners:  make(map[*model.Player]int),
+					Opponents: make(map[*model.Player]int),
+				})
+			}
+			for i := range players {
+				for key, val := range tc.partners[i] {
+					players[i].Partners[players[key]] = val
+				}
+				for key, val := range tc.opponents[i] {
+					players[i].Opponents[players[key]] = val
+				}
+			}
+
+			err := SplitDoublesSides(players, 0)
+			if err != nil {
+				t.Errorf("Not expected error but got: %v", err)
+				return
+			}
+
+			gotNames := []string{}
+			for _, player := range players {
+				gotNames = append(gotNames, player.Name)
+			}
+			if !reflect.DeepEqual(tc.expected, gotNames) {
+				t.Errorf("Different order of players returned, expected %+v got %+v", tc.expected, gotNames)
+			}
+		})
+	}
+}
+
+func randomBand(rng *rand.Rand, size int) model.PlayerSlice {
+	var players model.PlayerSlice
+	for i := 0; i < size; i++ {
+		players = append(players, &model.Player{
+			Name:      fmt.Sprintf("Name%d", i),
+			Score:     float32(rng.Intn(5)),
+			Partners:  make(map[*model.Player]int),
+			Opponents: make(map[*model.Player]int),
+		})
+	}
+	for i := 0; i < size; i++ {
+		for j := i + 1; j < size; j++ {
+			if met := rng.Intn(4) - 1; met > 0 {
+				players[i].Partners[players[j]] = met
+				players[j].Partners[players[i]] = met
+			}
+		}
+	}
+	SortPlayerSliceByScorePriority(players)
+	return players
+}
+
+func totalTimesMet(players model.PlayerSlice) int {
+	total := 0
+	for i := 0; i+1 < len(players); i += 2 {
+		total += players[i].TimesMet(players[i+1])
+	}
+	return total
+}
+
+// totalReversedScores returns how much the scores are reversed between the pairs of consecutive players.
+func totalReversedScores(players model.PlayerSlice) float32 {
+	var pairs [][2]int
+	for i := 0; i+1 < len(players); i += 2 {
+		pairs = append(pairs, [2]int{i, i + 1})
+	}
+	return reversedScores(players, pairs)
+}
+
+func TestSeparateCompetedPlayersAgainstExhaustiveSearch(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	for round := 0; round < 200; round++ {
+		size := 2 * (1 + rng.Intn(5))
+		endFixed := round%2 == 1
+		players := randomBand(rng, size)
+		expected := make(model.PlayerSlice, size)
+		copy(expected, players)
+
+		err := SeparateCompetedPlayers(players, 0, size-1, endFixed)
+		if err != nil {
+			t.Fatalf("Not expected error but got: %v", err)
+		}
+		separateCompetedPlayersExhaustively(expected, 0, size-1, endFixed)
+
+		if endFixed && players[size-1] != expected[size-1] {
+			t.Fatalf("The last player was moved: expected %s got %s", expected[size-1].Name, players[size-1].Name)
+		}
+		if totalTimesMet(players) != totalTimesMet(expected) {
+			t.Fatalf("Unexpected total times met for a band of %d (endFixed %v), expected %d got %d",
+				size, endFixed, totalTimesMet(expected), totalTimesMet(players))
+		}
+		if totalReversedScores(players) != totalReversedScores(expected) {
+			t.Fatalf("Unexpected total reversed scores for a band of %d (endFixed %v), expected %v got %v",
+				size, endFixed, totalReversedScores(expected), totalReversedScores(players))
+		}
+	}
+}
+
+func BenchmarkSeparateCompetedPlayers(b *testing.B) {
+	for _, size := range []int{8, 16, 32, 64} {
+		b.Run(fmt.Sprintf("Matching%d", size), func(b *testing.B) {
+			band := randomBand(rand.New(rand.NewSource(1)), size)
+			players := make(model.PlayerSlice, size)
+			for i := 0; i < b.N; i++ {
+				copy(players, band)
+				SeparateCompetedPlayers(players, 0, size-1, false)
+			}
+		})
+		b.Run(fmt.Sprintf("Exhaustive%d", size), func(b *testing.B) {
+			if size > 16 {
+				b.Skipf("Exhaustive search over %d players does not finish in reasonable time", size)
+			}
+			band := randomBand(rand.New(rand.NewSource(1)), size)
+			players := make(model.PlayerSlice, size)
+			for i := 0; i < b.N; i++ {
+				copy(players, band)
+				separateCompetedPlayersExhaustively(players, 0, size-1, false)
+			}
+		})
+	}
+}
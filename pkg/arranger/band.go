@@ -120,6 +120,11 @@ func findSeparateRanges(players model.PlayerSlice, clusters []float32) []separat
 	return ranges
 }
 
+// SeparateCompetedPlayersWithinBands is the same as DefaultCostModel.SeparateCompetedPlayersWithinBands.
+func SeparateCompetedPlayersWithinBands(allPlayers, playingPlayers model.PlayerSlice) error {
+	return DefaultCostModel.SeparateCompetedPlayersWithinBands(allPlayers, playingPlayers)
+}
+
 // SeparateCompetedPlayersWithinBands scans a given sorted player list and break them into bands.
 // For each band, it will call SeparateCompetedPlayers to rearrange (in place) the players within the band,
 // so that people with similar scores will be shuffled in a way that everyone will play with an opponent
@@ -128,15 +133,25 @@ func findSeparateRanges(players model.PlayerSlice, clusters []float32) []separat
 // For a given list of players, the bands are determined using hierarchical clustering. The max distance
 // within clusters is the difference between the highest score and lowest score divided by
 // Max(6, Player Count / 2), or 0.5, whichever is bigger.
-func SeparateCompetedPlayersWithinBands(allPlayers, playingPlayers model.PlayerSlice) error {
-	clusters := clusterByScores(allPlayers)
+func (m CostModel) SeparateCompetedPlayersWithinBands(allPlayers, playingPlayers model.PlayerSlice) error {
+	return m.separateCompetedPlayersWithinBands(allPlayers, playingPlayers, HierarchicalBanding{}, nil, nil)
+}
+
+// separateCompetedPlayersWithinBands does the same as SeparateCompetedPlayersWithinBands with the
+// bands found by the given Banding, while honouring the constraints as separateCompetedPlayers does.
+// The bands and the ranges found, with the players as rearranged, are recorded in the explanation,
+// if it's not nil.
+func (m CostModel) separateCompetedPlayersWithinBands(allPlayers, playingPlayers model.PlayerSlice,
+	banding Banding, constraints Constraints, explanation *Explanation) error {
+	clusters := banding.Bands(allPlayers)
 	ranges := findSeparateRanges(playingPlayers, clusters)
 	for _, r := range ranges {
-		err := SeparateCompetedPlayers(playingPlayers, r.left, r.right, r.endFixed)
+		err := m.separateCompetedPlayers(playingPlayers, r.left, r.right, r.endFixed, constraints)
 		if err != nil {
 			log.Printf("Failed to separate competed players within bands among allPlayers %v and playingPlayers %v : %v", allPlayers, playingPlayers, err)
 		}
 	}
+	explanation.explainBands(clusters, ranges, playingPlayers)
 
 	return nil
 }
@@ -0,0 +1,37 @@
+package arranger
+
+import (
+	"github.com/yushenli/badminton_match_table/pkg/model"
+)
+
+func init() {
+	Register(DefaultArrangerName, BandedArranger{})
+}
+
+// BandedArranger is the default Arranger. It picks the players who have played the least,
+// sorts them by score, breaks them into score bands where players who have played together
+// are separated, and finally puts every consecutive 2 or 4 players into a match.
+type BandedArranger struct{}
+
+// Arrange implements Arranger.
+func (BandedArranger) Arrange(input Input) (model.MatchArrangement, error) {
+	playingPlayers, err := PickPlayersForCourts(input.Players, input.CourtCount)
+	if err != nil {
+		return nil, err
+	}
+
+	cost := input.CostModel()
+	SortPlayerSliceByScorePriority(playingPlayers)
+	err = cost.separateCompetedPlayersWithinBands(input.AllPlayers, playingPlayers, input.BandingStrategy(),
+		input.Constraints, input.Explanation)
+	if err != nil {
+		return nil, err
+	}
+
+	arrangement, err := makeMatchArrangements(input, playingPlayers)
+	if err != nil {
+		return nil, err
+	}
+	input.Explanation.explainArrangement(input, arrangement)
+	return arrangement, nil
+}
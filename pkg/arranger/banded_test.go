@@ -0,0 +1,95 @@
+package arranger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/yushenli/badminton_match_table/pkg/model"
+)
+
+func TestBandedArrangerArrange(t *testing.T) {
+	cases := []struct {
+		title           string
+		playerCount     int
+		courtCount      int
+		expectedMatches int
+		expectedPlayers int
+		expectedErr     bool
+	}{
+		{
+			"NotEnoughPlayers",
+			3,
+			2,
+			0,
+			0,
+			true,
+		},
+		{
+			"AllDoubles",
+			9,
+			2,
+			2,
+			8,
+			false,
+		},
+		{
+			"OneSingles",
+			6,
+			2,
+			2,
+			6,
+			false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			var players model.PlayerSlice
+			for i := 0; i < tc.playerCount; i++ {
+				players = append(players, &model.Player{
+					ID:        i + 1,
+					Name:      fmt.Sprintf("Name%d", i+1),
+					Score:     float32(i % 3),
+					Priority:  1.0,
+					Opponents: make(map[*model.Player]int),
+				})
+			}
+
+			matches, err := BandedArranger{}.Arrange(Input{
+				AllPlayers: players,
+				Players:    players,
+				CourtCount: tc.courtCount,
+				Seed:       1,
+			})
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("Expected error but got nil.")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+
+			if len(matches) != tc.expectedMatches {
+				t.Errorf("Unexpected number of matches, expected %d got %d", tc.expectedMatches, len(matches))
+			}
+			seen := make(map[*model.Player]bool)
+			for _, match := range matches {
+				for _, player := range []*model.Player{match.Side1.Player1, match.Side1.Player2, match.Side2.Player1, match.Side2.Player2} {
+					if player == nil {
+						continue
+					}
+					if seen[player] {
+						t.Errorf("Player %s is arranged more than once", player.Name)
+					}
+					seen[player] = true
+				}
+			}
+			if len(seen) != tc.expectedPlayers {
+				t.Errorf("Unexpected number of arranged players, expected %d got %d", tc.expectedPlayers, len(seen))
+			}
+		})
+	}
+}
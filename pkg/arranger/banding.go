@@ -0,0 +1,251 @@
+package arranger
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yushenli/badminton_match_table/pkg/model"
+)
+
+// Banding is a strategy that splits players into bands of similar scores. Within each band,
+// SeparateCompetedPlayersWithinBands rearranges the players to avoid repeated pairings.
+type Banding interface {
+	// Bands returns the lower bounds of the bands in descending order. Every bound is the lowest
+	// score in its band, so the last bound is the lowest score of all the players, and players
+	// of the same score are always in the same band.
+	Bands(players model.PlayerSlice) []float32
+	// String formats the Banding in the form accepted by ParseBanding.
+	String() string
+}
+
+// Banding names accepted by ParseBanding.
+const (
+	HierarchicalBandingName = "hierarchical"
+	FixedWidthBandingName   = "fixed"
+	QuantileBandingName     = "quantile"
+	KMeansBandingName       = "kmeans"
+)
+
+// BandingNames are the names of all the Banding strategies, the default one first.
+var BandingNames = []string{HierarchicalBandingName, FixedWidthBandingName, QuantileBandingName, KMeansBandingName}
+
+// Default parameters of the Banding strategies when ParseBanding is given only their names.
+const (
+	defaultBandWidth    = 1
+	defaultBandSize     = 4
+	defaultKMeansBandsK = 6
+)
+
+// ParseBanding parses a Banding from its name, optionally followed by a colon and its parameter:
+// "hierarchical", "fixed:<width>", "quantile:<size>" or "kmeans:<k>". An empty spec is the default
+// HierarchicalBanding.
+func ParseBanding(spec string) (Banding, error) {
+	spec = strings.TrimSpace(spec)
+	parts := strings.SplitN(spec, ":", 2)
+	name := strings.TrimSpace(parts[0])
+	param := ""
+	if len(parts) == 2 {
+		param = strings.TrimSpace(parts[1])
+	}
+
+	switch name {
+	case "", HierarchicalBandingName:
+		if param != "" {
+			return nil, fmt.Errorf("banding %q does not take a parameter", HierarchicalBandingName)
+		}
+		return HierarchicalBanding{}, nil
+	case FixedWidthBandingName:
+		width := float64(defaultBandWidth)
+		if param != "" {
+			var err error
+			width, err = strconv.ParseFloat(param, 32)
+			if err != nil || width <= 0 {
+				return nil, fmt.Errorf("invalid width of banding %q, a positive number is expected: %q", name, param)
+			}
+		}
+		return FixedWidthBanding{Width: float32(width)}, nil
+	case QuantileBandingName, KMeansBandingName:
+		n := defaultBandSize
+		if name == KMeansBandingName {
+			n = defaultKMeansBandsK
+		}
+		if param != "" {
+			var err error
+			n, err = strconv.Atoi(param)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid parameter of banding %q, a positive integer is expected: %q", name, param)
+			}
+		}
+		if name == KMeansBandingName {
+			return KMeansBanding{K: n}, nil
+		}
+		return QuantileBanding{Size: n}, nil
+	}
+	return nil, fmt.Errorf("unknown banding %q, expecting one of %v", name, BandingNames)
+}
+
+// HierarchicalBanding finds the bands with hierarchical clustering, see SeparateCompetedPlayersWithinBands.
+type HierarchicalBanding struct{}
+
+// Bands implements Banding.
+func (HierarchicalBanding) Bands(players model.PlayerSlice) []float32 {
+	return clusterByScores(players)
+}
+
+func (HierarchicalBanding) String() string {
+	return HierarchicalBandingName
+}
+
+// FixedWidthBanding puts players whose scores fall in the same multiple of Width, i.e. [0, Width),
+// [Width, 2*Width) and so on, into the same band.
+type FixedWidthBanding struct {
+	Width float32
+}
+
+// Bands implements Banding.
+func (b FixedWidthBanding) Bands(players model.PlayerSlice) []float32 {
+	return bandsOf(sortedScores(players), func(previous, score float32) bool {
+		return math.Floor(float64(previous/b.Width)) == math.Floor(float64(score/b.Width))
+	})
+}
+
+func (b FixedWidthBanding) String() string {
+	return fmt.Sprintf("%s:%g", FixedWidthBandingName, b.Width)
+}
+
+// QuantileBanding puts every Size players in descending score order into a band. A band grows
+// beyond Size when needed to keep players of the same score together.
+type QuantileBanding struct {
+	Size int
+}
+
+// Bands implements Banding.
+func (b QuantileBanding) Bands(players model.PlayerSlice) []float32 {
+	scores := sortedScores(players)
+	bounds := []float32{}
+	count := 0
+	for idx, score := range scores {
+		count++
+		if idx == len(scores)-1 || (count >= b.Size && scores[idx+1] != score) {
+			bounds = append(bounds, score)
+			count = 0
+		}
+	}
+	return bounds
+}
+
+func (b QuantileBanding) String() string {
+	return fmt.Sprintf("%s:%d", QuantileBandingName, b.Size)
+}
+
+// KMeansBanding splits the players into K bands minimising the sum of squared distances between
+// the scores and the mean score of their bands, which is solved exactly in one dimension. There
+// are fewer bands when there are fewer than K distinct scores.
+type KMeansBanding struct {
+	K int
+}
+
+// Bands implements Banding.
+func (b KMeansBanding) Bands(players model.PlayerSlice) []float32 {
+	scores := sortedScores(players)
+	if len(scores) == 0 {
+		return []float32{}
+	}
+
+	// Distinct scores in ascending order, weighted by how many players have them.
+	var values []float64
+	var weights []float64
+	for idx := len(scores) - 1; idx >= 0; idx-- {
+		if len(values) > 0 && values[len(values)-1] == float64(scores[idx]) {
+			weights[len(weights)-1]++
+			continue
+		}
+		values = append(values, float64(scores[idx]))
+		weights = append(weights, 1)
+	}
+	n := len(values)
+	k := b.K
+	if k > n {
+		k = n
+	}
+	if k < 1 {
+		k = 1
+	}
+
+	// Prefix sums of the weights, weighted values and weighted squares, so the sum of squared
+	// distances of values[i..j] to their mean is computed in constant time.
+	w := make([]float64, n+1)
+	s := make([]float64, n+1)
+	ss := make([]float64, n+1)
+	for idx := range values {
+		w[idx+1] = w[idx] + weights[idx]
+		s[idx+1] = s[idx] + weights[idx]*values[idx]
+		ss[idx+1] = ss[idx] + weights[idx]*values[idx]*values[idx]
+	}
+	cost := func(i, j int) float64 {
+		sum := s[j+1] - s[i]
+		return ss[j+1] - ss[i] - sum*sum/(w[j+1]-w[i])
+	}
+
+	// best[c][j] is the least cost of splitting values[0..j] into c+1 bands, and start[c][j]
+	// is where the last of those bands starts.
+	best := make([][]float64, k)
+	start := make([][]int, k)
+	for c := range best {
+		best[c] = make([]float64, n)
+		start[c] = make([]int, n)
+		for j := range best[c] {
+			if c == 0 {
+				best[c][j] = cost(0, j)
+				continue
+			}
+			best[c][j] = math.Inf(1)
+			for i := c; i <= j; i++ {
+				if candidate := best[c-1][i-1] + cost(i, j); candidate < best[c][j] {
+					best[c][j] = candidate
+					start[c][j] = i
+				}
+			}
+		}
+	}
+
+	var bounds []float32
+	j := n - 1
+	for c := k - 1; c >= 0; c-- {
+		i := start[c][j]
+		bounds = append(bounds, float32(values[i]))
+		j = i - 1
+	}
+	return bounds
+}
+
+func (b KMeansBanding) String() string {
+	return fmt.Sprintf("%s:%d", KMeansBandingName, b.K)
+}
+
+// sortedScores returns the scores of the players in descending order.
+func sortedScores(players model.PlayerSlice) []float32 {
+	scores := make([]float32, len(players))
+	for idx, player := range players {
+		scores[idx] = player.Score
+	}
+	sort.Slice(scores, func(i, j int) bool { return scores[i] > scores[j] })
+	return scores
+}
+
+// bandsOf returns the lower bounds of the bands of scores in descending order, where a score
+// joins the current band as long as sameBand holds between the previous score and it.
+func bandsOf(scores []float32, sameBand func(previous, score float32) bool) []float32 {
+	bounds := []float32{}
+	for idx, score := range scores {
+		if idx == 0 || !sameBand(scores[idx-1], score) {
+			bounds = append(bounds, score)
+			continue
+		}
+		bounds[len(bounds)-1] = score
+	}
+	return bounds
+}
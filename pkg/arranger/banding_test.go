@@ -0,0 +1,141 @@
+package arranger
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBandings(t *testing.T) {
+	fixtures := []struct {
+		title  string
+		scores []float32
+	}{
+		{"EmptySlice", []float32{}},
+		{"SinglePlayer", []float32{1.23}},
+		{"4Players1FarOff", []float32{4.0, 3.0, 2.0, -8.0}},
+		{"4PlayersSomeSame", []float32{4.0, 3.0, 2.0, 2.0}},
+		{"8PlayersBandSizeGreaterThan1", []float32{1.0, 2.0, 2.1, 2.0, 5.0, 6.0, 6.2, 8}},
+	}
+	cases := []struct {
+		title   string
+		banding Banding
+		// expected are the bands found among the players of each fixture, by its title.
+		expected map[string][]float32
+	}{
+		{
+			"Hierarchical",
+			HierarchicalBanding{},
+			map[string][]float32{
+				"EmptySlice":                   {},
+				"SinglePlayer":                 {1.23},
+				"4Players1FarOff":              {2.0, -8.0},
+				"4PlayersSomeSame":             {4.0, 3.0, 2.0},
+				"8PlayersBandSizeGreaterThan1": {8.0, 6.0, 5.0, 1.0},
+			},
+		},
+		{
+			"FixedWidth",
+			FixedWidthBanding{Width: 2},
+			map[string][]float32{
+				"EmptySlice":                   {},
+				"SinglePlayer":                 {1.23},
+				"4Players1FarOff":              {4.0, 2.0, -8.0},
+				"4PlayersSomeSame":             {4.0, 2.0},
+				"8PlayersBandSizeGreaterThan1": {8.0, 6.0, 5.0, 2.0, 1.0},
+			},
+		},
+		{
+			"Quantile",
+			QuantileBanding{Size: 2},
+			map[string][]float32{
+				"EmptySlice":                   {},
+				"SinglePlayer":                 {1.23},
+				"4Players1FarOff":              {3.0, -8.0},
+				"4PlayersSomeSame":             {3.0, 2.0},
+				"8PlayersBandSizeGreaterThan1": {6.2, 5.0, 2.0, 1.0},
+			},
+		},
+		{
+			"KMeans2",
+			KMeansBanding{K: 2},
+			map[string][]float32{
+				"EmptySlice":                   {},
+				"SinglePlayer":                 {1.23},
+				"4Players1FarOff":              {2.0, -8.0},
+				"4PlayersSomeSame":             {3.0, 2.0},
+				"8PlayersBandSizeGreaterThan1": {5.0, 1.0},
+			},
+		},
+		{
+			"KMeans3",
+			KMeansBanding{K: 3},
+			map[string][]float32{
+				"EmptySlice":                   {},
+				"SinglePlayer":                 {1.23},
+				"4Players1FarOff":              {3.0, 2.0, -8.0},
+				"4PlayersSomeSame":             {4.0, 3.0, 2.0},
+				"8PlayersBandSizeGreaterThan1": {8.0, 5.0, 1.0},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		for _, fixture := range fixtures {
+			t.Run(tc.title+"/"+fixture.title, func(t *testing.T) {
+				expected, ok := tc.expected[fixture.title]
+				if !ok {
+					t.Fatalf("No bands are expected for fixture %s", fixture.title)
+				}
+				bands := tc.banding.Bands(swissPlayers(fixture.scores, nil))
+				if !reflect.DeepEqual(expected, bands) {
+					t.Errorf("Unexpected bands, expected %v got %v", expected, bands)
+				}
+			})
+		}
+	}
+}
+
+func TestParseBanding(t *testing.T) {
+	cases := []struct {
+		title       string
+		spec        string
+		expected    Banding
+		expectedErr bool
+	}{
+		{"Empty", "", HierarchicalBanding{}, false},
+		{"Hierarchical", "hierarchical", HierarchicalBanding{}, false},
+		{"FixedDefault", "fixed", FixedWidthBanding{Width: 1}, false},
+		{"Fixed", " fixed : 1.5 ", FixedWidthBanding{Width: 1.5}, false},
+		{"QuantileDefault", "quantile", QuantileBanding{Size: 4}, false},
+		{"Quantile", "quantile:6", QuantileBanding{Size: 6}, false},
+		{"KMeansDefault", "kmeans", KMeansBanding{K: 6}, false},
+		{"KMeans", "kmeans:3", KMeansBanding{K: 3}, false},
+		{"HierarchicalWithParameter", "hierarchical:2", nil, true},
+		{"NegativeWidth", "fixed:-1", nil, true},
+		{"FractionalSize", "quantile:1.5", nil, true},
+		{"ZeroK", "kmeans:0", nil, true},
+		{"Unknown", "jenks", nil, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			banding, err := ParseBanding(tc.spec)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("Expected error but got nil.")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			if !reflect.DeepEqual(tc.expected, banding) {
+				t.Errorf("Unexpected banding, expected %v got %v", tc.expected, banding)
+			}
+			if parsed, _ := ParseBanding(banding.String()); !reflect.DeepEqual(banding, parsed) {
+				t.Errorf("Unexpected banding parsed from %q, expected %v got %v", banding.String(), banding, parsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package arranger
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CatchUpPolicy decides how many games a player who checks in late is credited with,
+// so they are not picked for every round until their game count catches up with the field.
+type CatchUpPolicy string
+
+// Supported catch-up policies.
+const (
+	// CatchUpNone credits nothing, late arrivals start with 0 games.
+	CatchUpNone CatchUpPolicy = ""
+	// CatchUpMedian credits the median games played by the field before the check-in.
+	CatchUpMedian CatchUpPolicy = "median"
+	// CatchUpElapsed credits the games the player would have played in the rounds before the
+	// check-in, had they played at the same rate as the players checked in at the time.
+	CatchUpElapsed CatchUpPolicy = "elapsed"
+)
+
+// CatchUpPolicies lists all the supported catch-up policies.
+var CatchUpPolicies = []CatchUpPolicy{CatchUpNone, CatchUpMedian, CatchUpElapsed}
+
+// ParseCatchUpPolicy returns the CatchUpPolicy of the given name.
+func ParseCatchUpPolicy(name string) (CatchUpPolicy, error) {
+	for _, policy := range CatchUpPolicies {
+		if string(policy) == name {
+			return policy, nil
+		}
+	}
+	return CatchUpNone, fmt.Errorf("unknown catch-up policy %q", name)
+}
+
+// Credit returns the games credited to a player who checked in late.
+// fieldGames are the games played before the check-in by each of the other players who had
+// checked in by then. playShares are, for each round before the check-in, the fraction of the
+// checked-in players who played in that round.
+func (p CatchUpPolicy) Credit(fieldGames []float32, playShares []float32) float32 {
+	switch p {
+	case CatchUpMedian:
+		return median(fieldGames)
+	case CatchUpElapsed:
+		var credit float32
+		for _, share := range playShares {
+			credit += share
+		}
+		return credit
+	}
+	return 0
+}
+
+// median returns the median of the values, or 0 if there is none.
+func median(values []float32) float32 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float32(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
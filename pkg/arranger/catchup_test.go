@@ -0,0 +1,63 @@
+package arranger
+
+import (
+	"testing"
+)
+
+func TestParseCatchUpPolicy(t *testing.T) {
+	cases := []struct {
+		title       string
+		name        string
+		expected    CatchUpPolicy
+		expectedErr bool
+	}{
+		{"None", "", CatchUpNone, false},
+		{"Median", "median", CatchUpMedian, false},
+		{"Elapsed", "elapsed", CatchUpElapsed, false},
+		{"Unknown", "mean", CatchUpNone, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			policy, err := ParseCatchUpPolicy(tc.name)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("Expected error but got nil.")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			if policy != tc.expected {
+				t.Errorf("Unexpected catch-up policy, expected %q got %q", tc.expected, policy)
+			}
+		})
+	}
+}
+
+func TestCatchUpPolicyCredit(t *testing.T) {
+	cases := []struct {
+		title      string
+		policy     CatchUpPolicy
+		fieldGames []float32
+		playShares []float32
+		expected   float32
+	}{
+		{"None", CatchUpNone, []float32{3, 4, 5}, []float32{1, 1}, 0},
+		{"MedianOdd", CatchUpMedian, []float32{5, 3, 4}, nil, 4},
+		{"MedianEven", CatchUpMedian, []float32{5, 3, 4, 1}, nil, 3.5},
+		{"MedianEmptyField", CatchUpMedian, nil, nil, 0},
+		{"Elapsed", CatchUpElapsed, nil, []float32{1, 0.5, 0.75}, 2.25},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			credit := tc.policy.Credit(tc.fieldGames, tc.playShares)
+			if credit != tc.expected {
+				t.Errorf("Unexpected credit, expected %v got %v", tc.expected, credit)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package arranger
+
+import (
+	"fmt"
+
+	"github.com/yushenli/badminton_match_table/pkg/model"
+)
+
+// ConstraintKind decides what two players must not do in the same round.
+type ConstraintKind string
+
+// Supported constraint kinds.
+const (
+	// NeverPartner keeps the two players off the same side.
+	NeverPartner ConstraintKind = "never_partner"
+	// NeverOppose keeps the two players off the opposite sides of a match.
+	NeverOppose ConstraintKind = "never_oppose"
+	// NeverMeet keeps the two players out of the same match.
+	NeverMeet ConstraintKind = "never_meet"
+)
+
+// ConstraintKinds lists all the supported constraint kinds.
+var ConstraintKinds = []ConstraintKind{NeverPartner, NeverOppose, NeverMeet}
+
+// ParseConstraintKind returns the ConstraintKind of the given name.
+func ParseConstraintKind(name string) (ConstraintKind, error) {
+	for _, kind := range ConstraintKinds {
+		if string(kind) == name {
+			return kind, nil
+		}
+	}
+	return "", fmt.Errorf("unknown constraint kind %q", name)
+}
+
+// Constraint is a hard constraint between two players, which an arrangement must never break.
+type Constraint struct {
+	Kind    ConstraintKind
+	Player1 *model.Player
+	Player2 *model.Player
+}
+
+// String returns a human readable form of the constraint.
+func (c Constraint) String() string {
+	return fmt.Sprintf("%s %s %s", c.Player1.Name, c.Kind, c.Player2.Name)
+}
+
+// involves returns whether the constraint is between the two given players.
+func (c Constraint) involves(a, b *model.Player) bool {
+	return (c.Player1 == a && c.Player2 == b) || (c.Player1 == b && c.Player2 == a)
+}
+
+// Constraints is a set of hard constraints between players.
+type Constraints []Constraint
+
+// find returns the first constraint between the two players that is one of the given kinds, or nil.
+func (cs Constraints) find(a, b *model.Player, kinds ...ConstraintKind) *Constraint {
+	for idx := range cs {
+		if !cs[idx].involves(a, b) {
+			continue
+		}
+		for _, kind := range kinds {
+			if cs[idx].Kind == kind {
+				return &cs[idx]
+			}
+		}
+	}
+	return nil
+}
+
+// canPartner returns whether the two players can be on the same side.
+func (cs Constraints) canPartner(a, b *model.Player) bool {
+	return cs.find(a, b, NeverPartner, NeverMeet) == nil
+}
+
+// canOppose returns whether the two players can be on the opposite sides of a match.
+func (cs Constraints) canOppose(a, b *model.Player) bool {
+	return cs.find(a, b, NeverOppose, NeverMeet) == nil
+}
+
+// canMeet returns whether the two players can be in the same match, on whichever sides.
+func (cs Constraints) canMeet(a, b *model.Player) bool {
+	return cs.find(a, b, NeverMeet) == nil
+}
+
+// Check returns an error naming the first constraint broken by the arrangement, or nil.
+func (cs Constraints) Check(arrangement model.MatchArrangement) error {
+	for idx, match := range arrangement {
+		for _, side := range []model.Side{match.Side1, match.Side2} {
+			if side.Player1 == nil || side.Player2 == nil {
+				continue
+			}
+			if c := cs.find(side.Player1, side.Player2, NeverPartner, NeverMeet); c != nil {
+				return fmt.Errorf("match %d breaks constraint %s", idx+1, c)
+			}
+		}
+		for _, player1 := range sidePlayers(match.Side1) {
+			for _, player2 := range sidePlayers(match.Side2) {
+				if c := cs.find(player1, player2, NeverOppose, NeverMeet); c != nil {
+					return fmt.Errorf("match %d breaks constraint %s", idx+1, c)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// canJoin returns whether the group of players can be added to a block of players for a match
+// of the given size, i.e. 2 for singles and 4 for doubles. In singles all the players oppose
+// each other, while in doubles whether they partner or oppose is left to the split of sides.
+func (cs Constraints) canJoin(block, group model.PlayerSlice, size int) bool {
+	for i, a := range group {
+		others := append(append(model.PlayerSlice(nil), block...), group[i+1:]...)
+		for _, b := range others {
+			if size == 2 && !cs.canOppose(a, b) {
+				return false
+			}
+			if !cs.canMeet(a, b) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// allowsSplit returns whether splitting the 4 players by split keeps the teams together and
+// breaks none of the constraints.
+func (cs Constraints) allowsSplit(four [4]*model.Player, split [4]int) bool {
+	if separatesTeammates(four, split) {
+		return false
+	}
+	if !cs.canPartner(four[split[0]], four[split[1]]) || !cs.canPartner(four[split[2]], four[split[3]]) {
+		return false
+	}
+	for i := 0; i < 2; i++ {
+		for j := 2; j < 4; j++ {
+			if !cs.canOppose(four[split[i]], four[split[j]]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// allowsBlock returns whether the players can form a match without breaking the constraints.
+func (cs Constraints) allowsBlock(block model.PlayerSlice) bool {
+	if len(block) == 2 {
+		return cs.canJoin(block[:1], block[1:], 2)
+	}
+	four := [4]*model.Player{block[0], block[1], block[2], block[3]}
+	for _, split := range doublesSplits {
+		if cs.allowsSplit(four, split) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxBlockSearchSteps bounds searchBlocks, so a large unsatisfiable round fails in reasonable time.
+const maxBlockSearchSteps = 100000
+
+// searchBlocks looks for a way to put all the players in the queue into matches of the given
+// sizes without breaking the constraints, trying the players in the order of the queue. The
+// blocks are returned in the order of sizes.
+// When respectTeams is set, the two players of a fixed partnership are put together into a
+// doubles match and not into a singles match, as takeBlock does. The format is not taken into
+// account. It's used when taking the blocks one by one fails to find one.
+func (cs Constraints) searchBlocks(queue model.PlayerSlice, sizes []int, respectTeams bool) ([]model.PlayerSlice, error) {
+	steps := 0
+	var search func(queue model.PlayerSlice, sizes []int) []model.PlayerSlice
+	search = func(queue model.PlayerSlice, sizes []int) []model.PlayerSlice {
+		if len(sizes) == 0 {
+			return []model.PlayerSlice{}
+		}
+		if len(queue) == 0 {
+			return nil
+		}
+
+		// groupOf returns the player together with their teammate if they have to be kept
+		// together, or nil if the player cannot join a match of the given size.
+		groupOf := func(player *model.Player, size int) model.PlayerSlice {
+			if mate := player.Teammate(); respectTeams && mate != nil && containsPlayer(queue, mate) {
+				if size == 2 {
+					return nil
+				}
+				return model.PlayerSlice{player, mate}
+			}
+			return model.PlayerSlice{player}
+		}
+
+		// The first player in the queue has to play in some match. Try them in a match of every
+		// size, with all the combinations of the other players to join them.
+		tried := make(map[int]bool)
+		for k, size := range sizes {
+			if tried[size] {
+				continue
+			}
+			tried[size] = true
+			group := groupOf(queue[0], size)
+			if group == nil || len(group) > size || !cs.canJoin(nil, group, size) {
+				continue
+			}
+			restSizes := append(append([]int(nil), sizes[:k]...), sizes[k+1:]...)
+
+			var choose func(block model.PlayerSlice, from int) []model.PlayerSlice
+			choose = func(block model.PlayerSlice, from int) []model.PlayerSlice {
+				steps++
+				if steps > maxBlockSearchSteps {
+					return nil
+				}
+				if len(block) == size {
+					if !cs.allowsBlock(block) {
+						return nil
+					}
+					var rest model.PlayerSlice
+					for _, player := range queue {
+						if !containsPlayer(block, player) {
+							rest = append(rest, player)
+						}
+					}
+					blocks := search(rest, restSizes)
+					if blocks == nil {
+						return nil
+					}
+					ret := append(append([]model.PlayerSlice(nil), blocks[:k]...), block)
+					return append(ret, blocks[k:]...)
+				}
+				for idx := from; idx < len(queue); idx++ {
+					if containsPlayer(block, queue[idx]) {
+						continue
+					}
+					group := groupOf(queue[idx], size)
+					if group == nil || len(block)+len(group) > size || !cs.canJoin(block, group, size) {
+						continue
+					}
+					next := append(append(model.PlayerSlice(nil), block...), group...)
+					if blocks := choose(next, idx+1); blocks != nil {
+						return blocks
+					}
+				}
+				return nil
+			}
+			if blocks := choose(group, 1); blocks != nil {
+				return blocks
+			}
+		}
+		return nil
+	}
+
+	blocks := search(queue, sizes)
+	if blocks == nil {
+		if steps > maxBlockSearchSteps {
+			return nil, fmt.Errorf("gave up looking for an arrangement of the %d picked players satisfying the constraints %v",
+				len(queue), cs)
+		}
+		return nil, fmt.Errorf("no arrangement of the %d picked players satisfies the constraints %v", len(queue), cs)
+	}
+	return blocks, nil
+}
+
+// containsPlayer returns whether the player is in players.
+func containsPlayer(players model.PlayerSlice, player *model.Player) bool {
+	for _, p := range players {
+		if p == player {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,262 @@
+package arranger
+
+import (
+	"testing"
+
+	"github.com/yushenli/badminton_match_table/pkg/model"
+)
+
+func TestParseConstraintKind(t *testing.T) {
+	cases := []struct {
+		title       string
+		name        string
+		expected    ConstraintKind
+		expectedErr bool
+	}{
+		{"NeverPartner", "never_partner", NeverPartner, false},
+		{"NeverOppose", "never_oppose", NeverOppose, false},
+		{"NeverMeet", "never_meet", NeverMeet, false},
+		{"Unknown", "never", "", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			kind, err := ParseConstraintKind(tc.name)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("Expected error but got nil.")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			if kind != tc.expected {
+				t.Errorf("Unexpected constraint kind, expected %q got %q", tc.expected, kind)
+			}
+		})
+	}
+}
+
+func TestConstraintsCheck(t *testing.T) {
+	players := makeTeamPlayers(4, nil)
+	arrangement := model.MatchArrangement{
+		{
+			Side1: model.Side{Player1: players[0], Player2: players[1]},
+			Side2: model.Side{Player1: players[2], Player2: players[3]},
+		},
+	}
+
+	cases := []struct {
+		title       string
+		constraint  Constraint
+		expectedErr bool
+	}{
+		{"PartnersNeverPartner", Constraint{NeverPartner, players[1], players[0]}, true},
+		{"PartnersNeverOppose", Constraint{NeverOppose, players[0], players[1]}, false},
+		{"OpponentsNeverPartner", Constraint{NeverPartner, players[0], players[2]}, false},
+		{"OpponentsNeverOppose", Constraint{NeverOppose, players[3], players[0]}, true},
+		{"PartnersNeverMeet", Constraint{NeverMeet, players[2], players[3]}, true},
+		{"OpponentsNeverMeet", Constraint{NeverMeet, players[1], players[2]}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			err := Constraints{tc.constraint}.Check(arrangement)
+			if tc.expectedErr && err == nil {
+				t.Errorf("Expected error but got nil.")
+			}
+			if !tc.expectedErr && err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+			}
+		})
+	}
+}
+
+func TestMakeMatchArrangementsWithConstraints(t *testing.T) {
+	type pair struct {
+		kind ConstraintKind
+		i, j int
+	}
+	cases := []struct {
+		title       string
+		count       int
+		courtCount  int
+		teams       [][2]int
+		constraints []pair
+		expectedErr bool
+	}{
+		{
+			"NeverPartnerAdjacent",
+			4, 1, nil,
+			[]pair{{NeverPartner, 0, 1}},
+			false,
+		},
+		{
+			"NeverOpposeAndNeverPartner",
+			4, 1, nil,
+			[]pair{{NeverOppose, 0, 1}, {NeverPartner, 0, 2}},
+			false,
+		},
+		{
+			"NeverMeetAcrossBlocks",
+			8, 2, nil,
+			[]pair{{NeverMeet, 0, 1}, {NeverMeet, 0, 2}, {NeverMeet, 0, 3}, {NeverMeet, 4, 5}},
+			false,
+		},
+		{
+			"NeverMeetInSingles",
+			6, 2, nil,
+			[]pair{{NeverMeet, 0, 1}, {NeverOppose, 0, 2}, {NeverOppose, 1, 2}},
+			false,
+		},
+		{
+			// Name1 cannot play singles against Name2, who can only play singles against Name3,
+			// whichever order the singles and doubles blocks are taken in.
+			"MixedBlockSizes",
+			6, 2, nil,
+			[]pair{{NeverOppose, 0, 1}, {NeverMeet, 1, 3}, {NeverMeet, 1, 4}, {NeverMeet, 1, 5}},
+			false,
+		},
+		{
+			"TeamAndNeverPartner",
+			4, 1, [][2]int{{0, 2}},
+			[]pair{{NeverPartner, 0, 1}},
+			false,
+		},
+		{
+			"UnsatisfiableNeverMeet",
+			4, 1, nil,
+			[]pair{{NeverMeet, 0, 3}},
+			true,
+		},
+		{
+			"UnsatisfiableSides",
+			4, 1, nil,
+			[]pair{{NeverPartner, 0, 1}, {NeverPartner, 0, 2}, {NeverPartner, 0, 3}},
+			true,
+		},
+		{
+			"UnsatisfiableWithTeam",
+			4, 1, [][2]int{{0, 1}},
+			[]pair{{NeverOppose, 0, 2}, {NeverOppose, 1, 3}},
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			players := makeTeamPlayers(tc.count, tc.teams)
+			var constraints Constraints
+			for _, c := range tc.constraints {
+				constraints = append(constraints, Constraint{c.kind, players[c.i], players[c.j]})
+			}
+
+			// The seed decides the order of the singles and doubles blocks.
+			for seed := 1; seed <= 3; seed++ {
+				matches, err := makeMatchArrangements(Input{CourtCount: tc.courtCount, Seed: seed, Constraints: constraints}, players)
+				if tc.expectedErr {
+					if err == nil {
+						t.Errorf("Expected error with seed %d but got nil.", seed)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("Not expecting error with seed %d but got %v.", seed, err)
+					continue
+				}
+				if err := constraints.Check(matches); err != nil {
+					t.Errorf("Unexpected broken constraint with seed %d: %v", seed, err)
+				}
+			}
+		})
+	}
+}
+
+func TestBandedArrangerArrangeWithConstraints(t *testing.T) {
+	players := makeTeamPlayers(8, nil)
+	constraints := Constraints{
+		{NeverMeet, players[0], players[1]},
+		{NeverMeet, players[2], players[3]},
+		{NeverPartner, players[4], players[5]},
+		{NeverOppose, players[6], players[7]},
+	}
+
+	matches, err := BandedArranger{}.Arrange(Input{
+		AllPlayers:  players,
+		Players:     players,
+		CourtCount:  2,
+		Seed:        1,
+		Constraints: constraints,
+	})
+	if err != nil {
+		t.Errorf("Not expecting error but got %v.", err)
+		return
+	}
+	if err := constraints.Check(matches); err != nil {
+		t.Errorf("Unexpected broken constraint: %v", err)
+	}
+}
+
+func TestMakeMatchArrangementsWithConstraintsAndTeams(t *testing.T) {
+	type pair struct {
+		i, j int
+	}
+	cases := []struct {
+		title         string
+		count         int
+		teams         [][2]int
+		neverMeet     []pair
+		expectedSplit bool
+	}{
+		{
+			// Taking the blocks one by one leaves Name5 with Name6 and Name7, so the blocks are
+			// searched, which should still keep Name1 and Name8 together.
+			"TeamKeptBySearch",
+			8,
+			[][2]int{{0, 7}},
+			[]pair{{4, 5}, {4, 6}},
+			false,
+		},
+		{
+			"TeamSplitBySearch",
+			8,
+			[][2]int{{0, 1}},
+			[]pair{{0, 2}, {0, 3}, {0, 4}, {1, 5}, {1, 6}, {1, 7}},
+			true,
+		},
+		{
+			// Name1 and Name2 must not be put against each other in singles, but play doubles
+			// together while Name5 plays singles.
+			"TeamKeptOutOfSingles",
+			6,
+			[][2]int{{0, 1}},
+			[]pair{{0, 4}},
+			false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			players := makeTeamPlayers(tc.count, tc.teams)
+			var constraints Constraints
+			for _, p := range tc.neverMeet {
+				constraints = append(constraints, Constraint{NeverMeet, players[p.i], players[p.j]})
+			}
+
+			matches, err := makeMatchArrangements(Input{CourtCount: 2, Seed: 1, Constraints: constraints}, players)
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			if err := constraints.Check(matches); err != nil {
+				t.Errorf("Unexpected broken constraint: %v", err)
+			}
+			split := splitTeams(matches)
+			if (len(split) > 0) != tc.expectedSplit {
+				t.Errorf("Unexpected split teams, expected split %v got %+v", tc.expectedSplit, split)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package arranger
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/yushenli/badminton_match_table/pkg/model"
+)
+
+// CostModel holds the weights used to evaluate an arrangement. The lower the cost, the better
+// the arrangement. Score gaps are measured in squared scores.
+type CostModel struct {
+	// RepeatPartner is the cost for every time the two players of a side have been partners before.
+	RepeatPartner float64
+	// RepeatOpponent is the cost for every time two players on opposite sides have been opponents before.
+	RepeatOpponent float64
+	// ScoreGapInSide is the cost per squared score gap between the two players of a side.
+	ScoreGapInSide float64
+	// ScoreGapBetweenSides is the cost per squared gap between the average scores of the two sides.
+	ScoreGapBetweenSides float64
+	// RoundsWaited is the cost for every round a benched player has been waiting since their last match.
+	// It only counts when whole arrangements are compared, e.g. by PlanRounds. Who plays in a
+	// round is decided by PickPlayersForCourts, which does not take the weights into account.
+	RoundsWaited float64
+}
+
+// DefaultCostModel is the CostModel used when an event does not configure its own.
+// Repeated partners are avoided at almost any cost. Repeated opponents are avoided over the score
+// gaps only while the scores are close: the squared score gaps of a match whose players are at most
+// 4 points apart never differ between two splits of the match by more than a single repeated
+// opponent, but with wider score spreads a split with a repeated opponent may cost less.
+var DefaultCostModel = CostModel{
+	RepeatPartner:        100,
+	RepeatOpponent:       20,
+	ScoreGapInSide:       1,
+	ScoreGapBetweenSides: 1,
+	RoundsWaited:         10,
+}
+
+// costModelKeys maps the keys used by ParseCostModel and CostModel.String to the weights.
+var costModelKeys = []struct {
+	key    string
+	weight func(m *CostModel) *float64
+}{
+	{"partner", func(m *CostModel) *float64 { return &m.RepeatPartner }},
+	{"opponent", func(m *CostModel) *float64 { return &m.RepeatOpponent }},
+	{"side_gap", func(m *CostModel) *float64 { return &m.ScoreGapInSide }},
+	{"match_gap", func(m *CostModel) *float64 { return &m.ScoreGapBetweenSides }},
+	{"waited", func(m *CostModel) *float64 { return &m.RoundsWaited }},
+}
+
+// ParseCostModel parses a CostModel from a comma separated list of key=weight, e.g.
+// "partner=100,opponent=20,side_gap=1,match_gap=1,waited=10".
+// Weights which are not given keep the values in DefaultCostModel.
+func ParseCostModel(spec string) (CostModel, error) {
+	m := DefaultCostModel
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return m, fmt.Errorf("invalid cost model entry %q, key=weight is expected", entry)
+		}
+
+		key := strings.TrimSpace(parts[0])
+		weight, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil || weight < 0 {
+			return m, fmt.Errorf("invalid weight for %q, a non-negative number is expected: %q", key, parts[1])
+		}
+
+		found := false
+		for _, k := range costModelKeys {
+			if k.key == key {
+				*k.weight(&m) = weight
+				found = true
+			}
+		}
+		if !found {
+			return m, fmt.Errorf("unknown cost model key %q", key)
+		}
+	}
+	return m, nil
+}
+
+// String formats the CostModel in the form accepted by ParseCostModel.
+func (m CostModel) String() string {
+	entries := make([]string, len(costModelKeys))
+	for idx, k := range costModelKeys {
+		entries[idx] = fmt.Sprintf("%s=%g", k.key, *k.weight(&m))
+	}
+	return strings.Join(entries, ",")
+}
+
+// CostBreakdown is the cost of an arrangement, broken down into the terms of a CostModel.
+type CostBreakdown struct {
+	RepeatPartner        float64 `json:"repeat_partner"`
+	RepeatOpponent       float64 `json:"repeat_opponent"`
+	ScoreGapInSide       float64 `json:"score_gap_in_side"`
+	ScoreGapBetweenSides float64 `json:"score_gap_between_sides"`
+	RoundsWaited         float64 `json:"rounds_waited"`
+}
+
+// Total returns the sum of all the terms.
+func (b CostBreakdown) Total() float64 {
+	return b.RepeatPartner + b.RepeatOpponent + b.ScoreGapInSide + b.ScoreGapBetweenSides + b.RoundsWaited
+}
+
+func (b *CostBreakdown) add(other CostBreakdown) {
+	b.RepeatPartner += other.RepeatPartner
+	b.RepeatOpponent += other.RepeatOpponent
+	b.ScoreGapInSide += other.ScoreGapInSide
+	b.ScoreGapBetweenSides += other.ScoreGapBetweenSides
+	b.RoundsWaited += other.RoundsWaited
+}
+
+func sidePlayers(side model.Side) []*model.Player {
+	var players []*model.Player
+	if side.Player1 != nil {
+		players = append(players, side.Player1)
+	}
+	if side.Player2 != nil {
+		players = append(players, side.Player2)
+	}
+	return players
+}
+
+func sideScore(side model.Side) float64 {
+	players := sidePlayers(side)
+	if len(players) == 0 {
+		return 0
+	}
+	var total float64
+	for _, player := range players {
+		total += float64(player.Score)
+	}
+	return total / float64(len(players))
+}
+
+func squaredGap(a, b float64) float64 {
+	return (a - b) * (a - b)
+}
+
+// MatchCost returns the cost breakdown of a single match.
+func (m CostModel) MatchCost(match model.Match) CostBreakdown {
+	var b CostBreakdown
+	for _, side := range []model.Side{match.Side1, match.Side2} {
+		if side.Player1 != nil && side.Player2 != nil {
+			// A fixed partnership always plays together, so it is never counted as a repeat.
+			if side.Player1.Teammate() != side.Player2 {
+				b.RepeatPartner += m.RepeatPartner * float64(side.Player1.Partners[side.Player2])
+			}
+			b.ScoreGapInSide += m.ScoreGapInSide * squaredGap(float64(side.Player1.Score), float64(side.Player2.Score))
+		}
+	}
+	for _, player1 := range sidePlayers(match.Side1) {
+		for _, player2 := range sidePlayers(match.Side2) {
+			b.RepeatOpponent += m.RepeatOpponent * float64(player1.Opponents[player2])
+		}
+	}
+	b.ScoreGapBetweenSides = m.ScoreGapBetweenSides * squaredGap(sideScore(match.Side1), sideScore(match.Side2))
+	return b
+}
+
+// Breakdown returns the cost breakdown of an arrangement, where benched are the players who
+// are available but not arranged to play.
+func (m CostModel) Breakdown(arrangement model.MatchArrangement, benched model.PlayerSlice) CostBreakdown {
+	var b CostBreakdown
+	for _, match := range arrangement {
+		b.add(m.MatchCost(match))
+	}
+	for _, player := range benched {
+		b.RoundsWaited += m.RoundsWaited * float64(player.RoundsWaited)
+	}
+	return b
+}
+
+// pairCost returns the cost for two players to be paired by SeparateCompetedPlayers.
+// Paired players end up in the same match, either as partners or as opponents, so both kinds
+// of repeats count. The score gap is left to the tie-break of SeparateCompetedPlayers, and to
+// the split of the sides. Teammates of a fixed partnership cost nothing to be paired, as they
+// will end up on the same side anyway.
+func (m CostModel) pairCost(a, b *model.Player) float64 {
+	if a.Teammate() == b {
+		return 0
+	}
+	return m.RepeatPartner*float64(a.Partners[b]) + m.RepeatOpponent*float64(a.Opponents[b])
+}
+
+// balancingSides returns a copy of the CostModel which does not mind the score gap inside a side,
+// so the split of a doubles match is decided by repeats and the gap between the sides only.
+func (m CostModel) balancingSides() CostModel {
+	m.ScoreGapInSide = 0
+	return m
+}
+
+// costUnits converts a cost to the integer units used by the matching solver.
+func costUnits(cost float64) int64 {
+	return int64(math.Round(cost * 100))
+}
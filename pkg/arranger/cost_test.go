@@ -0,0 +1,199 @@
+package arranger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/yushenli/badminton_match_table/pkg/model"
+)
+
+func TestParseCostModel(t *testing.T) {
+	cases := []struct {
+		title       string
+		spec        string
+		expected    CostModel
+		expectedErr bool
+	}{
+		{
+			"Empty",
+			"",
+			DefaultCostModel,
+			false,
+		},
+		{
+			"Partial",
+			"partner=5, waited = 0",
+			CostModel{
+				RepeatPartner:        5,
+				RepeatOpponent:       DefaultCostModel.RepeatOpponent,
+				ScoreGapInSide:       DefaultCostModel.ScoreGapInSide,
+				ScoreGapBetweenSides: DefaultCostModel.ScoreGapBetweenSides,
+				RoundsWaited:         0,
+			},
+			false,
+		},
+		{
+			"All",
+			"partner=1,opponent=2,side_gap=3,match_gap=4.5,waited=6",
+			CostModel{
+				RepeatPartner:        1,
+				RepeatOpponent:       2,
+				ScoreGapInSide:       3,
+				ScoreGapBetweenSides: 4.5,
+				RoundsWaited:         6,
+			},
+			false,
+		},
+		{
+			"UnknownKey",
+			"partners=1",
+			CostModel{},
+			true,
+		},
+		{
+			"MissingWeight",
+			"partner",
+			CostModel{},
+			true,
+		},
+		{
+			"NegativeWeight",
+			"partner=-1",
+			CostModel{},
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			m, err := ParseCostModel(tc.spec)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("Expected error but got nil.")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			if m != tc.expected {
+				t.Errorf("Unexpected cost model, expected %+v got %+v", tc.expected, m)
+			}
+
+			parsed, err := ParseCostModel(m.String())
+			if err != nil || parsed != m {
+				t.Errorf("Cost model %+v does not survive String(): got %+v, %v", m, parsed, err)
+			}
+		})
+	}
+}
+
+func TestCostModelBreakdown(t *testing.T) {
+	a := &model.Player{Name: "A", Score: 4}
+	b := &model.Player{Name: "B", Score: 3}
+	c := &model.Player{Name: "C", Score: 2}
+	d := &model.Player{Name: "D", Score: 1}
+	e := &model.Player{Name: "E", Score: 0, RoundsWaited: 2}
+	a.Partners = map[*model.Player]int{b: 2}
+	b.Partners = map[*model.Player]int{a: 2}
+	a.Opponents = map[*model.Player]int{c: 1, d: 1}
+	c.Opponents = map[*model.Player]int{a: 1}
+	d.Opponents = map[*model.Player]int{a: 1}
+
+	m := CostModel{
+		RepeatPartner:        10,
+		RepeatOpponent:       5,
+		ScoreGapInSide:       1,
+		ScoreGapBetweenSides: 2,
+		RoundsWaited:         3,
+	}
+	arrangement := model.MatchArrangement{
+		{
+			Side1: model.Side{Player1: a, Player2: b},
+			Side2: model.Side{Player1: c, Player2: d},
+		},
+	}
+
+	expected := CostBreakdown{
+		RepeatPartner:        20,
+		RepeatOpponent:       10,
+		ScoreGapInSide:       2,
+		ScoreGapBetweenSides: 8,
+		RoundsWaited:         6,
+	}
+	got := m.Breakdown(arrangement, model.PlayerSlice{e})
+	if got != expected {
+		t.Errorf("Unexpected cost breakdown, expected %+v got %+v", expected, got)
+	}
+	if got.Total() != 46 {
+		t.Errorf("Unexpected total cost, expected 46 got %v", got.Total())
+	}
+}
+
+func TestCostModelSplitDoublesSides(t *testing.T) {
+	cases := []struct {
+		title    string
+		m        CostModel
+		expected []string
+	}{
+		{
+			"Default",
+			DefaultCostModel,
+			[]string{"A", "B", "C", "D"},
+		},
+		{
+			"BalancedSides",
+			CostModel{ScoreGapBetweenSides: 1},
+			[]string{"A", "D", "B", "C"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			players := model.PlayerSlice{
+				{Name: "A", Score: 4},
+				{Name: "B", Score: 3},
+				{Name: "C", Score: 2},
+				{Name: "D", Score: 1},
+			}
+			tc.m.SplitDoublesSides(players, 0)
+			for idx, name := range tc.expected {
+				if players[idx].Name != name {
+					t.Errorf("Unexpected %d-th player, expected %q got %q", idx, name, players[idx].Name)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultCostModelPrefersAvoidingRepeatOpponentsOverScoreGaps(t *testing.T) {
+	// Every combination of scores 0 to 4 apart, with each pair of the four players in turn having
+	// been opponents once. The two of them are expected to be partners rather than opponents again.
+	for scores := 0; scores < 5*5*5*5; scores++ {
+		for i := 0; i < 4; i++ {
+			for j := i + 1; j < 4; j++ {
+				players := make(model.PlayerSlice, 4)
+				for idx, score := 0, scores; idx < 4; idx, score = idx+1, score/5 {
+					players[idx] = &model.Player{
+						Name:      fmt.Sprintf("Name%d", idx),
+						Score:     float32(score % 5),
+						Partners:  make(map[*model.Player]int),
+						Opponents: make(map[*model.Player]int),
+					}
+				}
+				players[i].Opponents[players[j]] = 1
+				players[j].Opponents[players[i]] = 1
+
+				err := DefaultCostModel.SplitDoublesSides(players, 0)
+				if err != nil {
+					t.Fatalf("Not expecting error but got %v.", err)
+				}
+				if players[0].Opponents[players[1]] == 0 && players[2].Opponents[players[3]] == 0 {
+					t.Fatalf("Unexpected split with scores %v %v %v %v, the repeat opponents are not partners",
+						players[0].Score, players[1].Score, players[2].Score, players[3].Score)
+				}
+			}
+		}
+	}
+}
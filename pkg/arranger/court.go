@@ -32,8 +32,11 @@ func canPlayCount(courtCount, playerCount int) (canPlayCount, singleCount, doubl
 // PickPlayersForCourts will schedule double matches on all courts at first, if there are not enough players
 // to play doubles on all courts, it will schedule some courts to host singles.
 // If there are not even enough players to fill single matches on all courts, an error will be returned.
-// Players will be picked from those who have played the least times. For ties, players with higher prioritiy
-// will be picked first.
+// Players who sat out the last round are always picked before the players who played it, so that nobody
+// sits out twice in a row while someone else plays twice in a row.
+// Then players will be picked from those who have played the least times. For ties, players who have sat
+// out more rounds, and then players with higher prioritiy will be picked first.
+// The two players of a fixed partnership are picked or rested together, when both of them are in players.
 // The passed in player slice will not be affected. A new slice of players will be returned.
 func PickPlayersForCourts(players model.PlayerSlice, courtCount int) (model.PlayerSlice, error) {
 	canPlay, _, _, err := canPlayCount(courtCount, len(players))
@@ -41,17 +44,86 @@ func PickPlayersForCourts(players model.PlayerSlice, courtCount int) (model.Play
 		return nil, err
 	}
 
+	ret := pickOrder(players)
+
+	present := make(map[*model.Player]bool)
+	for _, player := range ret {
+		present[player] = true
+	}
+	teammateOf := func(player *model.Player) *model.Player {
+		if mate := player.Teammate(); mate != nil && present[mate] {
+			return mate
+		}
+		return nil
+	}
+
+	picked := make(model.PlayerSlice, 0, canPlay)
+	taken := make(map[*model.Player]bool)
+	var skippedTeam *model.Player
+	for _, player := range ret {
+		if len(picked) == canPlay {
+			break
+		}
+		if taken[player] {
+			continue
+		}
+		group := model.PlayerSlice{player}
+		if mate := teammateOf(player); mate != nil {
+			group = append(group, mate)
+		}
+		if len(picked)+len(group) > canPlay {
+			if skippedTeam == nil {
+				skippedTeam = player
+			}
+			continue
+		}
+		for _, p := range group {
+			taken[p] = true
+		}
+		picked = append(picked, group...)
+	}
+
+	// canPlay is always even, so one open spot means an odd number of players outside teams
+	// were picked and only teams remain. Rest the last of them and pick the first remaining team.
+	if len(picked) < canPlay && skippedTeam != nil {
+		for i := len(picked) - 1; i >= 0; i-- {
+			if teammateOf(picked[i]) == nil {
+				picked = append(picked[:i], picked[i+1:]...)
+				break
+			}
+		}
+		picked = append(picked, skippedTeam, teammateOf(skippedTeam))
+	}
+
+	return picked, nil
+}
+
+// pickOrder returns a copy of players sorted in the order PickPlayersForCourts picks them,
+// before the teams are taken into account.
+func pickOrder(players model.PlayerSlice) model.PlayerSlice {
 	ret := make(model.PlayerSlice, len(players))
 	copy(ret, players)
 
 	sort.Slice(ret, func(i, j int) bool {
 		a := ret[i]
 		b := ret[j]
-		return a.Matches < b.Matches || (a.Matches == b.Matches && a.Priority > b.Priority)
+		if restedA, restedB := a.RoundsWaited > 0, b.RoundsWaited > 0; restedA != restedB {
+			return restedA
+		}
+		if a.Matches != b.Matches {
+			return a.Matches < b.Matches
+		}
+		if a.RoundsSatOut != b.RoundsSatOut {
+			return a.RoundsSatOut > b.RoundsSatOut
+		}
+		return a.Priority > b.Priority
 	})
-	ret = ret[:canPlay]
+	return ret
+}
 
-	return ret, nil
+// MakeMatchArrangements is the same as DefaultCostModel.MakeMatchArrangements.
+func MakeMatchArrangements(players model.PlayerSlice, courtCount int, seed int) (model.MatchArrangement, error) {
+	return DefaultCostModel.MakeMatchArrangements(players, courtCount, seed)
 }
 
 // MakeMatchArrangements put a processed slice of players into courtCount matches.
@@ -59,7 +131,20 @@ func PickPlayersForCourts(players model.PlayerSlice, courtCount int) (model.Play
 // randomized using the provided seed.
 // After the matches are determined, the order of the matches will be randomized using
 // the provided seed, so not the order of the courts does not represent the ranking.
-func MakeMatchArrangements(players model.PlayerSlice, courtCount int, seed int) (model.MatchArrangement, error) {
+// The sides of doubles matches are split by SplitDoublesSides.
+func (m CostModel) MakeMatchArrangements(players model.PlayerSlice, courtCount int, seed int) (model.MatchArrangement, error) {
+	return makeMatchArrangements(Input{CourtCount: courtCount, Seed: seed, Cost: &m}, players)
+}
+
+// makeMatchArrangements does the same as MakeMatchArrangements with the court count, seed,
+// cost model and format in the given input. Before the sides of a doubles match are split,
+// the players are regrouped to fit the format when possible. When the input balances sides,
+// the gap between the players of a side is ignored in the split.
+func makeMatchArrangements(input Input, players model.PlayerSlice) (model.MatchArrangement, error) {
+	courtCount, seed, cost := input.CourtCount, input.Seed, input.CostModel()
+	if input.BalanceSides {
+		cost = cost.balancingSides()
+	}
 	_, singles, doubles, err := canPlayCount(courtCount, len(players))
 	if err != nil {
 		return nil, err
@@ -75,11 +160,41 @@ func MakeMatchArrangements(players model.PlayerSlice, courtCount int, seed int)
 		isDoubles[i], isDoubles[j] = isDoubles[j], isDoubles[i]
 	})
 
+	// Players are taken into matches in order. When there are fixed partnerships, singles
+	// matches are filled first, so they can be left to the players outside teams.
+	order := make([]int, 0, singles+doubles)
+	for i := range isDoubles {
+		if !isDoubles[i] || !hasTeams(players) {
+			order = append(order, i)
+		}
+	}
+	for i := range isDoubles {
+		if isDoubles[i] && hasTeams(players) {
+			order = append(order, i)
+		}
+	}
+
+	sizes := make([]int, len(order))
+	for k, i := range order {
+		sizes[k] = 2
+		if isDoubles[i] {
+			sizes[k] = 4
+		}
+	}
+	blocks, err := takeBlocks(players, sizes, input.Format, input.Constraints)
+	if err != nil {
+		return nil, err
+	}
+
 	matches := make(model.MatchArrangement, singles+doubles)
 	idx := 0
-	for i := 0; i < singles+doubles; i++ {
+	for k, i := range order {
+		copy(players[idx:], blocks[k])
 		if isDoubles[i] {
-			SeparateCompetedPlayers(players, idx, idx+3, false)
+			err := cost.splitDoublesSides(players, idx, input.Format, input.Constraints)
+			if err != nil {
+				return nil, err
+			}
 			matches[i] = model.Match{
 				Side1: model.Side{
 					Player1: players[idx],
@@ -112,3 +227,120 @@ func MakeMatchArrangements(players model.PlayerSlice, courtCount int, seed int)
 
 	return matches, nil
 }
+
+// hasTeams returns whether any two of the players are in a fixed partnership.
+func hasTeams(players model.PlayerSlice) bool {
+	present := make(map[*model.Player]bool)
+	for _, player := range players {
+		if present[player.Teammate()] {
+			return true
+		}
+		present[player] = true
+	}
+	return false
+}
+
+// takeBlocks puts the players into blocks of the given sizes, one by one with takeBlock. If that
+// fails to satisfy the constraints, a search through all the possible blocks is made, ignoring the
+// format. The search keeps the teams together if it can, otherwise it ignores them as well, and the
+// teams split up are listed in the explanation, see Explanation.SplitTeams.
+func takeBlocks(players model.PlayerSlice, sizes []int, format Format, constraints Constraints) ([]model.PlayerSlice, error) {
+	queue := append(model.PlayerSlice(nil), players...)
+	var blocks []model.PlayerSlice
+	for _, size := range sizes {
+		block, rest, ok := takeBlock(queue, size, format, constraints)
+		if !ok {
+			if blocks, err := constraints.searchBlocks(players, sizes, true); err == nil {
+				return blocks, nil
+			}
+			return constraints.searchBlocks(players, sizes, false)
+		}
+		blocks = append(blocks, block)
+		queue = rest
+	}
+	return blocks, nil
+}
+
+// takeBlock takes size players from the front of the queue for a match, and returns them
+// together with the rest of the queue. Players are taken in the order of the queue, skipping
+// those who do not fit:
+//   - The two players of a fixed partnership are taken together into a doubles match, and not
+//     into a singles match.
+//   - Players in a doubles match fit the format, see Format.blockTargets. When there are not
+//     enough fitting players, the block is completed with the next players regardless of format.
+//   - Players in the block do not break the constraints.
+//
+// If the teams cannot be kept together, they are ignored. Returns false if no block can be found.
+func takeBlock(queue model.PlayerSlice, size int, format Format, constraints Constraints) (model.PlayerSlice, model.PlayerSlice, bool) {
+	inQueue := make(map[*model.Player]bool)
+	for _, player := range queue {
+		inQueue[player] = true
+	}
+
+	for _, respectTeams := range []bool{true, false} {
+		var open map[string]int
+		if size == 4 {
+			open = format.blockTargets(queue)
+		}
+		var block model.PlayerSlice
+		taken := make(map[*model.Player]bool)
+		for _, spots := range []map[string]int{open, nil} {
+			for _, player := range queue {
+				if len(block) == size {
+					break
+				}
+				if taken[player] {
+					continue
+				}
+				group := model.PlayerSlice{player}
+				if mate := player.Teammate(); respectTeams && mate != nil && inQueue[mate] {
+					if size == 2 {
+						continue
+					}
+					group = append(group, mate)
+				}
+				if len(block)+len(group) > size || !constraints.canJoin(block, group, size) || !takeSpots(spots, group) {
+					continue
+				}
+				for _, p := range group {
+					taken[p] = true
+				}
+				block = append(block, group...)
+			}
+		}
+		if len(block) < size || !constraints.allowsBlock(block) {
+			continue
+		}
+
+		rest := make(model.PlayerSlice, 0, len(queue)-size)
+		for _, player := range queue {
+			if !taken[player] {
+				rest = append(rest, player)
+			}
+		}
+		return block, rest, true
+	}
+
+	return nil, nil, false
+}
+
+// BenchedPlayers returns the players who are not arranged to play in the given arrangement,
+// in the same order as they are in players.
+func BenchedPlayers(players model.PlayerSlice, arrangement model.MatchArrangement) model.PlayerSlice {
+	playing := make(map[*model.Player]bool)
+	for _, match := range arrangement {
+		for _, side := range []model.Side{match.Side1, match.Side2} {
+			for _, player := range sidePlayers(side) {
+				playing[player] = true
+			}
+		}
+	}
+
+	var benched model.PlayerSlice
+	for _, player := range players {
+		if !playing[player] {
+			benched = append(benched, player)
+		}
+	}
+	return benched
+}
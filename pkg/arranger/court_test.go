@@ -1,6 +1,7 @@
 package arranger
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 
@@ -234,3 +235,234 @@ func TestPickPlayersForCourts(t *testing.T) {
 		})
 	}
 }
+
+func TestBenchedPlayers(t *testing.T) {
+	players := model.PlayerSlice{
+		{Name: "Name1"},
+		{Name: "Name2"},
+		{Name: "Name3"},
+		{Name: "Name4"},
+		{Name: "Name5"},
+	}
+	arrangement := model.MatchArrangement{
+		{
+			Side1: model.Side{Player1: players[3]},
+			Side2: model.Side{Player1: players[1]},
+		},
+	}
+
+	benched := BenchedPlayers(players, arrangement)
+	var names []string
+	for _, player := range benched {
+		names = append(names, player.Name)
+	}
+	expected := []string{"Name1", "Name3", "Name5"}
+	if !reflect.DeepEqual(expected, names) {
+		t.Errorf("Unexpected benched players, expected %v got %v", expected, names)
+	}
+}
+
+// makeTeamPlayers creates players named Name1, Name2, ... with descending scores, and teams up
+// the players at each pair of given indexes.
+func makeTeamPlayers(count int, teams [][2]int) model.PlayerSlice {
+	var players model.PlayerSlice
+	for idx := 0; idx < count; idx++ {
+		players = append(players, &model.Player{
+			Name:      fmt.Sprintf("Name%d", idx+1),
+			Score:     float32(count - idx),
+			Priority:  float32(count - idx),
+			Partners:  make(map[*model.Player]int),
+			Opponents: make(map[*model.Player]int),
+		})
+	}
+	for idx, team := range teams {
+		model.NewTeam(idx+1, players[team[0]], players[team[1]])
+	}
+	return players
+}
+
+func TestPickPlayersForCourtsWithTeams(t *testing.T) {
+	cases := []struct {
+		title      string
+		count      int
+		teams      [][2]int
+		courtCount int
+		expected   []string
+	}{
+		{
+			"TeamPickedTogether",
+			6,
+			[][2]int{{0, 5}},
+			1,
+			[]string{"Name1", "Name6", "Name2", "Name3"},
+		},
+		{
+			"TeamRestedTogether",
+			6,
+			[][2]int{{3, 4}},
+			1,
+			[]string{"Name1", "Name2", "Name3", "Name6"},
+		},
+		{
+			"OnlyTeamsRemain",
+			7,
+			[][2]int{{1, 2}, {3, 4}, {5, 6}},
+			1,
+			[]string{"Name2", "Name3", "Name4", "Name5"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			players := makeTeamPlayers(tc.count, tc.teams)
+			picked, err := PickPlayersForCourts(players, tc.courtCount)
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			var names []string
+			for _, player := range picked {
+				names = append(names, player.Name)
+			}
+			if !reflect.DeepEqual(tc.expected, names) {
+				t.Errorf("Unexpected picked players, expected %v got %v", tc.expected, names)
+			}
+		})
+	}
+}
+
+func TestMakeMatchArrangementsWithTeams(t *testing.T) {
+	cases := []struct {
+		title      string
+		count      int
+		teams      [][2]int
+		courtCount int
+	}{
+		{"TeamsAcrossBlocks", 8, [][2]int{{0, 7}, {3, 4}}, 2},
+		{"TeamsWithSingles", 6, [][2]int{{0, 1}, {2, 3}}, 2},
+		{"TeamsOfSplitCandidates", 4, [][2]int{{0, 3}, {1, 2}}, 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			players := makeTeamPlayers(tc.count, tc.teams)
+			matches, err := makeMatchArrangements(Input{CourtCount: tc.courtCount, Seed: 1}, players)
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			for idx, match := range matches {
+				for _, side := range []model.Side{match.Side1, match.Side2} {
+					for _, player := range sidePlayers(side) {
+						if mate := player.Teammate(); mate != nil && side.Player1 != mate && side.Player2 != mate {
+							t.Errorf("Match %d does not put %s with teammate %s on the same side", idx, player.Name, mate.Name)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMakeMatchArrangementsBalanceSides(t *testing.T) {
+	cases := []struct {
+		title        string
+		balanceSides bool
+		// partnered are the pairs of players, by index, who have partnered once before.
+		partnered [][2]int
+		// expected are the names of the partners of Name1.
+		expected string
+	}{
+		{"Unbalanced", false, nil, "Name2"},
+		{"Balanced", true, nil, "Name4"},
+		{"BalancedAvoidsRepeats", true, [][2]int{{0, 3}}, "Name3"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			players := makeTeamPlayers(4, nil)
+			for _, pair := range tc.partnered {
+				players[pair[0]].Partners[players[pair[1]]]++
+				players[pair[1]].Partners[players[pair[0]]]++
+			}
+			top := players[0]
+			matches, err := makeMatchArrangements(Input{CourtCount: 1, Seed: 1, BalanceSides: tc.balanceSides}, players)
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+
+			var partner string
+			for _, side := range []model.Side{matches[0].Side1, matches[0].Side2} {
+				if side.Player1 == top {
+					partner = side.Player2.Name
+				}
+				if side.Player2 == top {
+					partner = side.Player1.Name
+				}
+			}
+			if partner != tc.expected {
+				t.Errorf("Unexpected partner of %s, expected %s got %s", top.Name, tc.expected, partner)
+			}
+		})
+	}
+}
+
+func TestPickPlayersForCourtsRestFairness(t *testing.T) {
+	cases := []struct {
+		title    string
+		slice    model.PlayerSlice
+		expected []string
+	}{
+		{
+			"RestedBeforeFewerMatches",
+			model.PlayerSlice{
+				{Name: "Name1", Matches: 1, Priority: 5},
+				{Name: "Name2", Matches: 1, Priority: 4},
+				{Name: "Name3", Matches: 1, Priority: 3},
+				{Name: "Name4", Matches: 2, Priority: 2, RoundsWaited: 1},
+				{Name: "Name5", Matches: 2, Priority: 1, RoundsWaited: 1},
+			},
+			[]string{"Name4", "Name5", "Name1", "Name2"},
+		},
+		{
+			"LowPriorityNotRestedAgain",
+			model.PlayerSlice{
+				{Name: "Name1", Matches: 1, Priority: 5},
+				{Name: "Name2", Matches: 1, Priority: 4},
+				{Name: "Name3", Matches: 1, Priority: 3},
+				{Name: "Name4", Matches: 1, Priority: 2},
+				{Name: "Name5", Matches: 1, Priority: 1, RoundsWaited: 1, RoundsSatOut: 1},
+			},
+			[]string{"Name5", "Name1", "Name2", "Name3"},
+		},
+		{
+			"MoreRoundsSatOutFirst",
+			model.PlayerSlice{
+				{Name: "Name1", Matches: 1, Priority: 5, RoundsWaited: 1, RoundsSatOut: 1},
+				{Name: "Name2", Matches: 1, Priority: 4, RoundsWaited: 1, RoundsSatOut: 2},
+				{Name: "Name3", Matches: 1, Priority: 3, RoundsWaited: 1, RoundsSatOut: 1},
+				{Name: "Name4", Matches: 1, Priority: 2, RoundsWaited: 1, RoundsSatOut: 3},
+				{Name: "Name5", Matches: 1, Priority: 1, RoundsWaited: 1, RoundsSatOut: 1},
+			},
+			[]string{"Name4", "Name2", "Name1", "Name3"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			picked, err := PickPlayersForCourts(tc.slice, 1)
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			var names []string
+			for _, player := range picked {
+				names = append(names, player.Name)
+			}
+			if !reflect.DeepEqual(tc.expected, names) {
+				t.Errorf("Unexpected picked players, expected %v got %v", tc.expected, names)
+			}
+		})
+	}
+}
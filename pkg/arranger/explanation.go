@@ -0,0 +1,210 @@
+package arranger
+
+import (
+	"fmt"
+
+	"github.com/yushenli/badminton_match_table/pkg/model"
+)
+
+// Explanation describes how an arrangement was made, for organizers and tools to review it.
+// Arrangers fill the parts that apply to them.
+type Explanation struct {
+	// Bands are the lower bounds of the score bands found among all the players, from the
+	// highest band to the lowest.
+	Bands []float32 `json:"bands,omitempty"`
+	// Ranges are the ranges of the sorted playing players which were rearranged to separate
+	// players who have met before.
+	Ranges []RangeExplanation `json:"ranges,omitempty"`
+	// Players explains why each available player was picked or benched, in the order they
+	// were considered for picking.
+	Players []PlayerExplanation `json:"players"`
+	// SplitTeams lists the fixed partnerships whose players both play but not on the same side,
+	// which only happens when the constraints cannot be satisfied otherwise.
+	SplitTeams [][2]string `json:"split_teams,omitempty"`
+	// Matches holds the cost of each match, in the order of the arrangement.
+	Matches []MatchExplanation `json:"matches"`
+	// Total is the cost of the whole arrangement, including the benched players.
+	Total CostBreakdown `json:"total"`
+	// TotalCost is the sum of Total.
+	TotalCost float64 `json:"total_cost"`
+}
+
+// RangeExplanation is a range of the sorted playing players, see findSeparateRanges. Players are
+// listed as they were rearranged, so every two consecutive players were paired.
+type RangeExplanation struct {
+	Left     int      `json:"left"`
+	Right    int      `json:"right"`
+	EndFixed bool     `json:"end_fixed"`
+	Players  []string `json:"players"`
+}
+
+// PlayerExplanation tells whether and why a player was picked to play.
+type PlayerExplanation struct {
+	ID           int     `json:"id"`
+	Name         string  `json:"name"`
+	Score        float32 `json:"score"`
+	Matches      float32 `json:"matches"`
+	RoundsWaited int     `json:"rounds_waited"`
+	RoundsSatOut int     `json:"rounds_sat_out"`
+	Picked       bool    `json:"picked"`
+	Reason       string  `json:"reason"`
+}
+
+// MatchExplanation is the cost of a match.
+type MatchExplanation struct {
+	Court     int           `json:"court"`
+	Side1     []string      `json:"side1"`
+	Side2     []string      `json:"side2"`
+	Cost      CostBreakdown `json:"cost"`
+	TotalCost float64       `json:"total_cost"`
+}
+
+// Explain returns the explanation of an arrangement made for input by other means than an
+// Arranger, e.g. planned ahead by PlanRounds. Only the picks and the costs are explained.
+func Explain(input Input, arrangement model.MatchArrangement) Explanation {
+	var explanation Explanation
+	explanation.explainArrangement(input, arrangement)
+	return explanation
+}
+
+// explainBands records the bands and the ranges rearranged by separateCompetedPlayersWithinBands,
+// once the players have been rearranged.
+func (e *Explanation) explainBands(clusters []float32, ranges []separateRange, players model.PlayerSlice) {
+	if e == nil {
+		return
+	}
+	e.Bands = clusters
+	e.Ranges = nil
+	for _, r := range ranges {
+		var names []string
+		for _, player := range players[r.left : r.right+1] {
+			names = append(names, player.Name)
+		}
+		e.Ranges = append(e.Ranges, RangeExplanation{
+			Left:     r.left,
+			Right:    r.right,
+			EndFixed: r.endFixed,
+			Players:  names,
+		})
+	}
+}
+
+// explainArrangement records why the players in input were picked or benched, and the costs of
+// the arrangement under the cost model of input.
+func (e *Explanation) explainArrangement(input Input, arrangement model.MatchArrangement) {
+	if e == nil {
+		return
+	}
+	cost := input.CostModel()
+
+	benched := BenchedPlayers(input.Players, arrangement)
+	isBenched := make(map[*model.Player]bool)
+	for _, player := range benched {
+		isBenched[player] = true
+	}
+	var lastPicked *model.Player
+	order := pickOrder(input.Players)
+	for _, player := range order {
+		if !isBenched[player] {
+			lastPicked = player
+		}
+	}
+
+	e.Players = nil
+	for _, player := range order {
+		e.Players = append(e.Players, PlayerExplanation{
+			ID:           player.ID,
+			Name:         player.Name,
+			Score:        player.Score,
+			Matches:      player.Matches,
+			RoundsWaited: player.RoundsWaited,
+			RoundsSatOut: player.RoundsSatOut,
+			Picked:       !isBenched[player],
+			Reason:       pickReason(player, !isBenched[player], isBenched, lastPicked),
+		})
+	}
+
+	e.SplitTeams = nil
+	for _, team := range splitTeams(arrangement) {
+		e.SplitTeams = append(e.SplitTeams, [2]string{team.Player1.Name, team.Player2.Name})
+	}
+
+	e.Matches = nil
+	for idx, match := range arrangement {
+		breakdown := cost.MatchCost(match)
+		e.Matches = append(e.Matches, MatchExplanation{
+			Court:     idx + 1,
+			Side1:     playerNames(sidePlayers(match.Side1)),
+			Side2:     playerNames(sidePlayers(match.Side2)),
+			Cost:      breakdown,
+			TotalCost: breakdown.Total(),
+		})
+	}
+	e.Total = cost.Breakdown(arrangement, benched)
+	e.TotalCost = e.Total.Total()
+}
+
+// splitTeams returns the teams whose players both play in the arrangement but not on the same side.
+func splitTeams(arrangement model.MatchArrangement) []*model.Team {
+	sideOf := make(map[*model.Player]int)
+	for idx, match := range arrangement {
+		for _, player := range sidePlayers(match.Side1) {
+			sideOf[player] = 2 * idx
+		}
+		for _, player := range sidePlayers(match.Side2) {
+			sideOf[player] = 2*idx + 1
+		}
+	}
+
+	var teams []*model.Team
+	for _, match := range arrangement {
+		for _, player := range append(sidePlayers(match.Side1), sidePlayers(match.Side2)...) {
+			team := player.Team
+			if team == nil || team.Player1 != player {
+				continue
+			}
+			if side, ok := sideOf[team.Player2]; ok && side != sideOf[player] {
+				teams = append(teams, team)
+			}
+		}
+	}
+	return teams
+}
+
+// pickReason returns why the player was picked or benched, where lastPicked is the last
+// player picked in the pick order.
+func pickReason(player *model.Player, picked bool, isBenched map[*model.Player]bool, lastPicked *model.Player) string {
+	mate := player.Teammate()
+	if picked {
+		switch {
+		case player.RoundsWaited > 0:
+			return fmt.Sprintf("sat out the last %d round(s)", player.RoundsWaited)
+		case mate != nil && !isBenched[mate]:
+			return fmt.Sprintf("picked together with teammate %s", mate.Name)
+		default:
+			return fmt.Sprintf("has played %g matches, among the fewest", player.Matches)
+		}
+	}
+
+	switch {
+	case lastPicked == nil:
+		return "no player is picked"
+	case mate != nil && isBenched[mate]:
+		return fmt.Sprintf("rests together with teammate %s", mate.Name)
+	case player.RoundsWaited == 0 && lastPicked.RoundsWaited > 0:
+		return "played the last round, while others sat it out"
+	case player.Matches > lastPicked.Matches:
+		return fmt.Sprintf("has played %g matches, more than the picked players", player.Matches)
+	default:
+		return fmt.Sprintf("tied with the picked players on %g matches, lost on rounds sat out or priority", player.Matches)
+	}
+}
+
+// playerNames returns the names of the players.
+func playerNames(players []*model.Player) []string {
+	names := make([]string, 0, len(players))
+	for _, player := range players {
+		names = append(names, player.Name)
+	}
+	return names
+}
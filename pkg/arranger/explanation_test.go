@@ -0,0 +1,82 @@
+package arranger
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yushenli/badminton_match_table/pkg/model"
+)
+
+func TestBandedArrangerExplanation(t *testing.T) {
+	players := makeTeamPlayers(5, nil)
+	players[4].RoundsWaited = 1
+	players[3].Matches = 1
+
+	var explanation Explanation
+	input := Input{
+		AllPlayers:  players,
+		Players:     players,
+		CourtCount:  1,
+		Seed:        1,
+		Explanation: &explanation,
+	}
+	arrangement, err := BandedArranger{}.Arrange(input)
+	if err != nil {
+		t.Errorf("Not expecting error but got %v.", err)
+		return
+	}
+
+	if len(explanation.Bands) == 0 {
+		t.Errorf("Expected bands to be explained but got none.")
+	}
+
+	expectedPicked := map[string]bool{"Name1": true, "Name2": true, "Name3": true, "Name4": false, "Name5": true}
+	if len(explanation.Players) != len(players) {
+		t.Errorf("Unexpected number of explained players, expected %d got %d", len(players), len(explanation.Players))
+	}
+	for idx, player := range explanation.Players {
+		if player.Picked != expectedPicked[player.Name] {
+			t.Errorf("Unexpected picked for %s, expected %v got %v", player.Name, expectedPicked[player.Name], player.Picked)
+		}
+		if player.Reason == "" {
+			t.Errorf("Expected a reason for %s but got none.", player.Name)
+		}
+		if idx == 0 && player.Name != "Name5" {
+			t.Errorf("Unexpected first player in the pick order, expected Name5 got %s", player.Name)
+		}
+	}
+
+	if len(explanation.Matches) != len(arrangement) {
+		t.Errorf("Unexpected number of explained matches, expected %d got %d", len(arrangement), len(explanation.Matches))
+	}
+	expectedTotal := DefaultCostModel.Breakdown(arrangement, model.PlayerSlice{players[3]})
+	if explanation.Total != expectedTotal || explanation.TotalCost != expectedTotal.Total() {
+		t.Errorf("Unexpected total cost, expected %+v got %+v", expectedTotal, explanation.Total)
+	}
+}
+
+func TestExplainBandsAfterSeparation(t *testing.T) {
+	players := makeTeamPlayers(8, nil)
+	for _, player := range players {
+		player.Score = 1
+	}
+	// Name1 and Name2 would be paired in the sorted order, were they not partners before.
+	players[0].Partners[players[1]] = 1
+	players[1].Partners[players[0]] = 1
+
+	var explanation Explanation
+	err := DefaultCostModel.separateCompetedPlayersWithinBands(players, players, HierarchicalBanding{}, nil, &explanation)
+	if err != nil {
+		t.Errorf("Not expecting error but got %v.", err)
+		return
+	}
+	if len(explanation.Ranges) == 0 {
+		t.Errorf("Expected ranges to be explained but got none.")
+	}
+	for _, r := range explanation.Ranges {
+		expected := playerNames(players[r.Left : r.Right+1])
+		if !reflect.DeepEqual(r.Players, expected) {
+			t.Errorf("Unexpected players of range %d-%d, expected %v got %v", r.Left, r.Right, expected, r.Players)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package arranger
+
+import (
+	"fmt"
+
+	"github.com/yushenli/badminton_match_table/pkg/model"
+)
+
+// Format decides which player categories may be combined in doubles matches.
+type Format string
+
+// Supported formats.
+const (
+	// FormatOpen ignores player categories.
+	FormatOpen Format = ""
+	// FormatMixed makes every doubles side one man and one woman.
+	FormatMixed Format = "mixed"
+	// FormatLevel makes all the players of a doubles match the same category,
+	// i.e. men's doubles or women's doubles.
+	FormatLevel Format = "level"
+)
+
+// Formats lists all the supported formats.
+var Formats = []Format{FormatOpen, FormatMixed, FormatLevel}
+
+// ParseFormat returns the Format of the given name.
+func ParseFormat(name string) (Format, error) {
+	for _, format := range Formats {
+		if string(format) == name {
+			return format, nil
+		}
+	}
+	return FormatOpen, fmt.Errorf("unknown format %q", name)
+}
+
+// fitsSide returns whether the two players can form a side under the format.
+func (f Format) fitsSide(a, b *model.Player) bool {
+	if f != FormatMixed {
+		return true
+	}
+	return a.Category == model.CategoryUnknown || b.Category == model.CategoryUnknown || a.Category != b.Category
+}
+
+// blockTargets returns how many spots of each category are open in a doubles match whose highest
+// ranked candidates are the given players, or nil if the format does not care.
+func (f Format) blockTargets(candidates model.PlayerSlice) map[string]int {
+	switch f {
+	case FormatMixed:
+		return map[string]int{model.CategoryMale: 2, model.CategoryFemale: 2}
+	case FormatLevel:
+		if len(candidates) > 4 {
+			candidates = candidates[:4]
+		}
+		counts := make(map[string]int)
+		for _, player := range candidates {
+			counts[player.Category]++
+		}
+		// Go with the majority, and with ties, the category of the highest ranked player.
+		target := model.CategoryUnknown
+		if counts[model.CategoryMale] > counts[model.CategoryFemale] {
+			target = model.CategoryMale
+		} else if counts[model.CategoryFemale] > counts[model.CategoryMale] {
+			target = model.CategoryFemale
+		} else {
+			for _, player := range candidates {
+				if player.Category != model.CategoryUnknown {
+					target = player.Category
+					break
+				}
+			}
+		}
+		if target == model.CategoryUnknown {
+			return nil
+		}
+		return map[string]int{target: 4}
+	}
+	return nil
+}
+
+// takeSpots takes the open spots for the group of players and returns true, or returns false
+// without taking any spot if the group does not fit. Players with an unknown category fit any spot.
+func takeSpots(open map[string]int, group []*model.Player) bool {
+	if open == nil {
+		return true
+	}
+
+	left := make(map[string]int)
+	for category, count := range open {
+		left[category] = count
+	}
+	var unknown int
+	for _, player := range group {
+		if player.Category == model.CategoryUnknown {
+			unknown++
+			continue
+		}
+		if left[player.Category] == 0 {
+			return false
+		}
+		left[player.Category]--
+	}
+
+	total := 0
+	for _, count := range left {
+		total += count
+	}
+	if unknown > total {
+		return false
+	}
+	for _, category := range []string{model.CategoryMale, model.CategoryFemale} {
+		for unknown > 0 && left[category] > 0 {
+			left[category]--
+			unknown--
+		}
+	}
+	for category := range open {
+		open[category] = left[category]
+	}
+	return true
+}
+
+// misfits returns the number of doubles matches in the arrangement which do not fit the format.
+func (f Format) misfits(arrangement model.MatchArrangement) int {
+	count := 0
+	for _, match := range arrangement {
+		if match.Side1.Player2 == nil || match.Side2.Player2 == nil {
+			continue
+		}
+		fits := f.fitsSide(match.Side1.Player1, match.Side1.Player2) && f.fitsSide(match.Side2.Player1, match.Side2.Player2)
+		if f == FormatLevel {
+			category := model.CategoryUnknown
+			for _, player := range append(sidePlayers(match.Side1), sidePlayers(match.Side2)...) {
+				if player.Category == model.CategoryUnknown {
+					continue
+				}
+				if category != model.CategoryUnknown && player.Category != category {
+					fits = false
+				}
+				category = player.Category
+			}
+		}
+		if !fits {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,126 @@
+package arranger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/yushenli/badminton_match_table/pkg/model"
+)
+
+func TestParseFormat(t *testing.T) {
+	cases := []struct {
+		title       string
+		name        string
+		expected    Format
+		expectedErr bool
+	}{
+		{"Open", "", FormatOpen, false},
+		{"Mixed", "mixed", FormatMixed, false},
+		{"Level", "level", FormatLevel, false},
+		{"Unknown", "XD", FormatOpen, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			format, err := ParseFormat(tc.name)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("Expected error but got nil.")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			if format != tc.expected {
+				t.Errorf("Unexpected format, expected %q got %q", tc.expected, format)
+			}
+		})
+	}
+}
+
+func sideCategories(side model.Side) string {
+	return side.Player1.Category + side.Player2.Category
+}
+
+func TestMakeMatchArrangementsWithFormat(t *testing.T) {
+	cases := []struct {
+		title      string
+		categories string
+		format     Format
+		// check reports whether a match fits the format.
+		check func(match model.Match) bool
+	}{
+		{
+			"MixedFromSortedByCategory",
+			"MMMMFFFF",
+			FormatMixed,
+			func(match model.Match) bool {
+				return match.Side1.Player1.Category != match.Side1.Player2.Category &&
+					match.Side2.Player1.Category != match.Side2.Player2.Category
+			},
+		},
+		{
+			"MixedWithUnknown",
+			"MMMUFFMF",
+			FormatMixed,
+			func(match model.Match) bool {
+				return sideCategories(match.Side1) != "MM" && sideCategories(match.Side2) != "MM" &&
+					sideCategories(match.Side1) != "FF" && sideCategories(match.Side2) != "FF"
+			},
+		},
+		{
+			"LevelFromAlternating",
+			"MFMFMFMF",
+			FormatLevel,
+			func(match model.Match) bool {
+				category := match.Side1.Player1.Category
+				return match.Side1.Player2.Category == category &&
+					match.Side2.Player1.Category == category &&
+					match.Side2.Player2.Category == category
+			},
+		},
+		{
+			"MixedFallback",
+			"MMMMMMFF",
+			FormatMixed,
+			func(match model.Match) bool {
+				return true
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			var players model.PlayerSlice
+			for idx, category := range tc.categories {
+				c := string(category)
+				if c == "U" {
+					c = model.CategoryUnknown
+				}
+				players = append(players, &model.Player{
+					Name:     fmt.Sprintf("Name%d", idx+1),
+					Score:    float32(len(tc.categories) - idx),
+					Category: c,
+				})
+			}
+
+			matches, err := makeMatchArrangements(Input{CourtCount: 2, Seed: 1, Format: tc.format}, players)
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			if len(matches) != 2 {
+				t.Errorf("Unexpected number of matches, expected 2 got %d", len(matches))
+				return
+			}
+			for idx, match := range matches {
+				if !tc.check(match) {
+					t.Errorf("Match %d does not fit format %q: %s vs %s", idx, tc.format,
+						sideCategories(match.Side1), sideCategories(match.Side2))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,735 @@
+package arranger
+
+// This file implements Edmonds' blossom algorithm for maximum weight matching in general
+// graphs, in O(n^3) time. It closely follows the well known implementation by Joris van Rantwijk
+// (mwmatching.py), which in turn is based on "An O(EV log V) algorithm for finding a maximal
+// weighted matching in general graphs" by Galil, Micali and Gabow, and on Galil's
+// "Efficient algorithms for finding maximum matching in graphs".
+//
+// Edge weights are integers so that all the dual variables stay integers and no floating point
+// comparison is involved.
+
+// weightedEdge is an undirected edge between vertex i and j.
+type weightedEdge struct {
+	i, j   int
+	weight int64
+}
+
+// maxWeightMatching computes a maximum weight matching on the graph given by the edges.
+// Vertices are identified by non-negative integers. When maxCardinality is set, only
+// maximum cardinality matchings are considered, and the one with the maximum weight
+// among them is returned.
+//
+// The returned slice maps each vertex to the vertex it is matched with, or -1 if the
+// vertex is not matched.
+func maxWeightMatching(edges []weightedEdge, maxCardinality bool) []int {
+	if len(edges) == 0 {
+		return []int{}
+	}
+	m := newBlossomMatcher(edges, maxCardinality)
+	m.solve()
+
+	mate := make([]int, m.nvertex)
+	for v := range mate {
+		mate[v] = -1
+		if m.mate[v] >= 0 {
+			mate[v] = m.endpoint[m.mate[v]]
+		}
+	}
+	return mate
+}
+
+// blossomMatcher holds the state of one run of the blossom algorithm.
+//
+// Vertices are numbered 0 .. nvertex-1, non-trivial blossoms are numbered
+// nvertex .. 2*nvertex-1. Edge k connects endpoint 2*k and 2*k+1.
+type blossomMatcher struct {
+	edges          []weightedEdge
+	nvertex        int
+	maxCardinality bool
+
+	// endpoint[p] is the vertex to which endpoint p is attached.
+	endpoint []int
+	// neighbend[v] is the list of remote endpoints of the edges attached to vertex v.
+	neighbend [][]int
+	// mate[v] is the remote endpoint of the matched edge of vertex v, or -1.
+	mate []int
+	// label is 0 for unlabeled, 1 for S-vertex/blossom, 2 for T-vertex/blossom.
+	// 5 is used temporarily while scanning for blossoms.
+	label []int
+	// labelend is the remote endpoint of the edge through which a vertex or blossom got its label.
+	labelend []int
+	// inblossom[v] is the top-level blossom to which vertex v belongs.
+	inblossom []int
+	// blossomparent[b] is the immediate parent blossom of b, or -1 for top-level blossoms.
+	blossomparent []int
+	// blossomchilds[b] is the ordered list of sub-blossoms of b, starting from the base.
+	blossomchilds [][]int
+	// blossombase[b] is the base vertex of blossom b.
+	blossombase []int
+	// blossomendps[b] lists the endpoints on the edges that connect the sub-blossoms of b.
+	blossomendps [][]int
+	// bestedge[v] is the least-slack edge to a different S-blossom, or -1.
+	bestedge []int
+	// blossombestedges[b] is a list of least-slack edges to neighbouring S-blossoms.
+	blossombestedges [][]int
+	// hasBestEdges tells whether blossombestedges[b] is set.
+	hasBestEdges   []bool
+	unusedblossoms []int
+	dualvar        []int64
+	allowedge      []bool
+	queue          []int
+}
+
+func newBlossomMatcher(edges []weightedEdge, maxCardinality bool) *blossomMatcher {
+	nvertex := 0
+	var maxWeight int64
+	for _, e := range edges {
+		if e.i >= nvertex {
+			nvertex = e.i + 1
+		}
+		if e.j >= nvertex {
+			nvertex = e.j + 1
+		}
+		if e.weight > maxWeight {
+			maxWeight = e.weight
+		}
+	}
+
+	m := &blossomMatcher{
+		edges:            edges,
+		nvertex:          nvertex,
+		maxCardinality:   maxCardinality,
+		endpoint:         make([]int, 2*len(edges)),
+		neighbend:        make([][]int, nvertex),
+		mate:             make([]int, nvertex),
+		label:            make([]int, 2*nvertex),
+		labelend:         make([]int, 2*nvertex),
+		inblossom:        make([]int, nvertex),
+		blossomparent:    make([]int, 2*nvertex),
+		blossomchilds:    make([][]int, 2*nvertex),
+		blossombase:      make([]int, 2*nvertex),
+		blossomendps:     make([][]int, 2*nvertex),
+		bestedge:         make([]int, 2*nvertex),
+		blossombestedges: make([][]int, 2*nvertex),
+		hasBestEdges:     make([]bool, 2*nvertex),
+		dualvar:          make([]int64, 2*nvertex),
+		allowedge:        make([]bool, len(edges)),
+	}
+
+	for p := range m.endpoint {
+		if p%2 == 0 {
+			m.endpoint[p] = edges[p/2].i
+		} else {
+			m.endpoint[p] = edges[p/2].j
+		}
+	}
+	for k, e := range edges {
+		m.neighbend[e.i] = append(m.neighbend[e.i], 2*k+1)
+		m.neighbend[e.j] = append(m.neighbend[e.j], 2*k)
+	}
+	for v := 0; v < nvertex; v++ {
+		m.mate[v] = -1
+		m.inblossom[v] = v
+		m.blossombase[v] = v
+		m.blossombase[nvertex+v] = -1
+		m.dualvar[v] = maxWeight
+	}
+	for b := 0; b < 2*nvertex; b++ {
+		m.labelend[b] = -1
+		m.blossomparent[b] = -1
+		m.bestedge[b] = -1
+	}
+	for b := nvertex; b < 2*nvertex; b++ {
+		m.unusedblossoms = append(m.unusedblossoms, b)
+	}
+
+	return m
+}
+
+// wrap turns a possibly negative index into a valid index of a slice of length n,
+// the same way negative indexes work in Python.
+func wrap(j, n int) int {
+	return ((j % n) + n) % n
+}
+
+func indexOf(slice []int, val int) int {
+	for idx, v := range slice {
+		if v == val {
+			return idx
+		}
+	}
+	return -1
+}
+
+// slack returns 2 * the slack of edge k, which does not involve blossom duals.
+func (m *blossomMatcher) slack(k int) int64 {
+	e := m.edges[k]
+	return m.dualvar[e.i] + m.dualvar[e.j] - 2*e.weight
+}
+
+// blossomLeaves returns all the vertices contained in blossom b.
+func (m *blossomMatcher) blossomLeaves(b int) []int {
+	if b < m.nvertex {
+		return []int{b}
+	}
+	var leaves []int
+	for _, t := range m.blossomchilds[b] {
+		leaves = append(leaves, m.blossomLeaves(t)...)
+	}
+	return leaves
+}
+
+// assignLabel assigns label t to the top-level blossom containing vertex w,
+// coming through an edge from remote endpoint p.
+func (m *blossomMatcher) assignLabel(w, t, p int) {
+	b := m.inblossom[w]
+	m.label[w], m.label[b] = t, t
+	m.labelend[w], m.labelend[b] = p, p
+	m.bestedge[w], m.bestedge[b] = -1, -1
+	if t == 1 {
+		// b became an S-blossom, add its vertices to the queue.
+		m.queue = append(m.queue, m.blossomLeaves(b)...)
+	} else if t == 2 {
+		// b became a T-blossom, assign label S to its mate.
+		base := m.blossombase[b]
+		m.assignLabel(m.endpoint[m.mate[base]], 1, m.mate[base]^1)
+	}
+}
+
+// scanBlossom traces back from vertices v and w to discover either a new blossom
+// or an augmenting path. It returns the base vertex of the new blossom, or -1.
+func (m *blossomMatcher) scanBlossom(v, w int) int {
+	var path []int
+	base := -1
+	for v != -1 || w != -1 {
+		b := m.inblossom[v]
+		if m.label[b]&4 != 0 {
+			base = m.blossombase[b]
+			break
+		}
+		path = append(path, b)
+		m.label[b] = 5
+		if m.labelend[b] == -1 {
+			// The base of blossom b is single, stop tracing this path.
+			v = -1
+		} else {
+			v = m.endpoint[m.labelend[b]]
+			b = m.inblossom[v]
+			// b is a T-blossom, trace one more step back.
+			v = m.endpoint[m.labelend[b]]
+		}
+		// Swap v and w so that we alternate between both paths.
+		if w != -1 {
+			v, w = w, v
+		}
+	}
+	for _, b := range path {
+		m.label[b] = 1
+	}
+	return base
+}
+
+// addBlossom constructs a new blossom with the given base, containing edge k which
+// connects a pair of S-vertices.
+func (m *blossomMatcher) addBlossom(base, k int) {
+	v, w := m.edges[k].i, m.edges[k].j
+	bb := m.inblossom[base]
+	bv := m.inblossom[v]
+	bw := m.inblossom[w]
+
+	b := m.unusedblossoms[len(m.unusedblossoms)-1]
+	m.unusedblossoms = m.unusedblossoms[:len(m.unusedblossoms)-1]
+	m.blossombase[b] = base
+	m.blossomparent[b] = -1
+	m.blossomparent[bb] = b
+
+	var path, endps []int
+	// Trace back from v to base.
+	for bv != bb {
+		m.blossomparent[bv] = b
+		path = append(path, bv)
+		endps = append(endps, m.labelend[bv])
+		v = m.endpoint[m.labelend[bv]]
+		bv = m.inblossom[v]
+	}
+	path = append(path, bb)
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	for i, j := 0, len(endps)-1; i < j; i, j = i+1, j-1 {
+		endps[i], endps[j] = endps[j], endps[i]
+	}
+	endps = append(endps, 2*k)
+	// Trace back from w to base.
+	for bw != bb {
+		m.blossomparent[bw] = b
+		path = append(path, bw)
+		endps = append(endps, m.labelend[bw]^1)
+		w = m.endpoint[m.labelend[bw]]
+		bw = m.inblossom[w]
+	}
+	m.blossomchilds[b] = path
+	m.blossomendps[b] = endps
+
+	m.label[b] = 1
+	m.labelend[b] = m.labelend[bb]
+	m.dualvar[b] = 0
+	for _, leaf := range m.blossomLeaves(b) {
+		if m.label[m.inblossom[leaf]] == 2 {
+			// This T-vertex now turns into an S-vertex because it becomes part of an S-blossom.
+			m.queue = append(m.queue, leaf)
+		}
+		m.inblossom[leaf] = b
+	}
+
+	// Compute blossombestedges[b].
+	bestedgeto := make([]int, 2*m.nvertex)
+	for idx := range bestedgeto {
+		bestedgeto[idx] = -1
+	}
+	for _, sub := range path {
+		var nblists [][]int
+		if !m.hasBestEdges[sub] {
+			// This sub-blossom does not have a list of least-slack edges,
+			// get the information from the vertices.
+			for _, leaf := range m.blossomLeaves(sub) {
+				nblist := make([]int, len(m.neighbend[leaf]))
+				for idx, p := range m.neighbend[leaf] {
+					nblist[idx] = p / 2
+				}
+				nblists = append(nblists, nblist)
+			}
+		} else {
+			nblists = [][]int{m.blossombestedges[sub]}
+		}
+		for _, nblist := range nblists {
+			for _, ek := range nblist {
+				j := m.edges[ek].j
+				if m.inblossom[j] == b {
+					j = m.edges[ek].i
+				}
+				bj := m.inblossom[j]
+				if bj != b && m.label[bj] == 1 &&
+					(bestedgeto[bj] == -1 || m.slack(ek) < m.slack(bestedgeto[bj])) {
+					bestedgeto[bj] = ek
+				}
+			}
+		}
+		// Forget about least-slack edges of the sub-blossom.
+		m.blossombestedges[sub] = nil
+		m.hasBestEdges[sub] = false
+		m.bestedge[sub] = -1
+	}
+
+	var best []int
+	for _, ek := range bestedgeto {
+		if ek != -1 {
+			best = append(best, ek)
+		}
+	}
+	m.blossombestedges[b] = best
+	m.hasBestEdges[b] = true
+	m.bestedge[b] = -1
+	for _, ek := range best {
+		if m.bestedge[b] == -1 || m.slack(ek) < m.slack(m.bestedge[b]) {
+			m.bestedge[b] = ek
+		}
+	}
+}
+
+// expandBlossom expands the given top-level blossom.
+func (m *blossomMatcher) expandBlossom(b int, endstage bool) {
+	// Convert sub-blossoms into top-level blossoms.
+	for _, s := range m.blossomchilds[b] {
+		m.blossomparent[s] = -1
+		if s < m.nvertex {
+			m.inblossom[s] = s
+		} else if endstage && m.dualvar[s] == 0 {
+			// Recursively expand this sub-blossom.
+			m.expandBlossom(s, endstage)
+		} else {
+			for _, leaf := range m.blossomLeaves(s) {
+				m.inblossom[leaf] = s
+			}
+		}
+	}
+
+	// If we expand a T-blossom during a stage, its sub-blossoms must be relabeled.
+	if !endstage && m.label[b] == 2 {
+		childs := m.blossomchilds[b]
+		endps := m.blossomendps[b]
+		n := len(childs)
+		// Start at the sub-blossom through which the expanding blossom obtained its label,
+		// and relabel sub-blossoms until we reach the base.
+		entrychild := m.inblossom[m.endpoint[m.labelend[b]^1]]
+		j := indexOf(childs, entrychild)
+		var jstep, endptrick int
+		if j&1 != 0 {
+			// Start index is odd, go forward and wrap.
+			j -= n
+			jstep = 1
+			endptrick = 0
+		} else {
+			// Start index is even, go backward.
+			jstep = -1
+			endptrick = 1
+		}
+		// Move along the blossom until we get to the base.
+		p := m.labelend[b]
+		for j != 0 {
+			// Relabel the T-sub-blossom.
+			m.label[m.endpoint[p^1]] = 0
+			m.label[m.endpoint[endps[wrap(j-endptrick, n)]^endptrick^1]] = 0
+			m.assignLabel(m.endpoint[p^1], 2, p)
+			// Step to the next S-sub-blossom and note its forward endpoint.
+			m.allowedge[endps[wrap(j-endptrick, n)]/2] = true
+			j += jstep
+			p = endps[wrap(j-endptrick, n)] ^ endptrick
+			// Step to the next T-sub-blossom.
+			m.allowedge[p/2] = true
+			j += jstep
+		}
+		// Relabel the base T-sub-blossom without stepping through to its mate.
+		bv := childs[wrap(j, n)]
+		m.label[m.endpoint[p^1]], m.label[bv] = 2, 2
+		m.labelend[m.endpoint[p^1]], m.labelend[bv] = p, p
+		m.bestedge[bv] = -1
+		// Continue along the blossom until we get back to entrychild.
+		j += jstep
+		for childs[wrap(j, n)] != entrychild {
+			bv = childs[wrap(j, n)]
+			if m.label[bv] == 1 {
+				// This sub-blossom just got label S through one of its neighbours, leave it.
+				j += jstep
+				continue
+			}
+			leaves := m.blossomLeaves(bv)
+			v := leaves[len(leaves)-1]
+			for _, leaf := range leaves {
+				if m.label[leaf] != 0 {
+					v = leaf
+					break
+				}
+			}
+			// If the sub-blossom contains a reachable vertex, assign label T to the sub-blossom.
+			if m.label[v] != 0 {
+				m.label[v] = 0
+				m.label[m.endpoint[m.mate[m.blossombase[bv]]]] = 0
+				m.assignLabel(v, 2, m.labelend[v])
+			}
+			j += jstep
+		}
+	}
+
+	// Recycle the blossom number.
+	m.label[b] = -1
+	m.labelend[b] = -1
+	m.blossomchilds[b] = nil
+	m.blossomendps[b] = nil
+	m.blossombase[b] = -1
+	m.blossombestedges[b] = nil
+	m.hasBestEdges[b] = false
+	m.bestedge[b] = -1
+	m.unusedblossoms = append(m.unusedblossoms, b)
+}
+
+// augmentBlossom swaps matched/unmatched edges over an alternating path through blossom b
+// between vertex v and the base vertex.
+func (m *blossomMatcher) augmentBlossom(b, v int) {
+	// Bubble up through the blossom tree from vertex v to an immediate sub-blossom of b.
+	t := v
+	for m.blossomparent[t] != b {
+		t = m.blossomparent[t]
+	}
+	// Recursively deal with the first sub-blossom.
+	if t >= m.nvertex {
+		m.augmentBlossom(t, v)
+	}
+
+	childs := m.blossomchilds[b]
+	endps := m.blossomendps[b]
+	n := len(childs)
+	// Decide in which direction we will go round the blossom.
+	i := indexOf(childs, t)
+	j := i
+	var jstep, endptrick int
+	if i&1 != 0 {
+		// Start index is odd, go forward and wrap.
+		j -= n
+		jstep = 1
+		endptrick = 0
+	} else {
+		// Start index is even, go backward.
+		jstep = -1
+		endptrick = 1
+	}
+	// Move along the blossom until we get to the base.
+	for j != 0 {
+		// Step to the next sub-blossom and augment it recursively if necessary.
+		j += jstep
+		t = childs[wrap(j, n)]
+		p := endps[wrap(j-endptrick, n)] ^ endptrick
+		if t >= m.nvertex {
+			m.augmentBlossom(t, m.endpoint[p])
+		}
+		// Step to the next sub-blossom and augment it recursively if necessary.
+		j += jstep
+		t = childs[wrap(j, n)]
+		if t >= m.nvertex {
+			m.augmentBlossom(t, m.endpoint[p^1])
+		}
+		// Match the edge connecting those sub-blossoms.
+		m.mate[m.endpoint[p]] = p ^ 1
+		m.mate[m.endpoint[p^1]] = p
+	}
+	// Rotate the list of sub-blossoms to put the new base at the front.
+	m.blossomchilds[b] = append(append([]int{}, childs[i:]...), childs[:i]...)
+	m.blossomendps[b] = append(append([]int{}, endps[i:]...), endps[:i]...)
+	m.blossombase[b] = m.blossombase[m.blossomchilds[b][0]]
+}
+
+// augmentMatching swaps matched/unmatched edges over an alternating path between two
+// single vertices. The augmenting path runs through edge k, which connects a pair of S-vertices.
+func (m *blossomMatcher) augmentMatching(k int) {
+	e := m.edges[k]
+	for _, sp := range [][2]int{{e.i, 2*k + 1}, {e.j, 2 * k}} {
+		s, p := sp[0], sp[1]
+		// Match vertex s to remote endpoint p, then trace back from s until we find a single
+		// vertex, swapping matched and unmatched edges as we go.
+		for {
+			bs := m.inblossom[s]
+			// Augment through the S-blossom from s to base.
+			if bs >= m.nvertex {
+				m.augmentBlossom(bs, s)
+			}
+			m.mate[s] = p
+			// Trace one step back.
+			if m.labelend[bs] == -1 {
+				// Reached a single vertex, stop.
+				break
+			}
+			t := m.endpoint[m.labelend[bs]]
+			bt := m.inblossom[t]
+			// Trace one step back.
+			s = m.endpoint[m.labelend[bt]]
+			j := m.endpoint[m.labelend[bt]^1]
+			// Augment through the T-blossom from j to base.
+			if bt >= m.nvertex {
+				m.augmentBlossom(bt, j)
+			}
+			m.mate[j] = m.labelend[bt]
+			// Keep the opposite endpoint, it will be assigned to mate[s] in the next step.
+			p = m.labelend[bt] ^ 1
+		}
+	}
+}
+
+func (m *blossomMatcher) solve() {
+	nvertex := m.nvertex
+	// Each iteration of this loop is a stage. A stage finds an augmenting path and uses that
+	// to improve the matching.
+	for stage := 0; stage < nvertex; stage++ {
+		// Remove labels from top-level blossoms/vertices and forget least-slack edges.
+		for idx := range m.label {
+			m.label[idx] = 0
+			m.bestedge[idx] = -1
+		}
+		for b := nvertex; b < 2*nvertex; b++ {
+			m.blossombestedges[b] = nil
+			m.hasBestEdges[b] = false
+		}
+		for idx := range m.allowedge {
+			m.allowedge[idx] = false
+		}
+		m.queue = m.queue[:0]
+
+		// Label single blossoms/vertices with S and put them in the queue.
+		for v := 0; v < nvertex; v++ {
+			if m.mate[v] == -1 && m.label[m.inblossom[v]] == 0 {
+				m.assignLabel(v, 1, -1)
+			}
+		}
+
+		augmented := false
+		for {
+			// Continue labeling until all vertices which are reachable through an alternating
+			// path have got a label.
+			for len(m.queue) > 0 && !augmented {
+				v := m.queue[len(m.queue)-1]
+				m.queue = m.queue[:len(m.queue)-1]
+
+				for _, p := range m.neighbend[v] {
+					k := p / 2
+					w := m.endpoint[p]
+					// w is a neighbour of v, ignore it if they are in the same blossom.
+					if m.inblossom[v] == m.inblossom[w] {
+						continue
+					}
+					var kslack int64
+					if !m.allowedge[k] {
+						kslack = m.slack(k)
+						if kslack <= 0 {
+							// Edge k has zero slack, it is allowable.
+							m.allowedge[k] = true
+						}
+					}
+					if m.allowedge[k] {
+						if m.label[m.inblossom[w]] == 0 {
+							// w is a free vertex, label it with T and its mate with S.
+							m.assignLabel(w, 2, p^1)
+						} else if m.label[m.inblossom[w]] == 1 {
+							// w is an S-vertex, it is either a new blossom or an augmenting path.
+							base := m.scanBlossom(v, w)
+							if base >= 0 {
+								m.addBlossom(base, k)
+							} else {
+								m.augmentMatching(k)
+								augmented = true
+								break
+							}
+						} else if m.label[w] == 0 {
+							// w is inside a T-blossom but w itself has not yet been reached
+							// from outside the blossom, mark it as reached.
+							m.label[w] = 2
+							m.labelend[w] = p ^ 1
+						}
+					} else if m.label[m.inblossom[w]] == 1 {
+						// Keep track of the least-slack non-allowable edge to a different S-blossom.
+						b := m.inblossom[v]
+						if m.bestedge[b] == -1 || kslack < m.slack(m.bestedge[b]) {
+							m.bestedge[b] = k
+						}
+					} else if m.label[w] == 0 {
+						// w is a free vertex, or an unreached vertex inside a T-blossom. Keep
+						// track of the least-slack edge that reaches w.
+						if m.bestedge[w] == -1 || kslack < m.slack(m.bestedge[w]) {
+							m.bestedge[w] = k
+						}
+					}
+				}
+			}
+
+			if augmented {
+				break
+			}
+
+			// There is no augmenting path under these constraints, compute delta and reduce
+			// slack in the optimization problem.
+			deltatype := -1
+			var delta int64
+			deltaedge, deltablossom := -1, -1
+
+			// Compute delta1: the minimum value of any vertex dual.
+			if !m.maxCardinality {
+				deltatype = 1
+				delta = m.dualvar[0]
+				for v := 1; v < nvertex; v++ {
+					if m.dualvar[v] < delta {
+						delta = m.dualvar[v]
+					}
+				}
+			}
+
+			// Compute delta2: the minimum slack on any edge between an S-vertex and a free vertex.
+			for v := 0; v < nvertex; v++ {
+				if m.label[m.inblossom[v]] == 0 && m.bestedge[v] != -1 {
+					d := m.slack(m.bestedge[v])
+					if deltatype == -1 || d < delta {
+						delta = d
+						deltatype = 2
+						deltaedge = m.bestedge[v]
+					}
+				}
+			}
+
+			// Compute delta3: half the minimum slack on any edge between a pair of S-blossoms.
+			for b := 0; b < 2*nvertex; b++ {
+				if m.blossomparent[b] == -1 && m.label[b] == 1 && m.bestedge[b] != -1 {
+					d := m.slack(m.bestedge[b]) / 2
+					if deltatype == -1 || d < delta {
+						delta = d
+						deltatype = 3
+						deltaedge = m.bestedge[b]
+					}
+				}
+			}
+
+			// Compute delta4: the minimum z variable of any T-blossom.
+			for b := nvertex; b < 2*nvertex; b++ {
+				if m.blossombase[b] >= 0 && m.blossomparent[b] == -1 && m.label[b] == 2 &&
+					(deltatype == -1 || m.dualvar[b] < delta) {
+					delta = m.dualvar[b]
+					deltatype = 4
+					deltablossom = b
+				}
+			}
+
+			if deltatype == -1 {
+				// No further improvement possible, max-cardinality optimum reached.
+				// Do a final delta update to make the optimum verifiable.
+				deltatype = 1
+				delta = m.dualvar[0]
+				for v := 1; v < nvertex; v++ {
+					if m.dualvar[v] < delta {
+						delta = m.dualvar[v]
+					}
+				}
+				if delta < 0 {
+					delta = 0
+				}
+			}
+
+			// Update dual variables according to delta.
+			for v := 0; v < nvertex; v++ {
+				if m.label[m.inblossom[v]] == 1 {
+					m.dualvar[v] -= delta
+				} else if m.label[m.inblossom[v]] == 2 {
+					m.dualvar[v] += delta
+				}
+			}
+			for b := nvertex; b < 2*nvertex; b++ {
+				if m.blossombase[b] >= 0 && m.blossomparent[b] == -1 {
+					if m.label[b] == 1 {
+						m.dualvar[b] += delta
+					} else if m.label[b] == 2 {
+						m.dualvar[b] -= delta
+					}
+				}
+			}
+
+			// Take action at the point where the minimum delta occurred.
+			if deltatype == 1 {
+				// No further improvement possible, optimum reached.
+				break
+			} else if deltatype == 2 {
+				// Use the least-slack edge to continue the search.
+				m.allowedge[deltaedge] = true
+				i, j := m.edges[deltaedge].i, m.edges[deltaedge].j
+				if m.label[m.inblossom[i]] == 0 {
+					i, j = j, i
+				}
+				m.queue = append(m.queue, i)
+			} else if deltatype == 3 {
+				// Use the least-slack edge to continue the search.
+				m.allowedge[deltaedge] = true
+				m.queue = append(m.queue, m.edges[deltaedge].i)
+			} else if deltatype == 4 {
+				// Expand the least-z blossom.
+				m.expandBlossom(deltablossom, false)
+			}
+		}
+
+		// Stop when no more augmenting path can be found.
+		if !augmented {
+			break
+		}
+
+		// End of a stage, expand all S-blossoms which have dualvar = 0.
+		for b := nvertex; b < 2*nvertex; b++ {
+			if m.blossomparent[b] == -1 && m.blossombase[b] >= 0 && m.label[b] == 1 && m.dualvar[b] == 0 {
+				m.expandBlossom(b, true)
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package arranger
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// bruteForceMatching returns the best (cardinality, weight) over all matchings of the graph.
+func bruteForceMatching(nvertex int, edges []weightedEdge, maxCardinality bool) (int, int64) {
+	used := make([]bool, nvertex)
+	bestCard, bestWeight := 0, int64(0)
+	var try func(k, card int, weight int64)
+	try = func(k, card int, weight int64) {
+		if k == len(edges) {
+			if maxCardinality {
+				if card > bestCard || (card == bestCard && weight > bestWeight) {
+					bestCard, bestWeight = card, weight
+				}
+			} else if weight > bestWeight {
+				bestCard, bestWeight = card, weight
+			}
+			return
+		}
+		try(k+1, card, weight)
+		e := edges[k]
+		if !used[e.i] && !used[e.j] {
+			used[e.i], used[e.j] = true, true
+			try(k+1, card+1, weight+e.weight)
+			used[e.i], used[e.j] = false, false
+		}
+	}
+	try(0, 0, 0)
+	return bestCard, bestWeight
+}
+
+func TestMaxWeightMatching(t *testing.T) {
+	cases := []struct {
+		title          string
+		edges          []weightedEdge
+		maxCardinality bool
+		expected       []int
+	}{
+		{
+			"Empty",
+			[]weightedEdge{},
+			false,
+			[]int{},
+		},
+		{
+			"SingleEdge",
+			[]weightedEdge{{0, 1, 1}},
+			false,
+			[]int{1, 0},
+		},
+		{
+			"HeavierMiddleEdge",
+			[]weightedEdge{{1, 2, 10}, {2, 3, 11}},
+			false,
+			[]int{-1, -1, 3, 2},
+		},
+		{
+			"MaxCardinalityOverWeight",
+			[]weightedEdge{{1, 2, 5}, {2, 3, 11}, {3, 4, 5}},
+			true,
+			[]int{-1, 2, 1, 4, 3},
+		},
+		{
+			"SBlossom",
+			[]weightedEdge{{1, 2, 8}, {1, 3, 9}, {2, 3, 10}, {3, 4, 7}},
+			false,
+			[]int{-1, 2, 1, 4, 3},
+		},
+		{
+			"TBlossomExpansion",
+			[]weightedEdge{{1, 2, 23}, {1, 5, 22}, {1, 6, 15}, {2, 3, 25}, {3, 4, 22}, {4, 5, 25}, {4, 8, 14}, {5, 7, 13}},
+			false,
+			[]int{-1, 6, 3, 2, 8, 7, 1, 5, 4},
+		},
+		{
+			"NestedSBlossomRelabel",
+			[]weightedEdge{{1, 2, 19}, {1, 3, 20}, {1, 8, 8}, {2, 3, 25}, {2, 4, 18}, {3, 5, 18}, {4, 5, 13}, {4, 7, 7}, {5, 6, 7}},
+			false,
+			[]int{-1, 8, 3, 2, 7, 6, 5, 4, 1},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			mate := maxWeightMatching(tc.edges, tc.maxCardinality)
+			if len(mate) != len(tc.expected) {
+				t.Errorf("Unexpected matching, expected %v got %v", tc.expected, mate)
+				return
+			}
+			for idx := range mate {
+				if mate[idx] != tc.expected[idx] {
+					t.Errorf("Unexpected matching, expected %v got %v", tc.expected, mate)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestMaxWeightMatchingAgainstBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	for round := 0; round < 500; round++ {
+		nvertex := 2 + rng.Intn(7)
+		var edges []weightedEdge
+		for i := 0; i < nvertex; i++ {
+			for j := i + 1; j < nvertex; j++ {
+				if rng.Intn(3) > 0 {
+					edges = append(edges, weightedEdge{i, j, int64(rng.Intn(20))})
+				}
+			}
+		}
+		if len(edges) == 0 {
+			continue
+		}
+		maxCardinality := round%2 == 0
+
+		mate := maxWeightMatching(edges, maxCardinality)
+		card, weight := 0, int64(0)
+		for _, e := range edges {
+			if e.i < len(mate) && mate[e.i] == e.j {
+				card++
+				weight += e.weight
+			}
+		}
+		expectedCard, expectedWeight := bruteForceMatching(nvertex, edges, maxCardinality)
+		if weight != expectedWeight || (maxCardinality && card != expectedCard) {
+			t.Fatalf("Unexpected matching %v for edges %v (maxCardinality %v), expected cardinality %d weight %d got %d %d",
+				mate, edges, maxCardinality, expectedCard, expectedWeight, card, weight)
+		}
+	}
+}
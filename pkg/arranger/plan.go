@@ -0,0 +1,267 @@
+package arranger
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/yushenli/badminton_match_table/pkg/model"
+)
+
+// Plan holds the arrangements of consecutive upcoming rounds.
+type Plan []model.MatchArrangement
+
+// planCandidates is the number of alternative arrangements PlanRounds considers for each round,
+// besides the one made by the Arranger.
+const planCandidates = 8
+
+// PlanRounds plans the given number of upcoming rounds for the players in input, the first
+// of which uses input.Seed. Rounds are planned one by one. For each round, the arrangement
+// made by the Arranger and a few alternatives made by swapping its players are considered.
+// Each of them is followed by arranging the rest of the rounds with the Arranger, assuming
+// everyone stays available, and the one leading to the lowest total cost over all the
+// rounds is kept. So partner/opponent variety and rest fairness are optimised over the whole
+// horizon instead of only the next round.
+//
+// The match history of the players in input is not modified.
+func PlanRounds(arranger Arranger, input Input, rounds int) (Plan, error) {
+	if rounds <= 0 {
+		return nil, fmt.Errorf("at least 1 round needs to be planned, %d given", rounds)
+	}
+
+	sim, originals := cloneInput(input)
+	sim.Explanation = nil
+	rng := rand.New(rand.NewSource(int64(input.Seed)))
+
+	var plan Plan
+	for round := 0; round < rounds; round++ {
+		roundInput := sim
+		roundInput.Seed = input.Seed + round
+		arrangement, err := arranger.Arrange(roundInput)
+		if err != nil {
+			return nil, fmt.Errorf("failed to arrange round %d of the plan: %v", round+1, err)
+		}
+
+		candidates := []model.MatchArrangement{arrangement}
+		for i := 0; i < planCandidates; i++ {
+			if candidate, ok := perturbArrangement(roundInput, arrangement, rng); ok {
+				candidates = append(candidates, candidate)
+			}
+		}
+
+		best := arrangement
+		bestCost := -1.0
+		for _, candidate := range candidates {
+			cost, err := rolloutCost(arranger, roundInput, candidate, rounds-round)
+			if err != nil {
+				continue
+			}
+			if bestCost < 0 || cost < bestCost {
+				best = candidate
+				bestCost = cost
+			}
+		}
+
+		plan = append(plan, mapArrangement(best, originals))
+		ApplyArrangement(sim.Players, best)
+	}
+	return plan, nil
+}
+
+// rolloutCost returns the total cost of playing the arrangement in the round of input, followed
+// by the given number of rounds minus one arranged by the Arranger.
+func rolloutCost(arranger Arranger, input Input, arrangement model.MatchArrangement, rounds int) (float64, error) {
+	sim, clones := cloneInput(input)
+	arrangement = mapArrangement(arrangement, invert(clones))
+	cost := sim.CostModel()
+
+	var total float64
+	for round := 0; round < rounds; round++ {
+		if round > 0 {
+			sim.Seed = input.Seed + round
+			var err error
+			arrangement, err = arranger.Arrange(sim)
+			if err != nil {
+				return 0, err
+			}
+		}
+		total += cost.Breakdown(arrangement, BenchedPlayers(sim.Players, arrangement)).Total()
+		ApplyArrangement(sim.Players, arrangement)
+	}
+	return total, nil
+}
+
+// ApplyArrangement updates the match history of the players as if the arrangement was played,
+// which is how PlanRounds and offline simulations move on to the next round.
+func ApplyArrangement(players model.PlayerSlice, arrangement model.MatchArrangement) {
+	for _, match := range arrangement {
+		for _, side := range []model.Side{match.Side1, match.Side2} {
+			if side.Player1 != nil && side.Player2 != nil {
+				side.Player1.Partners[side.Player2]++
+				side.Player2.Partners[side.Player1]++
+			}
+		}
+		for _, player1 := range sidePlayers(match.Side1) {
+			for _, player2 := range sidePlayers(match.Side2) {
+				player1.Opponents[player2]++
+				player2.Opponents[player1]++
+			}
+		}
+	}
+
+	benched := make(map[*model.Player]bool)
+	for _, player := range BenchedPlayers(players, arrangement) {
+		benched[player] = true
+	}
+	for _, player := range players {
+		if benched[player] {
+			player.RoundsWaited++
+			if player.Matches > 0 {
+				player.RoundsSatOut++
+			}
+			continue
+		}
+		player.Matches++
+		player.RoundsWaited = 0
+	}
+}
+
+// cloneInput returns a copy of input whose players, teams and constraints are deep copies that
+// can be modified freely, together with the map from each copy to its original player.
+func cloneInput(input Input) (Input, map[*model.Player]*model.Player) {
+	clones := make(map[*model.Player]*model.Player)
+	clone := func(player *model.Player) *model.Player {
+		if c, ok := clones[player]; ok {
+			return c
+		}
+		c := *player
+		c.Team = nil
+		clones[player] = &c
+		return &c
+	}
+
+	ret := input
+	ret.AllPlayers = nil
+	for _, player := range input.AllPlayers {
+		ret.AllPlayers = append(ret.AllPlayers, clone(player))
+	}
+	ret.Players = nil
+	for _, player := range input.Players {
+		ret.Players = append(ret.Players, clone(player))
+	}
+
+	for original, c := range clones {
+		c.Partners = make(map[*model.Player]int)
+		for other, times := range original.Partners {
+			c.Partners[clone(other)] = times
+		}
+		c.Opponents = make(map[*model.Player]int)
+		for other, times := range original.Opponents {
+			c.Opponents[clone(other)] = times
+		}
+	}
+	for _, player := range input.Players {
+		if team := player.Team; team != nil && team.Player1 == player {
+			model.NewTeam(team.ID, clone(team.Player1), clone(team.Player2))
+		}
+	}
+
+	ret.Constraints = nil
+	for _, constraint := range input.Constraints {
+		ret.Constraints = append(ret.Constraints, Constraint{
+			Kind:    constraint.Kind,
+			Player1: clone(constraint.Player1),
+			Player2: clone(constraint.Player2),
+		})
+	}
+
+	originals := make(map[*model.Player]*model.Player)
+	for original, c := range clones {
+		originals[c] = original
+	}
+	return ret, originals
+}
+
+// invert returns the inverse of a one-to-one player map.
+func invert(players map[*model.Player]*model.Player) map[*model.Player]*model.Player {
+	ret := make(map[*model.Player]*model.Player)
+	for k, v := range players {
+		ret[v] = k
+	}
+	return ret
+}
+
+// mapArrangement returns a copy of the arrangement with the players replaced by the mapped ones.
+func mapArrangement(arrangement model.MatchArrangement, players map[*model.Player]*model.Player) model.MatchArrangement {
+	mapSide := func(side model.Side) model.Side {
+		var ret model.Side
+		if side.Player1 != nil {
+			ret.Player1 = players[side.Player1]
+		}
+		if side.Player2 != nil {
+			ret.Player2 = players[side.Player2]
+		}
+		return ret
+	}
+
+	ret := make(model.MatchArrangement, len(arrangement))
+	for idx, match := range arrangement {
+		ret[idx] = model.Match{Side1: mapSide(match.Side1), Side2: mapSide(match.Side2)}
+	}
+	return ret
+}
+
+// perturbArrangement returns a copy of the arrangement with two players swapped, either two
+// playing players or a playing player and a benched one. Players in teams are never swapped,
+// and a benched player only replaces one who has played as many matches or more, and has sat out
+// as many rounds in a row or fewer, so the picks stay as fair as PickPlayersForCourts makes them.
+// Returns false if the swap found breaks the constraints or fits the format worse.
+func perturbArrangement(input Input, arrangement model.MatchArrangement, rng *rand.Rand) (model.MatchArrangement, bool) {
+	ret := mapArrangement(arrangement, identity(input.Players))
+	var slots []**model.Player
+	for idx := range ret {
+		for _, slot := range []**model.Player{&ret[idx].Side1.Player1, &ret[idx].Side1.Player2,
+			&ret[idx].Side2.Player1, &ret[idx].Side2.Player2} {
+			if *slot != nil && (*slot).Teammate() == nil {
+				slots = append(slots, slot)
+			}
+		}
+	}
+	var benched model.PlayerSlice
+	for _, player := range BenchedPlayers(input.Players, arrangement) {
+		if player.Teammate() == nil {
+			benched = append(benched, player)
+		}
+	}
+	if len(slots) == 0 {
+		return nil, false
+	}
+
+	slot := slots[rng.Intn(len(slots))]
+	pick := rng.Intn(len(slots) + len(benched))
+	if pick < len(slots) {
+		if slots[pick] == slot {
+			return nil, false
+		}
+		*slot, *slots[pick] = *slots[pick], *slot
+	} else {
+		player := benched[pick-len(slots)]
+		if player.Matches > (*slot).Matches || player.RoundsWaited < (*slot).RoundsWaited {
+			return nil, false
+		}
+		*slot = player
+	}
+
+	if input.Constraints.Check(ret) != nil || input.Format.misfits(ret) > input.Format.misfits(arrangement) {
+		return nil, false
+	}
+	return ret, true
+}
+
+// identity returns the map from each player to themselves.
+func identity(players model.PlayerSlice) map[*model.Player]*model.Player {
+	ret := make(map[*model.Player]*model.Player)
+	for _, player := range players {
+		ret[player] = player
+	}
+	return ret
+}
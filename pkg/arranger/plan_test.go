@@ -0,0 +1,165 @@
+package arranger
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/yushenli/badminton_match_table/pkg/model"
+)
+
+// planCost returns the total cost of playing the plan round after round from the given input.
+func planCost(input Input, plan Plan) float64 {
+	sim, originals := cloneInput(input)
+	clones := invert(originals)
+	cost := sim.CostModel()
+
+	var total float64
+	for _, arrangement := range plan {
+		arrangement = mapArrangement(arrangement, clones)
+		total += cost.Breakdown(arrangement, BenchedPlayers(sim.Players, arrangement)).Total()
+		ApplyArrangement(sim.Players, arrangement)
+	}
+	return total
+}
+
+// greedyPlan arranges the rounds one by one with the arranger, without any lookahead.
+func greedyPlan(arranger Arranger, input Input, rounds int) Plan {
+	sim, originals := cloneInput(input)
+	var plan Plan
+	for round := 0; round < rounds; round++ {
+		sim.Seed = input.Seed + round
+		arrangement, err := arranger.Arrange(sim)
+		if err != nil {
+			return nil
+		}
+		plan = append(plan, mapArrangement(arrangement, originals))
+		ApplyArrangement(sim.Players, arrangement)
+	}
+	return plan
+}
+
+func TestPlanRounds(t *testing.T) {
+	cases := []struct {
+		title      string
+		count      int
+		courtCount int
+		rounds     int
+	}{
+		{"6Players1Court", 6, 1, 5},
+		{"9Players2Courts", 9, 2, 6},
+		{"13Players3Courts", 13, 3, 4},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			players := makeTeamPlayers(tc.count, nil)
+			constraints := Constraints{{NeverPartner, players[0], players[1]}}
+			input := Input{
+				AllPlayers:  players,
+				Players:     players,
+				CourtCount:  tc.courtCount,
+				Seed:        1,
+				Constraints: constraints,
+				// The hierarchical banding breaks ties in map order, which makes the greedy plan
+				// differ from run to run, so a banding which does not is used to compare the two.
+				Banding: FixedWidthBanding{Width: 1},
+			}
+
+			plan, err := PlanRounds(BandedArranger{}, input, tc.rounds)
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			if len(plan) != tc.rounds {
+				t.Errorf("Unexpected number of planned rounds, expected %d got %d", tc.rounds, len(plan))
+				return
+			}
+
+			for _, player := range players {
+				if player.Matches != 0 || len(player.Partners) != 0 || len(player.Opponents) != 0 {
+					t.Errorf("Unexpected change to the history of %s: %+v", player.Name, player)
+				}
+			}
+
+			var lastBenched map[*model.Player]bool
+			for round, arrangement := range plan {
+				if len(arrangement) != tc.courtCount {
+					t.Errorf("Unexpected number of matches in round %d, expected %d got %d", round+1, tc.courtCount, len(arrangement))
+				}
+				if err := constraints.Check(arrangement); err != nil {
+					t.Errorf("Unexpected broken constraint in round %d: %v", round+1, err)
+				}
+
+				benched := make(map[*model.Player]bool)
+				for _, player := range BenchedPlayers(players, arrangement) {
+					benched[player] = true
+				}
+				restedTwice, playedTwice := false, false
+				for _, player := range players {
+					if lastBenched != nil && lastBenched[player] && benched[player] {
+						restedTwice = true
+					}
+					if lastBenched != nil && !lastBenched[player] && !benched[player] {
+						playedTwice = true
+					}
+				}
+				if restedTwice && playedTwice {
+					t.Errorf("Round %d rests a player twice in a row while another plays twice in a row", round+1)
+				}
+				lastBenched = benched
+			}
+
+			greedy := greedyPlan(BandedArranger{}, input, tc.rounds)
+			if planCost(input, plan) > planCost(input, greedy) {
+				t.Errorf("Unexpected plan cost higher than the greedy one, %v > %v",
+					planCost(input, plan), planCost(input, greedy))
+			}
+		})
+	}
+}
+
+func TestPerturbArrangementKeepsPickFairness(t *testing.T) {
+	players := makeTeamPlayers(8, nil)
+	for idx, player := range players {
+		player.Matches = float32(idx % 3)
+		player.RoundsWaited = idx % 2
+	}
+	input := Input{AllPlayers: players, Players: players, CourtCount: 1, Seed: 1}
+	arrangement, err := BandedArranger{}.Arrange(input)
+	if err != nil {
+		t.Errorf("Not expecting error but got %v.", err)
+		return
+	}
+	playing := make(map[*model.Player]bool)
+	for _, match := range arrangement {
+		for _, player := range append(sidePlayers(match.Side1), sidePlayers(match.Side2)...) {
+			playing[player] = true
+		}
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		candidate, ok := perturbArrangement(input, arrangement, rng)
+		if !ok {
+			continue
+		}
+		var in, out model.PlayerSlice
+		for _, player := range BenchedPlayers(players, arrangement) {
+			if !containsPlayer(BenchedPlayers(players, candidate), player) {
+				in = append(in, player)
+			}
+		}
+		for _, player := range BenchedPlayers(players, candidate) {
+			if playing[player] {
+				out = append(out, player)
+			}
+		}
+		if len(in) != len(out) || len(in) > 1 {
+			t.Fatalf("Unexpected swap, %v in and %v out", playerNames(in), playerNames(out))
+		}
+		if len(in) == 1 && (in[0].Matches > out[0].Matches || in[0].RoundsWaited < out[0].RoundsWaited) {
+			t.Errorf("Unexpected swap of %s (%g matches, waited %d) in for %s (%g matches, waited %d)",
+				in[0].Name, in[0].Matches, in[0].RoundsWaited, out[0].Name, out[0].Matches, out[0].RoundsWaited)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package arranger
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/yushenli/badminton_match_table/pkg/model"
+)
+
+// DefaultArrangerName is the name of the Arranger used when an event does not choose one.
+const DefaultArrangerName = "banded"
+
+// Input holds everything an Arranger needs to arrange the next round.
+type Input struct {
+	// AllPlayers are all the players in the event, including the ones in break.
+	// They are used to understand the overall score distribution of the event.
+	AllPlayers model.PlayerSlice
+	// Players are the players who are available to play the next round. Their match
+	// history is expected to be filled already.
+	Players model.PlayerSlice
+	// CourtCount is the number of courts available for the next round.
+	CourtCount int
+	// Seed is used by arrangers which need randomness, so that the same input always
+	// leads to the same arrangement.
+	Seed int
+	// Cost is the CostModel used to evaluate arrangements, DefaultCostModel is used if it's nil.
+	Cost *CostModel
+	// Banding splits the players into score bands, HierarchicalBanding is used if it's nil.
+	Banding Banding
+	// Format decides which player categories may be combined in doubles matches.
+	Format Format
+	// BalanceSides splits the four players of a doubles match into the sides with the closest
+	// combined scores, e.g. the strongest and weakest against the two in between, instead of
+	// keeping players of similar scores on the same side. Repeats are still avoided first.
+	BalanceSides bool
+	// Constraints are the hard constraints between players that the arrangement must not break.
+	Constraints Constraints
+	// Explanation, when not nil, is filled by the Arranger with how the arrangement was made.
+	Explanation *Explanation
+}
+
+// CostModel returns the CostModel to be used for the input.
+func (input Input) CostModel() CostModel {
+	if input.Cost == nil {
+		return DefaultCostModel
+	}
+	return *input.Cost
+}
+
+// BandingStrategy returns the Banding to be used for the input.
+func (input Input) BandingStrategy() Banding {
+	if input.Banding == nil {
+		return HierarchicalBanding{}
+	}
+	return input.Banding
+}
+
+// Arranger is a strategy that turns a set of players and their history into the matches
+// of the next round.
+type Arranger interface {
+	Arrange(input Input) (model.MatchArrangement, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Arranger)
+)
+
+// Register makes an Arranger available by the given name.
+// Registering the same name twice, or registering a nil Arranger, panics.
+func Register(name string, arranger Arranger) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if arranger == nil {
+		panic(fmt.Sprintf("arranger: Register arranger %q is nil", name))
+	}
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("arranger: Register called twice for arranger %q", name))
+	}
+	registry[name] = arranger
+}
+
+// Lookup returns the Arranger registered under the given name.
+// An empty name returns the default Arranger.
+func Lookup(name string) (Arranger, error) {
+	if name == "" {
+		name = DefaultArrangerName
+	}
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	arranger, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown arranger %q", name)
+	}
+	return arranger, nil
+}
+
+// Names returns the sorted names of all the registered Arrangers.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,94 @@
+package arranger
+
+import (
+	"testing"
+
+	"github.com/yushenli/badminton_match_table/pkg/model"
+)
+
+type fakeArranger struct{}
+
+func (fakeArranger) Arrange(input Input) (model.MatchArrangement, error) {
+	return nil, nil
+}
+
+func TestLookup(t *testing.T) {
+	Register("fake-lookup", fakeArranger{})
+
+	cases := []struct {
+		title       string
+		name        string
+		expected    Arranger
+		expectedErr bool
+	}{
+		{
+			"EmptyNameIsDefault",
+			"",
+			BandedArranger{},
+			false,
+		},
+		{
+			"Banded",
+			"banded",
+			BandedArranger{},
+			false,
+		},
+		{
+			"Registered",
+			"fake-lookup",
+			fakeArranger{},
+			false,
+		},
+		{
+			"Unknown",
+			"unknown",
+			nil,
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			arranger, err := Lookup(tc.name)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("Expected error but got nil.")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			if arranger != tc.expected {
+				t.Errorf("Unexpected arranger, expected %T got %T", tc.expected, arranger)
+			}
+		})
+	}
+}
+
+func TestRegisterTwicePanics(t *testing.T) {
+	Register("fake-twice", fakeArranger{})
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected Register to panic on a duplicated name")
+		}
+	}()
+	Register("fake-twice", fakeArranger{})
+}
+
+func TestNames(t *testing.T) {
+	names := Names()
+	found := false
+	for idx, name := range names {
+		if name == DefaultArrangerName {
+			found = true
+		}
+		if idx > 0 && names[idx-1] >= name {
+			t.Errorf("Names are not sorted: %v", names)
+		}
+	}
+	if !found {
+		t.Errorf("Default arranger %q is not in %v", DefaultArrangerName, names)
+	}
+}
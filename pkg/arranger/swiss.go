@@ -0,0 +1,249 @@
+package arranger
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/yushenli/badminton_match_table/pkg/model"
+)
+
+func init() {
+	Register("swiss", SwissArranger{})
+}
+
+// swissFloatPenalty is the cost of pairing two players one score group apart, which dominates
+// the cost of the order within the groups, so players only float when they have to.
+const swissFloatPenalty = 10000
+
+// SwissArranger arranges rounds under the Swiss system. The players picked to play, as
+// PickPlayersForCourts does, are put into score groups of players with the same score, and
+// every player is paired with an opponent of their own group they have never played against.
+// Pairings which break a constraint are never made either.
+//
+// Within a score group, the top half plays the bottom half, i.e. the first player plays the
+// player right after the middle of the group. To avoid a rematch, the opponents are shifted
+// within the bottom half first, before two players of the same half are paired. A player floats
+// to another group when their group has an odd number of players, or when they cannot be paired
+// inside it without a rematch. Floats are decided by a min-cost perfect matching where a pairing
+// costs the square of how many groups apart the players are, so as few players as possible float
+// and never farther than needed. When a group is odd, its lowest ranked player floats down to
+// meet the highest ranked player of the next group, as the pairing closest to top half against
+// bottom half.
+//
+// Doubles courts take two pairings each, e.g. A against B and C against D, and put every pair of
+// opponents on the opposing sides: either A and C against B and D, or A and D against B and C,
+// whichever has the lower cost. A side split which would make a rematch, e.g. A against D when
+// they have met before, is not used. Formats and fixed partnerships are not considered, since the
+// Swiss system pairs individual players.
+type SwissArranger struct{}
+
+// Arrange implements Arranger. Returns error if the players cannot be paired without a rematch.
+func (SwissArranger) Arrange(input Input) (model.MatchArrangement, error) {
+	playingPlayers, err := PickPlayersForCourts(input.Players, input.CourtCount)
+	if err != nil {
+		return nil, err
+	}
+	_, singles, doubles, err := canPlayCount(input.CourtCount, len(playingPlayers))
+	if err != nil {
+		return nil, err
+	}
+
+	SortPlayerSliceByScorePriority(playingPlayers)
+	pairings, err := swissPairings(playingPlayers, input.Constraints)
+	if err != nil {
+		return nil, err
+	}
+
+	cost := input.CostModel()
+	if input.BalanceSides {
+		cost = cost.balancingSides()
+	}
+	arrangement, pairings, err := takeSwissDoubles(pairings, doubles, cost, input.Constraints)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < singles; i++ {
+		arrangement = append(arrangement, model.Match{
+			Side1: model.Side{Player1: pairings[i][0]},
+			Side2: model.Side{Player1: pairings[i][1]},
+		})
+	}
+
+	rand.Seed(int64(input.Seed))
+	rand.Shuffle(len(arrangement), func(i, j int) {
+		arrangement[i], arrangement[j] = arrangement[j], arrangement[i]
+	})
+	input.Explanation.explainArrangement(input, arrangement)
+	return arrangement, nil
+}
+
+// swissPairings pairs the players, sorted by score, into opponents under the Swiss system.
+// The pairings are returned in the order of their higher ranked players.
+func swissPairings(players model.PlayerSlice, constraints Constraints) ([][2]*model.Player, error) {
+	// groupOf is the score group of each player, and rankIn and sizeOf their rank within it and
+	// the size of the group.
+	groupOf := make([]int, len(players))
+	rankIn := make([]int, len(players))
+	var sizes []int
+	for i, player := range players {
+		if i == 0 || player.Score != players[i-1].Score {
+			sizes = append(sizes, 0)
+		}
+		groupOf[i] = len(sizes) - 1
+		rankIn[i] = sizes[groupOf[i]]
+		sizes[groupOf[i]]++
+	}
+
+	cost := func(i, j int) int64 {
+		distance := int64(groupOf[j] - groupOf[i])
+		// The ideal opponent is half a group below; a player floating down is ideally the
+		// bottom of their group, met by the top of the next group.
+		half := sizes[groupOf[i]] / 2
+		if half == 0 {
+			half = 1
+		}
+		offset := int64(j - i - half)
+		if offset < 0 {
+			offset = -offset
+		}
+		if groupOf[j] != groupOf[i] {
+			offset = int64(sizes[groupOf[i]] - 1 - rankIn[i] + rankIn[j])
+		} else if (rankIn[i] < half) == (rankIn[j] < half) {
+			// Two players of the same half only meet when the halves cannot be paired.
+			offset += int64(half)
+		}
+		return swissFloatPenalty*distance*distance + offset
+	}
+	allowed := func(i, j int) bool {
+		return players[i].Opponents[players[j]] == 0 && constraints.canOppose(players[i], players[j])
+	}
+
+	items := make([]int, len(players))
+	for i := range items {
+		items[i] = i
+	}
+	pairs, _, ok := perfectMatching(items, cost, allowed)
+	if !ok {
+		return nil, fmt.Errorf("the %d playing players cannot be paired without a rematch", len(players))
+	}
+
+	ret := make([][2]*model.Player, len(pairs))
+	for idx, pair := range pairs {
+		ret[idx] = [2]*model.Player{players[pair[0]], players[pair[1]]}
+	}
+	return ret, nil
+}
+
+// takeSwissDoubles combines the pairings into the given number of doubles courts without a
+// rematch, and returns the matches and the remaining pairings for the singles courts.
+// Every pairing is tried with the next ones in order, so the higher ranked pairings share a court
+// whenever a complete set of courts allows it; a combination which leaves the remaining pairings
+// unable to fill the other courts is backtracked from.
+func takeSwissDoubles(pairings [][2]*model.Player, doubles int, cost CostModel, constraints Constraints) (
+	model.MatchArrangement, [][2]*model.Player, error) {
+	if len(pairings) > 64 {
+		return nil, nil, fmt.Errorf("cannot combine %d pairings into doubles courts", len(pairings))
+	}
+	// merged caches the best match of two pairings, or nil if they cannot share a court.
+	merged := map[[2]int]*model.Match{}
+	merge := func(i, j int) *model.Match {
+		if match, ok := merged[[2]int{i, j}]; ok {
+			return match
+		}
+		first, other := pairings[i], pairings[j]
+		var best *model.Match
+		for _, candidate := range []model.Match{
+			{Side1: model.Side{Player1: first[0], Player2: other[0]}, Side2: model.Side{Player1: first[1], Player2: other[1]}},
+			{Side1: model.Side{Player1: first[0], Player2: other[1]}, Side2: model.Side{Player1: first[1], Player2: other[0]}},
+		} {
+			if constraints.Check(model.MatchArrangement{candidate}) != nil ||
+				!canSwissOppose(candidate, constraints) {
+				continue
+			}
+			if best == nil || cost.MatchCost(candidate).Total() < cost.MatchCost(*best).Total() {
+				candidate := candidate
+				best = &candidate
+			}
+		}
+		merged[[2]int{i, j}] = best
+		return best
+	}
+
+	// failed records the sets of used pairings, with the number of courts left, from which the
+	// courts cannot be filled.
+	failed := map[[2]uint64]bool{}
+	var arrangement model.MatchArrangement
+	var rest [][2]*model.Player
+	var search func(used uint64, left int) bool
+	search = func(used uint64, left int) bool {
+		if left == 0 {
+			for i, pairing := range pairings {
+				if used&(1<<uint(i)) == 0 {
+					rest = append(rest, pairing)
+				}
+			}
+			return true
+		}
+		key := [2]uint64{used, uint64(left)}
+		if failed[key] {
+			return false
+		}
+		first := 0
+		for first < len(pairings) && used&(1<<uint(first)) != 0 {
+			first++
+		}
+		free := 0
+		for i := first; i < len(pairings); i++ {
+			if used&(1<<uint(i)) == 0 {
+				free++
+			}
+		}
+		if free < 2*left {
+			failed[key] = true
+			return false
+		}
+		for k := first + 1; k < len(pairings); k++ {
+			if used&(1<<uint(k)) != 0 {
+				continue
+			}
+			match := merge(first, k)
+			if match == nil {
+				continue
+			}
+			arrangement = append(arrangement, *match)
+			if search(used|1<<uint(first)|1<<uint(k), left-1) {
+				return true
+			}
+			arrangement = arrangement[:len(arrangement)-1]
+		}
+		// The first pairing may also be left to a singles court, if there are more pairings than
+		// the doubles courts take.
+		if free > 2*left {
+			if search(used|1<<uint(first), left) {
+				rest = append([][2]*model.Player{pairings[first]}, rest...)
+				return true
+			}
+		}
+		failed[key] = true
+		return false
+	}
+
+	if !search(0, doubles) {
+		return nil, nil, fmt.Errorf("the %d pairings cannot be combined into %d doubles courts without a rematch",
+			len(pairings), doubles)
+	}
+	return arrangement, rest, nil
+}
+
+// canSwissOppose returns whether every player of a match may play against every player of the
+// other side, i.e. they have never played against each other and no constraint keeps them apart.
+func canSwissOppose(match model.Match, constraints Constraints) bool {
+	for _, a := range sidePlayers(match.Side1) {
+		for _, b := range sidePlayers(match.Side2) {
+			if a.Opponents[b] > 0 || !constraints.canOppose(a, b) {
+				return false
+			}
+		}
+	}
+	return true
+}
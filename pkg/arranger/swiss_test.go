@@ -0,0 +1,282 @@
+package arranger
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/yushenli/badminton_match_table/pkg/model"
+)
+
+// swissPlayers returns players with the given scores, where met lists the pairs of players,
+// by index, who have played against each other.
+func swissPlayers(scores []float32, met [][2]int) model.PlayerSlice {
+	var players model.PlayerSlice
+	for idx, score := range scores {
+		players = append(players, &model.Player{
+			ID:        idx + 1,
+			Name:      fmt.Sprintf("Name%d", idx+1),
+			Score:     score,
+			Priority:  float32(len(scores) - idx),
+			Partners:  make(map[*model.Player]int),
+			Opponents: make(map[*model.Player]int),
+		})
+	}
+	for _, pair := range met {
+		players[pair[0]].Opponents[players[pair[1]]]++
+		players[pair[1]].Opponents[players[pair[0]]]++
+	}
+	return players
+}
+
+func TestSwissPairings(t *testing.T) {
+	cases := []struct {
+		title    string
+		scores   []float32
+		met      [][2]int
+		expected [][2]int
+		err      bool
+	}{
+		{
+			"TopHalfAgainstBottomHalf",
+			[]float32{0, 0, 0, 0, 0, 0},
+			nil,
+			[][2]int{{0, 3}, {1, 4}, {2, 5}},
+			false,
+		},
+		{
+			"ScoreGroups",
+			[]float32{2, 2, 1, 1, 1, 1},
+			nil,
+			[][2]int{{0, 1}, {2, 4}, {3, 5}},
+			false,
+		},
+		{
+			"LowestFloatsDown",
+			[]float32{2, 2, 2, 1, 1, 1},
+			nil,
+			[][2]int{{0, 1}, {2, 3}, {4, 5}},
+			false,
+		},
+		{
+			"RematchAvoidedWithinGroup",
+			[]float32{1, 1, 1, 1},
+			[][2]int{{0, 2}},
+			[][2]int{{0, 3}, {1, 2}},
+			false,
+		},
+		{
+			"RematchForcesFloat",
+			[]float32{2, 2, 1, 1},
+			[][2]int{{0, 1}},
+			[][2]int{{0, 2}, {1, 3}},
+			false,
+		},
+		{
+			"NoPairingWithoutRematch",
+			[]float32{1, 0},
+			[][2]int{{0, 1}},
+			nil,
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			players := swissPlayers(tc.scores, tc.met)
+			pairings, err := swissPairings(players, nil)
+			if tc.err {
+				if err == nil {
+					t.Errorf("Expected error but got nil.")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			var got [][2]int
+			for _, pairing := range pairings {
+				got = append(got, [2]int{pairing[0].ID - 1, pairing[1].ID - 1})
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.expected) {
+				t.Errorf("Unexpected pairings, expected %v got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestSwissArrangerArrange(t *testing.T) {
+	cases := []struct {
+		title           string
+		playerCount     int
+		courtCount      int
+		expectedMatches int
+		expectedPlayers int
+	}{
+		{"AllSingles", 4, 2, 2, 4},
+		{"AllDoubles", 9, 2, 2, 8},
+		{"OneSingles", 6, 2, 2, 6},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			var scores []float32
+			for i := 0; i < tc.playerCount; i++ {
+				scores = append(scores, float32(i%3))
+			}
+			// Everyone has played the next player, which the Swiss system must not pair again.
+			var met [][2]int
+			for i := 0; i+1 < tc.playerCount; i += 2 {
+				met = append(met, [2]int{i, i + 1})
+			}
+			players := swissPlayers(scores, met)
+
+			arrangement, err := SwissArranger{}.Arrange(Input{
+				AllPlayers: players,
+				Players:    players,
+				CourtCount: tc.courtCount,
+			})
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			if len(arrangement) != tc.expectedMatches {
+				t.Errorf("Unexpected matches, expected %d got %d", tc.expectedMatches, len(arrangement))
+			}
+			playing := 0
+			for _, match := range arrangement {
+				side1, side2 := sidePlayers(match.Side1), sidePlayers(match.Side2)
+				playing += len(side1) + len(side2)
+				if len(side1) == 1 && side1[0].Opponents[side2[0]] > 0 {
+					t.Errorf("Unexpected rematch between %s and %s", side1[0].Name, side2[0].Name)
+				}
+			}
+			if playing != tc.expectedPlayers {
+				t.Errorf("Unexpected playing players, expected %d got %d", tc.expectedPlayers, playing)
+			}
+		})
+	}
+}
+
+func TestTakeSwissDoublesAvoidsRematch(t *testing.T) {
+	cases := []struct {
+		title         string
+		met           [][2]int
+		expected      []int
+		expectedError bool
+	}{
+		// Both ways to merge Name1-Name2 and Name3-Name4 put Name1 against a past opponent.
+		{"SkipsRematchPairing", [][2]int{{0, 2}, {0, 3}}, []int{1, 2, 5, 6}, false},
+		{"KeepsSplitWithoutRematch", [][2]int{{0, 3}}, []int{1, 2, 3, 4}, false},
+		// Name1-Name2 cannot share a court with any pairing, so it is left to the singles court.
+		{"LeavesPairingToSingles", [][2]int{{0, 2}, {0, 3}, {0, 4}, {0, 5}}, []int{3, 4, 5, 6}, false},
+		{"NoPairingLeft", [][2]int{{0, 2}, {0, 3}, {0, 4}, {0, 5}, {2, 4}, {2, 5}}, nil, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			players := swissPlayers([]float32{0, 0, 0, 0, 0, 0}, tc.met)
+			pairings := [][2]*model.Player{
+				{players[0], players[1]},
+				{players[2], players[3]},
+				{players[4], players[5]},
+			}
+			matches, rest, err := takeSwissDoubles(pairings, 1, DefaultCostModel, nil)
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("Expected error but got nil.")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			if len(matches) != 1 || len(rest) != 1 {
+				t.Errorf("Unexpected matches and remaining pairings, expected 1 and 1 got %d and %d",
+					len(matches), len(rest))
+				return
+			}
+
+			match := matches[0]
+			var got []int
+			for _, a := range sidePlayers(match.Side1) {
+				got = append(got, a.ID)
+				for _, b := range sidePlayers(match.Side2) {
+					if a.Opponents[b] > 0 {
+						t.Errorf("Unexpected rematch between %s and %s", a.Name, b.Name)
+					}
+				}
+			}
+			for _, b := range sidePlayers(match.Side2) {
+				got = append(got, b.ID)
+			}
+			sort.Ints(got)
+			if fmt.Sprint(got) != fmt.Sprint(tc.expected) {
+				t.Errorf("Unexpected players, expected %v got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestTakeSwissDoublesSearchesPairings(t *testing.T) {
+	// The pairings are Name1-Name2, Name3-Name4, Name5-Name6 and Name7-Name8. The first can share
+	// a court with the second and the third, the third only with the first, and the second with
+	// the fourth, so taking the first two pairings together leaves the last two unable to meet.
+	met := [][2]int{{0, 6}, {0, 7}, {4, 2}, {4, 3}, {4, 6}, {4, 7}}
+	cases := []struct {
+		title         string
+		doubles       int
+		expected      []string
+		expectedRest  int
+		expectedError bool
+	}{
+		{"AllDoubles", 2, []string{"[1 2 5 6]", "[3 4 7 8]"}, 0, false},
+		{"WithSingles", 1, []string{"[1 2 3 4]"}, 2, false},
+		{"TooManyCourts", 3, nil, 0, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			players := swissPlayers([]float32{0, 0, 0, 0, 0, 0, 0, 0}, met)
+			pairings := [][2]*model.Player{
+				{players[0], players[1]},
+				{players[2], players[3]},
+				{players[4], players[5]},
+				{players[6], players[7]},
+			}
+			matches, rest, err := takeSwissDoubles(pairings, tc.doubles, DefaultCostModel, nil)
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("Expected error but got nil.")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			if len(rest) != tc.expectedRest {
+				t.Errorf("Unexpected remaining pairings, expected %d got %d", tc.expectedRest, len(rest))
+			}
+
+			var got []string
+			for _, match := range matches {
+				if !canSwissOppose(match, nil) {
+					t.Errorf("Unexpected rematch in %v", match)
+				}
+				var ids []int
+				for _, player := range append(sidePlayers(match.Side1), sidePlayers(match.Side2)...) {
+					ids = append(ids, player.ID)
+				}
+				sort.Ints(ids)
+				got = append(got, fmt.Sprint(ids))
+			}
+			sort.Strings(got)
+			if fmt.Sprint(got) != fmt.Sprint(tc.expected) {
+				t.Errorf("Unexpected matches, expected %v got %v", tc.expected, got)
+			}
+		})
+	}
+}
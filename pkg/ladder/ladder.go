@@ -0,0 +1,95 @@
+// Package ladder keeps a club ladder, a ranking which persists across events, where players move up
+// by beating the players above them in challenges.
+package ladder
+
+import (
+	"fmt"
+	"time"
+)
+
+// Ladder is the ranking of a ladder, the names of the players from the top down.
+// Players are identified by their names, as they are entered again for every event.
+type Ladder []string
+
+// Position returns the 1-based position of the player, or 0 if the player is not on the ladder.
+func (l Ladder) Position(name string) int {
+	for idx, entry := range l {
+		if entry == name {
+			return idx + 1
+		}
+	}
+	return 0
+}
+
+// Join returns the ladder with the player added to the bottom, unless they are on it already.
+func (l Ladder) Join(name string) Ladder {
+	if l.Position(name) != 0 {
+		return l
+	}
+	return append(append(Ladder{}, l...), name)
+}
+
+// Apply returns the ladder after the result of a challenge. A challenger who wins takes the place
+// of the defender, who moves down one place along with everyone in between. Nothing changes when
+// the defender wins.
+func (l Ladder) Apply(challenger, defender string, challengerWon bool) Ladder {
+	ret := append(Ladder{}, l...)
+	from, to := l.Position(challenger), l.Position(defender)
+	if !challengerWon || from == 0 || to == 0 || to > from {
+		return ret
+	}
+	copy(ret[to:from], l[to-1:from-1])
+	ret[to-1] = challenger
+	return ret
+}
+
+// Challenge is a challenge between two players of a ladder.
+type Challenge struct {
+	Challenger string
+	Defender   string
+	Created    time.Time
+	// Scheduled is set once the challenge has a match to be played in.
+	Scheduled bool
+}
+
+// Rules are the rules for the challenges of a ladder.
+type Rules struct {
+	// Reach is how many places above their own a player may challenge.
+	Reach int
+	// Expiry is how long a challenge may wait to be scheduled before it lapses, 0 for never.
+	Expiry time.Duration
+}
+
+// Check returns an error if the challenger may not challenge the defender, given the challenges
+// which are still open. Both must be on the ladder, the defender must be above the challenger
+// within the reach, and neither may be in another open challenge.
+func (r Rules) Check(l Ladder, open []Challenge, challenger, defender string) error {
+	from, to := l.Position(challenger), l.Position(defender)
+	if from == 0 {
+		return fmt.Errorf("%q is not on the ladder", challenger)
+	}
+	if to == 0 {
+		return fmt.Errorf("%q is not on the ladder", defender)
+	}
+	if to >= from {
+		return fmt.Errorf("%q at %d may only challenge players above, not %q at %d", challenger, from, defender, to)
+	}
+	if from-to > r.Reach {
+		return fmt.Errorf("%q at %d may challenge at most %d place(s) above, not %q at %d",
+			challenger, from, r.Reach, defender, to)
+	}
+	for _, c := range open {
+		for _, name := range []string{challenger, defender} {
+			if c.Challenger == name || c.Defender == name {
+				return fmt.Errorf("%q is already in the open challenge of %q against %q", name, c.Challenger, c.Defender)
+			}
+		}
+	}
+	return nil
+}
+
+// Expired returns whether the challenge has lapsed at the given time, i.e. it has waited longer
+// than the expiry without being scheduled.
+func (r Rules) Expired(c Challenge, now time.Time) bool {
+	return r.Expiry > 0 && !c.Scheduled && !now.Before(c.Created.Add(r.Expiry))
+}
@@ -0,0 +1,106 @@
+package ladder
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestApply(t *testing.T) {
+	ladder := Ladder{"a", "b", "c", "d", "e"}
+	cases := []struct {
+		title         string
+		challenger    string
+		defender      string
+		challengerWon bool
+		expected      Ladder
+	}{
+		{"ChallengerWins", "d", "b", true, Ladder{"a", "d", "b", "c", "e"}},
+		{"ChallengerWinsOnePlaceUp", "b", "a", true, Ladder{"b", "a", "c", "d", "e"}},
+		{"ChallengerLoses", "d", "b", false, Ladder{"a", "b", "c", "d", "e"}},
+		{"FromTheBottom", "e", "c", true, Ladder{"a", "b", "e", "c", "d"}},
+		{"UnknownPlayer", "x", "a", true, Ladder{"a", "b", "c", "d", "e"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			actual := ladder.Apply(tc.challenger, tc.defender, tc.challengerWon)
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("Unexpected ladder, expected %v got %v", tc.expected, actual)
+			}
+		})
+	}
+	if !reflect.DeepEqual(ladder, Ladder{"a", "b", "c", "d", "e"}) {
+		t.Errorf("Unexpected change to the original ladder %v", ladder)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	ladder := Ladder{"a", "b"}
+	if actual := ladder.Join("c"); !reflect.DeepEqual(actual, Ladder{"a", "b", "c"}) {
+		t.Errorf("Unexpected ladder, expected %v got %v", Ladder{"a", "b", "c"}, actual)
+	}
+	if actual := ladder.Join("a"); !reflect.DeepEqual(actual, Ladder{"a", "b"}) {
+		t.Errorf("Unexpected ladder, expected %v got %v", Ladder{"a", "b"}, actual)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ladder := Ladder{"a", "b", "c", "d", "e", "f"}
+	rules := Rules{Reach: 2}
+	open := []Challenge{{Challenger: "f", Defender: "e"}}
+	cases := []struct {
+		title       string
+		challenger  string
+		defender    string
+		expectError bool
+	}{
+		{"WithinReach", "d", "b", false},
+		{"OnePlaceUp", "b", "a", false},
+		{"BeyondReach", "d", "a", true},
+		{"Downwards", "b", "d", true},
+		{"Themselves", "b", "b", true},
+		{"ChallengerNotOnLadder", "x", "a", true},
+		{"DefenderNotOnLadder", "a", "x", true},
+		{"DefenderInOpenChallenge", "f", "d", true},
+		{"ChallengerInOpenChallenge", "e", "c", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			err := rules.Check(ladder, open, tc.challenger, tc.defender)
+			if tc.expectError && err == nil {
+				t.Errorf("Expected error but got nil.")
+			}
+			if !tc.expectError && err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+			}
+		})
+	}
+}
+
+func TestExpired(t *testing.T) {
+	created := time.Date(2024, 3, 1, 19, 0, 0, 0, time.UTC)
+	cases := []struct {
+		title     string
+		expiry    time.Duration
+		scheduled bool
+		now       time.Time
+		expected  bool
+	}{
+		{"Fresh", 7 * 24 * time.Hour, false, created.Add(24 * time.Hour), false},
+		{"Lapsed", 7 * 24 * time.Hour, false, created.Add(7 * 24 * time.Hour), true},
+		{"Scheduled", 7 * 24 * time.Hour, true, created.Add(30 * 24 * time.Hour), false},
+		{"NeverExpires", 0, false, created.Add(365 * 24 * time.Hour), false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			c := Challenge{Challenger: "b", Defender: "a", Created: created, Scheduled: tc.scheduled}
+			actual := Rules{Reach: 1, Expiry: tc.expiry}.Expired(c, tc.now)
+			if actual != tc.expected {
+				t.Errorf("Unexpected expired, expected %v got %v", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+// Package metrics measures how fair and balanced the matches of an event, real or simulated,
+// have been: how evenly games were shared, how long players sat out, how often partners and
+// opponents repeated, how close the matches were and how busy the courts were.
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// Match is a match played in a round, where the sides are given by player IDs.
+type Match struct {
+	Court int
+	Side1 []int
+	Side2 []int
+	// Side1Score and Side2Score are the average scores of the sides when the match was arranged.
+	Side1Score float64
+	Side2Score float64
+}
+
+// PlayerMetrics holds the metrics of a single player.
+type PlayerMetrics struct {
+	ID    int `json:"id"`
+	Games int `json:"games"`
+	// MaxSitOutStreak is the most consecutive rounds the player sat out between their first and
+	// last matches. Rounds before a player arrived or after they left are not counted.
+	MaxSitOutStreak   int `json:"max_sit_out_streak"`
+	DistinctPartners  int `json:"distinct_partners"`
+	DistinctOpponents int `json:"distinct_opponents"`
+	PartnerRepeats    int `json:"partner_repeats"`
+	OpponentRepeats   int `json:"opponent_repeats"`
+}
+
+// Metrics holds the metrics of an event.
+type Metrics struct {
+	Rounds  int `json:"rounds"`
+	Matches int `json:"matches"`
+	// GamesMean, GamesVariance, GamesMin and GamesMax describe the games played per player.
+	GamesMean     float64 `json:"games_mean"`
+	GamesVariance float64 `json:"games_variance"`
+	GamesMin      int     `json:"games_min"`
+	GamesMax      int     `json:"games_max"`
+	// MaxSitOutStreak is the longest sit-out streak of any player, see PlayerMetrics.
+	MaxSitOutStreak int `json:"max_sit_out_streak"`
+	// PartnerRepeats counts every time two players partnered after the first time.
+	PartnerRepeats int `json:"partner_repeats"`
+	// OpponentRepeats counts every time two players faced each other after the first time.
+	OpponentRepeats int `json:"opponent_repeats"`
+	// AverageScoreGap is the average gap between the scores of the two sides of a match.
+	AverageScoreGap float64 `json:"average_score_gap"`
+	// CourtUtilisation is the share of the courts in all the rounds which had a match on them.
+	CourtUtilisation float64         `json:"court_utilisation"`
+	Players          []PlayerMetrics `json:"players"`
+}
+
+// pair is an unordered pair of player IDs, the lower ID first.
+type pair [2]int
+
+func newPair(a, b int) pair {
+	if a > b {
+		a, b = b, a
+	}
+	return pair{a, b}
+}
+
+// repeats returns how many times the pairs met after their first time, in total and per player.
+func repeats(counts map[pair]int) (int, map[int]int, map[int]int) {
+	total := 0
+	perPlayer := make(map[int]int)
+	distinct := make(map[int]int)
+	for p, times := range counts {
+		total += times - 1
+		for _, id := range p {
+			perPlayer[id] += times - 1
+			distinct[id]++
+		}
+	}
+	return total, perPlayer, distinct
+}
+
+// Compute computes the metrics of the given players, from the matches of each round on the given
+// number of courts. Players who never played are included, with no games.
+func Compute(playerIDs []int, rounds [][]Match, courts int) Metrics {
+	m := Metrics{Rounds: len(rounds)}
+
+	playedRounds := make(map[int][]int)
+	partners := make(map[pair]int)
+	opponents := make(map[pair]int)
+	var scoreGaps float64
+	for round, matches := range rounds {
+		for _, match := range matches {
+			m.Matches++
+			scoreGaps += math.Abs(match.Side1Score - match.Side2Score)
+			for _, side := range [][]int{match.Side1, match.Side2} {
+				for _, id := range side {
+					playedRounds[id] = append(playedRounds[id], round)
+				}
+				if len(side) == 2 {
+					partners[newPair(side[0], side[1])]++
+				}
+			}
+			for _, id1 := range match.Side1 {
+				for _, id2 := range match.Side2 {
+					opponents[newPair(id1, id2)]++
+				}
+			}
+		}
+	}
+	if m.Matches > 0 {
+		m.AverageScoreGap = scoreGaps / float64(m.Matches)
+	}
+	if courts > 0 && len(rounds) > 0 {
+		m.CourtUtilisation = float64(m.Matches) / float64(courts*len(rounds))
+	}
+
+	var partnerRepeats, opponentRepeats, distinctPartners, distinctOpponents map[int]int
+	m.PartnerRepeats, partnerRepeats, distinctPartners = repeats(partners)
+	m.OpponentRepeats, opponentRepeats, distinctOpponents = repeats(opponents)
+
+	ids := append([]int(nil), playerIDs...)
+	sort.Ints(ids)
+	var sum, sumSquares float64
+	for idx, id := range ids {
+		played := playedRounds[id]
+		player := PlayerMetrics{
+			ID:                id,
+			Games:             len(played),
+			DistinctPartners:  distinctPartners[id],
+			DistinctOpponents: distinctOpponents[id],
+			PartnerRepeats:    partnerRepeats[id],
+			OpponentRepeats:   opponentRepeats[id],
+		}
+		for i := 1; i < len(played); i++ {
+			if streak := played[i] - played[i-1] - 1; streak > player.MaxSitOutStreak {
+				player.MaxSitOutStreak = streak
+			}
+		}
+		m.Players = append(m.Players, player)
+
+		if idx == 0 || player.Games < m.GamesMin {
+			m.GamesMin = player.Games
+		}
+		if player.Games > m.GamesMax {
+			m.GamesMax = player.Games
+		}
+		if player.MaxSitOutStreak > m.MaxSitOutStreak {
+			m.MaxSitOutStreak = player.MaxSitOutStreak
+		}
+		sum += float64(player.Games)
+		sumSquares += float64(player.Games * player.Games)
+	}
+	if len(ids) > 0 {
+		m.GamesMean = sum / float64(len(ids))
+		m.GamesVariance = math.Max(0, sumSquares/float64(len(ids))-m.GamesMean*m.GamesMean)
+	}
+	return m
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCompute(t *testing.T) {
+	rounds := [][]Match{
+		{{Court: 1, Side1: []int{1, 2}, Side2: []int{3, 4}, Side1Score: 2, Side2Score: 1}},
+		{{Court: 1, Side1: []int{1, 3}, Side2: []int{2, 5}, Side1Score: 1, Side2Score: 1}},
+		{{Court: 1, Side1: []int{1, 2}, Side2: []int{4, 5}, Side1Score: 2, Side2Score: 1.5}},
+	}
+	m := Compute([]int{5, 4, 3, 2, 1, 6}, rounds, 2)
+
+	cases := []struct {
+		title    string
+		got      float64
+		expected float64
+	}{
+		{"Rounds", float64(m.Rounds), 3},
+		{"Matches", float64(m.Matches), 3},
+		{"GamesMean", m.GamesMean, 2},
+		{"GamesVariance", m.GamesVariance, 1},
+		{"GamesMin", float64(m.GamesMin), 0},
+		{"GamesMax", float64(m.GamesMax), 3},
+		{"MaxSitOutStreak", float64(m.MaxSitOutStreak), 1},
+		{"PartnerRepeats", float64(m.PartnerRepeats), 1},
+		{"OpponentRepeats", float64(m.OpponentRepeats), 4},
+		{"AverageScoreGap", m.AverageScoreGap, 0.5},
+		{"CourtUtilisation", m.CourtUtilisation, 0.5},
+	}
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			if math.Abs(tc.got-tc.expected) > 1e-9 {
+				t.Errorf("Unexpected %s, expected %v got %v", tc.title, tc.expected, tc.got)
+			}
+		})
+	}
+
+	expectedPlayers := []PlayerMetrics{
+		{ID: 1, Games: 3, DistinctPartners: 2, DistinctOpponents: 4, PartnerRepeats: 1, OpponentRepeats: 2},
+		{ID: 2, Games: 3, DistinctPartners: 2, DistinctOpponents: 4, PartnerRepeats: 1, OpponentRepeats: 2},
+		{ID: 3, Games: 2, DistinctPartners: 2, DistinctOpponents: 3, PartnerRepeats: 0, OpponentRepeats: 1},
+		{ID: 4, Games: 2, MaxSitOutStreak: 1, DistinctPartners: 2, DistinctOpponents: 2, PartnerRepeats: 0, OpponentRepeats: 2},
+		{ID: 5, Games: 2, DistinctPartners: 2, DistinctOpponents: 3, PartnerRepeats: 0, OpponentRepeats: 1},
+		{ID: 6},
+	}
+	if !reflect.DeepEqual(expectedPlayers, m.Players) {
+		t.Errorf("Unexpected player metrics, expected %+v got %+v", expectedPlayers, m.Players)
+	}
+}
+
+func TestComputeEmpty(t *testing.T) {
+	m := Compute(nil, nil, 3)
+	if !reflect.DeepEqual(Metrics{}, m) {
+		t.Errorf("Unexpected metrics, expected %+v got %+v", Metrics{}, m)
+	}
+}
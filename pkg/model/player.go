@@ -1,14 +1,56 @@
 package model
 
+// Player categories, used by formats such as mixed doubles.
+// Players with an unknown category can be arranged as either.
+const (
+	CategoryUnknown = ""
+	CategoryMale    = "M"
+	CategoryFemale  = "F"
+)
+
 // Player represents a player, with it's necessary information to be arranged.
 type Player struct {
-	Priority  float32
-	ID        int
-	Name      string
-	Score     float32
-	Matches   float32
+	Priority float32
+	ID       int
+	Name     string
+	Score    float32
+	Matches  float32
+	// Partners counts how many times the player has played on the same side as each other player.
+	Partners map[*Player]int
+	// Opponents counts how many times the player has played against each other player.
 	Opponents map[*Player]int
+	// RoundsWaited is the number of rounds the player has been sitting out since their last match,
+	// i.e. their current sit-out streak.
+	RoundsWaited int
+	// RoundsSatOut is the total number of rounds the player has sat out since their first match.
+	RoundsSatOut int
+	// Category is one of CategoryUnknown, CategoryMale and CategoryFemale.
+	Category string
+	// Team is the fixed partnership the player is in, or nil.
+	Team *Team
+}
+
+// TimesMet returns how many times the player has been in the same match as the other player,
+// either as partners or as opponents.
+func (p *Player) TimesMet(other *Player) int {
+	return p.Partners[other] + p.Opponents[other]
+}
+
+// Teammate returns the other player in the player's team, or nil if the player is not in a team.
+func (p *Player) Teammate() *Player {
+	if p.Team == nil {
+		return nil
+	}
+	if p.Team.Player1 == p {
+		return p.Team.Player2
+	}
+	return p.Team.Player1
 }
 
 // PlayerSlice is an alias for a slice of Player pointers.
 type PlayerSlice []*Player
+
+// ValidCategory returns whether the given string is a valid player category.
+func ValidCategory(category string) bool {
+	return category == CategoryUnknown || category == CategoryMale || category == CategoryFemale
+}
@@ -0,0 +1,21 @@
+package model
+
+// Team represents two players who registered as a fixed partnership. They are always
+// picked, rested and arranged on the same side together.
+type Team struct {
+	ID      int
+	Player1 *Player
+	Player2 *Player
+}
+
+// NewTeam creates a team of the two players and links both players to it.
+func NewTeam(id int, player1, player2 *Player) *Team {
+	team := &Team{
+		ID:      id,
+		Player1: player1,
+		Player2: player2,
+	}
+	player1.Team = team
+	player2.Team = team
+	return team
+}
@@ -0,0 +1,41 @@
+package rating
+
+func init() {
+	Register(DefaultSystemName, Classic{})
+}
+
+// Classic is the original scoring, where every win adds 1 to the score and every loss takes 1 off.
+type Classic struct{}
+
+// Initial implements System.
+func (Classic) Initial(score float64) Rating {
+	return Rating{Value: score}
+}
+
+// Update implements System.
+func (Classic) Update(ratings map[int]Rating, matches []Match) map[int]Rating {
+	ret := copyRatings(ratings)
+	for _, match := range matches {
+		// The points are ignored, a win is always worth 1.
+		change := -1.0
+		if match.Side1Won {
+			change = 1
+		}
+		for _, id := range match.Side1 {
+			rating := ret[id]
+			rating.Value += change
+			ret[id] = rating
+		}
+		for _, id := range match.Side2 {
+			rating := ret[id]
+			rating.Value -= change
+			ret[id] = rating
+		}
+	}
+	return ret
+}
+
+// Score implements System.
+func (Classic) Score(rating Rating) float64 {
+	return rating.Value
+}
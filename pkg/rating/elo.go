@@ -0,0 +1,69 @@
+package rating
+
+import (
+	"math"
+)
+
+func init() {
+	Register("elo", Elo{K: 32})
+}
+
+// PointsPerScore is how many rating points of Elo and Glicko-2 make one point of score.
+// A player starting with score s is rated 1500 + s*PointsPerScore.
+const PointsPerScore = 100
+
+// baseRating is the rating of a player starting with score 0.
+const baseRating = 1500
+
+// Elo is the Elo rating system adapted to doubles. The rating of a side is the average of its
+// players' ratings, and every player of a side gains or loses the same points as the side.
+type Elo struct {
+	// K is the most points a side can gain or lose in a match.
+	K float64
+}
+
+// Initial implements System.
+func (Elo) Initial(score float64) Rating {
+	return Rating{Value: baseRating + score*PointsPerScore}
+}
+
+// Update implements System.
+func (e Elo) Update(ratings map[int]Rating, matches []Match) map[int]Rating {
+	ret := copyRatings(ratings)
+	for _, match := range matches {
+		rating1 := averageRating(ret, match.Side1)
+		rating2 := averageRating(ret, match.Side2)
+		expected1 := 1 / (1 + math.Pow(10, (rating2-rating1)/400))
+		outcome1, _ := match.sideOutcomes()
+		change := e.K * (outcome1 - expected1)
+
+		for _, id := range match.Side1 {
+			rating := ret[id]
+			rating.Value += change
+			ret[id] = rating
+		}
+		for _, id := range match.Side2 {
+			rating := ret[id]
+			rating.Value -= change
+			ret[id] = rating
+		}
+	}
+	return ret
+}
+
+// Score implements System.
+func (Elo) Score(rating Rating) float64 {
+	return (rating.Value - baseRating) / PointsPerScore
+}
+
+// averageRating returns the average rating value of the players.
+func averageRating(ratings map[int]Rating, ids []int) float64 {
+	if len(ids) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, id := range ids {
+		sum += ratings[id].Value
+	}
+	return sum / float64(len(ids))
+}
@@ -0,0 +1,63 @@
+package rating
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEloUpdate(t *testing.T) {
+	cases := []struct {
+		title string
+		// scores are the initial scores of player 1 to 4, where 1 and 2 are on side 1.
+		scores   [4]float64
+		side1Won bool
+		points   [2]int
+		// expected is the rating change of side 1.
+		expected float64
+	}{
+		{"EvenWin", [4]float64{1, 1, 1, 1}, true, [2]int{}, 16},
+		{"EvenLoss", [4]float64{1, 1, 1, 1}, false, [2]int{}, -16},
+		{"UpsetWin", [4]float64{0, 0, 4, 4}, true, [2]int{}, 32 * (1 - 1/(1+math.Pow(10, 1)))},
+		{"ExpectedWin", [4]float64{4, 4, 0, 0}, true, [2]int{}, 32 * (1 - 1/(1+math.Pow(10, -1)))},
+		{"MixedSides", [4]float64{4, 0, 2, 2}, true, [2]int{}, 16},
+		{"NarrowWin", [4]float64{1, 1, 1, 1}, true, [2]int{42, 40}, 32 * ((1+42.0/82)/2 - 0.5)},
+		{"OneSidedWin", [4]float64{1, 1, 1, 1}, true, [2]int{42, 0}, 16},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			system := Elo{K: 32}
+			ratings := make(map[int]Rating)
+			for idx, score := range tc.scores {
+				ratings[idx+1] = system.Initial(score)
+			}
+			updated := system.Update(ratings, []Match{{Side1: []int{1, 2}, Side2: []int{3, 4}, Side1Won: tc.side1Won,
+				Side1Points: tc.points[0], Side2Points: tc.points[1]}})
+
+			for id := 1; id <= 4; id++ {
+				expected := tc.expected
+				if id > 2 {
+					expected = -expected
+				}
+				if change := updated[id].Value - ratings[id].Value; math.Abs(change-expected) > 1e-9 {
+					t.Errorf("Unexpected rating change of player %d, expected %v got %v", id, expected, change)
+				}
+			}
+		})
+	}
+}
+
+func TestEloUpdatePerMatch(t *testing.T) {
+	system := Elo{K: 32}
+	ratings := map[int]Rating{1: system.Initial(1), 2: system.Initial(1), 3: system.Initial(1)}
+	updated := system.Update(ratings, []Match{
+		{Side1: []int{1}, Side2: []int{2}, Side1Won: true},
+		{Side1: []int{1}, Side2: []int{3}, Side1Won: true},
+	})
+
+	// The second match is rated on player 1's rating after the first one, 16 points higher.
+	expected := 16 + 32*(1-1/(1+math.Pow(10, -16.0/400)))
+	if change := updated[1].Value - ratings[1].Value; math.Abs(change-expected) > 1e-9 {
+		t.Errorf("Unexpected rating change of player 1, expected %v got %v", expected, change)
+	}
+}
@@ -0,0 +1,167 @@
+package rating
+
+import (
+	"math"
+)
+
+func init() {
+	Register("glicko2", Glicko2{Tau: 0.5, InitialDeviation: 350, InitialVolatility: 0.06})
+}
+
+// glicko2Scale converts between the Glicko and the Glicko-2 scales.
+const glicko2Scale = 173.7178
+
+// glicko2Epsilon is the convergence tolerance of the volatility iteration.
+const glicko2Epsilon = 0.000001
+
+// Glicko2 is the Glicko-2 rating system by Mark Glickman, which tracks how certain a rating is
+// by its deviation. In doubles, a player is rated against the opposing side as if it was a
+// single player, whose rating is the average and whose deviation is the root mean square
+// of its players'.
+type Glicko2 struct {
+	// Tau constrains the change in volatility over time.
+	Tau float64
+	// InitialDeviation is the rating deviation of new players.
+	InitialDeviation float64
+	// InitialVolatility is the volatility of new players.
+	InitialVolatility float64
+}
+
+// Initial implements System.
+func (g Glicko2) Initial(score float64) Rating {
+	return Rating{
+		Value:      baseRating + score*PointsPerScore,
+		Deviation:  g.InitialDeviation,
+		Volatility: g.InitialVolatility,
+	}
+}
+
+// glicko2Game is a game of a player in a rating period, against an opponent on the Glicko-2 scale.
+type glicko2Game struct {
+	mu      float64
+	phi     float64
+	outcome float64
+}
+
+// Update implements System. Every match is a rating period of its own for the players in it,
+// while a player who plays none of the matches goes through one idle rating period.
+func (g Glicko2) Update(ratings map[int]Rating, matches []Match) map[int]Rating {
+	ret := copyRatings(ratings)
+	played := make(map[int]bool)
+	for _, match := range matches {
+		outcome1, outcome2 := match.sideOutcomes()
+		mu1, phi1 := compositeOpponent(ret, match.Side1)
+		mu2, phi2 := compositeOpponent(ret, match.Side2)
+		updated := make(map[int]Rating)
+		for _, id := range match.Side1 {
+			updated[id] = g.update(ret[id], []glicko2Game{{mu: mu2, phi: phi2, outcome: outcome1}})
+		}
+		for _, id := range match.Side2 {
+			updated[id] = g.update(ret[id], []glicko2Game{{mu: mu1, phi: phi1, outcome: outcome2}})
+		}
+		for id, rating := range updated {
+			ret[id] = rating
+			played[id] = true
+		}
+	}
+
+	for id, rating := range ret {
+		if !played[id] {
+			ret[id] = g.update(rating, nil)
+		}
+	}
+	return ret
+}
+
+// Score implements System.
+func (Glicko2) Score(rating Rating) float64 {
+	return (rating.Value - baseRating) / PointsPerScore
+}
+
+// compositeOpponent returns the rating and deviation, on the Glicko-2 scale, of a side seen as
+// a single player.
+func compositeOpponent(ratings map[int]Rating, ids []int) (float64, float64) {
+	if len(ids) == 0 {
+		return 0, 0
+	}
+	var mu, phiSquared float64
+	for _, id := range ids {
+		mu += (ratings[id].Value - baseRating) / glicko2Scale
+		phi := ratings[id].Deviation / glicko2Scale
+		phiSquared += phi * phi
+	}
+	return mu / float64(len(ids)), math.Sqrt(phiSquared / float64(len(ids)))
+}
+
+// glicko2G is the g function of Glicko-2, which weighs a game by the opponent's deviation.
+func glicko2G(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+// glicko2E is the expected outcome against an opponent.
+func glicko2E(mu, muj, phij float64) float64 {
+	return 1 / (1 + math.Exp(-glicko2G(phij)*(mu-muj)))
+}
+
+// update returns the rating of a player after the games of a rating period, following step 2
+// to 8 of the Glicko-2 paper. A player who did not play only has their deviation increased.
+func (g Glicko2) update(rating Rating, games []glicko2Game) Rating {
+	mu := (rating.Value - baseRating) / glicko2Scale
+	phi := rating.Deviation / glicko2Scale
+	sigma := rating.Volatility
+
+	if len(games) == 0 {
+		phi = math.Sqrt(phi*phi + sigma*sigma)
+		return Rating{Value: rating.Value, Deviation: phi * glicko2Scale, Volatility: sigma}
+	}
+
+	var vInverse, deltaSum float64
+	for _, game := range games {
+		gj := glicko2G(game.phi)
+		e := glicko2E(mu, game.mu, game.phi)
+		vInverse += gj * gj * e * (1 - e)
+		deltaSum += gj * (game.outcome - e)
+	}
+	v := 1 / vInverse
+	delta := v * deltaSum
+
+	// Find the new volatility with the Illinois algorithm.
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		return ex*(delta*delta-phi*phi-v-ex)/(2*math.Pow(phi*phi+v+ex, 2)) - (x-a)/(g.Tau*g.Tau)
+	}
+	lower := a
+	var upper float64
+	if delta*delta > phi*phi+v {
+		upper = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*g.Tau) < 0 {
+			k++
+		}
+		upper = a - k*g.Tau
+	}
+	fLower, fUpper := f(lower), f(upper)
+	for math.Abs(upper-lower) > glicko2Epsilon {
+		c := lower + (lower-upper)*fLower/(fUpper-fLower)
+		fc := f(c)
+		if fc*fUpper <= 0 {
+			lower, fLower = upper, fUpper
+		} else {
+			fLower /= 2
+		}
+		upper, fUpper = c, fc
+	}
+	newSigma := math.Exp(lower / 2)
+
+	phiStar := math.Sqrt(phi*phi + newSigma*newSigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*deltaSum
+
+	return Rating{
+		Value:      newMu*glicko2Scale + baseRating,
+		Deviation:  newPhi * glicko2Scale,
+		Volatility: newSigma,
+	}
+}
@@ -0,0 +1,89 @@
+package rating
+
+import (
+	"math"
+	"testing"
+)
+
+// TestGlicko2Update checks the example in Mark Glickman's "Example of the Glicko-2 system", where
+// the three games are played in one rating period.
+func TestGlicko2Update(t *testing.T) {
+	system := Glicko2{Tau: 0.5, InitialDeviation: 350, InitialVolatility: 0.06}
+	ratings := map[int]Rating{
+		1: {Value: 1500, Deviation: 200, Volatility: 0.06},
+		2: {Value: 1400, Deviation: 30, Volatility: 0.06},
+		3: {Value: 1550, Deviation: 100, Volatility: 0.06},
+		4: {Value: 1700, Deviation: 300, Volatility: 0.06},
+		5: {Value: 1500, Deviation: 50, Volatility: 0.06},
+	}
+	var games []glicko2Game
+	for _, game := range []struct {
+		opponent int
+		outcome  float64
+	}{{2, 1}, {3, 0}, {4, 0}} {
+		mu, phi := compositeOpponent(ratings, []int{game.opponent})
+		games = append(games, glicko2Game{mu: mu, phi: phi, outcome: game.outcome})
+	}
+	updated := system.update(ratings[1], games)
+	idle := system.Update(ratings, []Match{{Side1: []int{1}, Side2: []int{2}, Side1Won: true}})
+
+	cases := []struct {
+		title    string
+		got      float64
+		expected float64
+		delta    float64
+	}{
+		{"Rating", updated.Value, 1464.06, 0.01},
+		{"Deviation", updated.Deviation, 151.52, 0.01},
+		{"Volatility", updated.Volatility, 0.05999, 0.00001},
+		{"IdleRating", idle[5].Value, 1500, 0},
+		{"IdleDeviation", idle[5].Deviation, math.Sqrt(50*50 + math.Pow(0.06*glicko2Scale, 2)), 1e-9},
+	}
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			if math.Abs(tc.got-tc.expected) > tc.delta {
+				t.Errorf("Unexpected %s, expected %v got %v", tc.title, tc.expected, tc.got)
+			}
+		})
+	}
+}
+
+func TestGlicko2UpdatePerMatch(t *testing.T) {
+	system := Glicko2{Tau: 0.5, InitialDeviation: 350, InitialVolatility: 0.06}
+	ratings := map[int]Rating{1: system.Initial(1), 2: system.Initial(1), 3: system.Initial(2)}
+	updated := system.Update(ratings, []Match{
+		{Side1: []int{1}, Side2: []int{2}, Side1Won: true},
+		{Side1: []int{1}, Side2: []int{3}, Side1Won: true},
+	})
+
+	// Player 1 plays player 3 on the rating left by the first match.
+	mu2, phi2 := compositeOpponent(ratings, []int{2})
+	expected := system.update(ratings[1], []glicko2Game{{mu: mu2, phi: phi2, outcome: 1}})
+	mu3, phi3 := compositeOpponent(ratings, []int{3})
+	expected = system.update(expected, []glicko2Game{{mu: mu3, phi: phi3, outcome: 1}})
+	if math.Abs(updated[1].Value-expected.Value) > 1e-9 || math.Abs(updated[1].Deviation-expected.Deviation) > 1e-9 {
+		t.Errorf("Unexpected rating of player 1, expected %+v got %+v", expected, updated[1])
+	}
+}
+
+func TestGlicko2Doubles(t *testing.T) {
+	system := Glicko2{Tau: 0.5, InitialDeviation: 350, InitialVolatility: 0.06}
+	ratings := map[int]Rating{1: system.Initial(2), 2: system.Initial(2), 3: system.Initial(2), 4: system.Initial(2)}
+	updated := system.Update(ratings, []Match{{Side1: []int{1, 2}, Side2: []int{3, 4}, Side1Won: true}})
+
+	for id := 1; id <= 4; id++ {
+		won := id <= 2
+		if won && updated[id].Value <= ratings[id].Value {
+			t.Errorf("Expected player %d to gain rating but got %v", id, updated[id].Value)
+		}
+		if !won && updated[id].Value >= ratings[id].Value {
+			t.Errorf("Expected player %d to lose rating but got %v", id, updated[id].Value)
+		}
+		if updated[id].Deviation >= ratings[id].Deviation {
+			t.Errorf("Expected the deviation of player %d to shrink but got %v", id, updated[id].Deviation)
+		}
+	}
+	if system.Score(ratings[1]) != 2 {
+		t.Errorf("Unexpected score, expected 2 got %v", system.Score(ratings[1]))
+	}
+}
@@ -0,0 +1,119 @@
+// Package rating implements rating systems which update the ratings of players after every match.
+// Ratings are converted back to the scale of scores, so they can be used as
+// model.Player.Score for banding.
+package rating
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DefaultSystemName is the name of the System used when an event does not choose one.
+const DefaultSystemName = "classic"
+
+// Rating is the rating of a player. Systems which do not track the uncertainty of a rating
+// leave Deviation and Volatility as 0.
+type Rating struct {
+	Value      float64
+	Deviation  float64
+	Volatility float64
+}
+
+// Match is the result of a match, where the sides are given by player IDs.
+type Match struct {
+	Side1    []int
+	Side2    []int
+	Side1Won bool
+	// Side1Points and Side2Points are the points won by each side over all the games, or both 0
+	// if the game scores were not recorded.
+	Side1Points int
+	Side2Points int
+}
+
+// System updates the ratings of players with the results of their matches.
+type System interface {
+	// Initial returns the rating of a player who starts with the given score.
+	Initial(score float64) Rating
+	// Update returns the ratings after the matches, which are usually the ones of a round. The
+	// matches are rated one by one in the given order, each against the ratings left by the ones
+	// before, so a player who plays more than once, e.g. under winners stay on, is rated on
+	// their latest rating every time. Players in ratings who did not play are included in the
+	// returned ratings too.
+	Update(ratings map[int]Rating, matches []Match) map[int]Rating
+	// Score converts a rating back to the scale of scores.
+	Score(rating Rating) float64
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]System)
+)
+
+// Register makes a System available by the given name.
+// Registering the same name twice, or registering a nil System, panics.
+func Register(name string, system System) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if system == nil {
+		panic(fmt.Sprintf("rating: Register system %q is nil", name))
+	}
+	if _, dup := registry[name]; dup {
+		panic(fmt.Sprintf("rating: Register called twice for system %q", name))
+	}
+	registry[name] = system
+}
+
+// Lookup returns the System registered by the given name. An empty name stands for
+// DefaultSystemName.
+func Lookup(name string) (System, error) {
+	if name == "" {
+		name = DefaultSystemName
+	}
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	system, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown rating system %q", name)
+	}
+	return system, nil
+}
+
+// Names returns the sorted names of all the registered systems.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	var names []string
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// copyRatings returns a copy of the ratings.
+func copyRatings(ratings map[int]Rating) map[int]Rating {
+	ret := make(map[int]Rating, len(ratings))
+	for id, rating := range ratings {
+		ret[id] = rating
+	}
+	return ret
+}
+
+// sideOutcomes returns, for each side of the match, its outcome of 1 for a win and 0 for a loss.
+// When the points are known, the outcome is the average of that and the side's share of the
+// points, so a narrow win counts less than a one-sided one.
+func (m Match) sideOutcomes() (float64, float64) {
+	outcome1 := 0.0
+	if m.Side1Won {
+		outcome1 = 1
+	}
+	if total := m.Side1Points + m.Side2Points; total > 0 {
+		outcome1 = (outcome1 + float64(m.Side1Points)/float64(total)) / 2
+	}
+	return outcome1, 1 - outcome1
+}
@@ -0,0 +1,59 @@
+package rating
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	cases := []struct {
+		title       string
+		name        string
+		expected    System
+		expectedErr bool
+	}{
+		{"Default", "", Classic{}, false},
+		{"Classic", "classic", Classic{}, false},
+		{"Elo", "elo", Elo{K: 32}, false},
+		{"Unknown", "trueskill", nil, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			system, err := Lookup(tc.name)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("Expected error but got nil.")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			if !reflect.DeepEqual(system, tc.expected) {
+				t.Errorf("Unexpected system, expected %+v got %+v", tc.expected, system)
+			}
+		})
+	}
+}
+
+func TestNames(t *testing.T) {
+	expected := []string{"classic", "elo", "glicko2"}
+	if names := Names(); !reflect.DeepEqual(expected, names) {
+		t.Errorf("Unexpected names, expected %v got %v", expected, names)
+	}
+}
+
+func TestClassicUpdate(t *testing.T) {
+	system := Classic{}
+	ratings := map[int]Rating{1: system.Initial(3), 2: system.Initial(2), 3: system.Initial(1), 4: system.Initial(0), 5: system.Initial(5)}
+	ratings = system.Update(ratings, []Match{{Side1: []int{1, 2}, Side2: []int{3, 4}, Side1Won: false}})
+
+	expected := map[int]float64{1: 2, 2: 1, 3: 2, 4: 1, 5: 5}
+	for id, score := range expected {
+		if got := system.Score(ratings[id]); got != score {
+			t.Errorf("Unexpected score of player %d, expected %v got %v", id, score, got)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package rotation
+
+import (
+	"github.com/yushenli/badminton_match_table/pkg/arranger"
+	"github.com/yushenli/badminton_match_table/pkg/model"
+)
+
+// FreePlayers returns the players who are not busy, i.e. not in any match still being played,
+// keyed by their IDs.
+func FreePlayers(players model.PlayerSlice, busy map[int]bool) model.PlayerSlice {
+	var ret model.PlayerSlice
+	for _, player := range players {
+		if !busy[player.ID] {
+			ret = append(ret, player)
+		}
+	}
+	return ret
+}
+
+// ArrangeCourt arranges the next match on a single court with the given arranger, from the free
+// players of the input only, so the same fairness rules apply as when arranging a whole round.
+// Returns false if there are not enough free players for a match.
+func ArrangeCourt(a arranger.Arranger, input arranger.Input, busy map[int]bool) (model.Match, bool, error) {
+	input.Players = FreePlayers(input.Players, busy)
+	input.CourtCount = 1
+	if len(input.Players) < 2 {
+		return model.Match{}, false, nil
+	}
+	arrangement, err := a.Arrange(input)
+	if err != nil {
+		return model.Match{}, false, err
+	}
+	if len(arrangement) == 0 {
+		return model.Match{}, false, nil
+	}
+	return arrangement[0], true, nil
+}
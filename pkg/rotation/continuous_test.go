@@ -0,0 +1,97 @@
+package rotation
+
+import (
+	"testing"
+
+	"github.com/yushenli/badminton_match_table/pkg/arranger"
+	"github.com/yushenli/badminton_match_table/pkg/model"
+)
+
+func continuousPlayers(count int) model.PlayerSlice {
+	var players model.PlayerSlice
+	for i := 1; i <= count; i++ {
+		players = append(players, &model.Player{
+			ID:        i,
+			Score:     float32(count - i),
+			Partners:  make(map[*model.Player]int),
+			Opponents: make(map[*model.Player]int),
+		})
+	}
+	return players
+}
+
+func TestArrangeCourt(t *testing.T) {
+	cases := []struct {
+		title         string
+		players       int
+		busy          []int
+		expectedOk    bool
+		expectedCount int
+	}{
+		{"DoublesFromFreePlayers", 10, []int{1, 2, 3, 4, 5, 6}, true, 4},
+		{"SinglesWhenFewAreFree", 7, []int{1, 2, 3, 4}, true, 2},
+		{"NotEnoughFreePlayers", 5, []int{1, 2, 3, 4}, false, 0},
+		{"NobodyBusy", 4, nil, true, 4},
+	}
+
+	a, err := arranger.Lookup(arranger.DefaultArrangerName)
+	if err != nil {
+		t.Fatalf("Not expecting error but got %v.", err)
+	}
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			players := continuousPlayers(tc.players)
+			busy := make(map[int]bool)
+			for _, id := range tc.busy {
+				busy[id] = true
+			}
+			input := arranger.Input{AllPlayers: players, Players: players, CourtCount: 3}
+
+			match, ok, err := ArrangeCourt(a, input, busy)
+			if err != nil {
+				t.Fatalf("Not expecting error but got %v.", err)
+			}
+			if ok != tc.expectedOk {
+				t.Fatalf("Unexpected ok, expected %v got %v", tc.expectedOk, ok)
+			}
+			if !ok {
+				return
+			}
+
+			var ids []int
+			for _, side := range []model.Side{match.Side1, match.Side2} {
+				for _, player := range []*model.Player{side.Player1, side.Player2} {
+					if player != nil {
+						ids = append(ids, player.ID)
+					}
+				}
+			}
+			if len(ids) != tc.expectedCount {
+				t.Errorf("Unexpected number of players, expected %v got %v", tc.expectedCount, len(ids))
+			}
+			for _, id := range ids {
+				if busy[id] {
+					t.Errorf("Unexpected busy player %d in the match %v", id, ids)
+				}
+			}
+		})
+	}
+}
+
+func TestFreePlayers(t *testing.T) {
+	players := continuousPlayers(5)
+	free := FreePlayers(players, map[int]bool{2: true, 4: true})
+	var ids []int
+	for _, player := range free {
+		ids = append(ids, player.ID)
+	}
+	expected := []int{1, 3, 5}
+	if len(ids) != len(expected) {
+		t.Fatalf("Unexpected free players, expected %v got %v", expected, ids)
+	}
+	for idx := range expected {
+		if ids[idx] != expected[idx] {
+			t.Errorf("Unexpected free players, expected %v got %v", expected, ids)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Package rotation decides the next match on a court as soon as the previous one ends, for events
+// which do not play in lockstep rounds.
+package rotation
+
+import (
+	"fmt"
+)
+
+// Mode is how the matches of an event are scheduled.
+type Mode string
+
+// The modes of scheduling matches.
+const (
+	// Rounds schedules all the courts at once, round by round.
+	Rounds Mode = ""
+	// WinnersStayOn keeps the winning side on its court, up to a number of consecutive stays,
+	// against challengers from a waiting queue.
+	WinnersStayOn Mode = "winners_stay_on"
+	// Continuous arranges the next match on a court as soon as it reports a result, from the
+	// players who are not playing on the other courts.
+	Continuous Mode = "continuous"
+)
+
+// Modes are all the modes of scheduling matches.
+var Modes = []Mode{Rounds, WinnersStayOn, Continuous}
+
+// ParseMode returns the Mode of the given name.
+func ParseMode(name string) (Mode, error) {
+	for _, mode := range Modes {
+		if string(mode) == name {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("unknown scheduling mode %q, expecting one of %v", name, Modes)
+}
+
+// Match is a match on a court between two sides of one or two players each, identified by
+// their IDs.
+type Match struct {
+	Side1 []int
+	Side2 []int
+	// Winner is the side who won, 1 or 2, or 0 if the match has not been decided.
+	Winner int
+}
+
+// Take takes the sides of a match of the given side size from the front of the queue.
+// Returns false if the queue does not have enough players.
+func Take(queue []int, sideSize int) (Match, []int, bool) {
+	if len(queue) < 2*sideSize {
+		return Match{}, queue, false
+	}
+	match := Match{
+		Side1: append([]int{}, queue[:sideSize]...),
+		Side2: append([]int{}, queue[sideSize:2*sideSize]...),
+	}
+	return match, append([]int{}, queue[2*sideSize:]...), true
+}
@@ -0,0 +1,48 @@
+package rotation
+
+import (
+	"fmt"
+)
+
+// StayOn is the winners-stay-on rotation, also known as king of the court. The winning side of
+// a match keeps the court as side 1 of the next match, and the losers join the back of the
+// waiting queue, whose front players challenge the winners.
+type StayOn struct {
+	// MaxStays is the most matches in a row a side can win on a court before it has to leave
+	// the court as well, 0 for no limit.
+	MaxStays int
+}
+
+// Next returns the next match on a court after the given decided match, where stays is how many
+// matches in a row side 1 of the decided match had won on the court before it. The returned stays
+// are those of side 1 of the next match, and the returned queue is what is left waiting. When the
+// winners have to leave, the losers join the queue before them, and the next match is taken from
+// the front of the queue. As the players of the decided match join the queue, there are always
+// enough players for the next match, if only the same ones.
+func (s StayOn) Next(match Match, stays int, queue []int) (Match, int, []int, error) {
+	if match.Winner != 1 && match.Winner != 2 {
+		return Match{}, 0, queue, fmt.Errorf("the match has not been decided")
+	}
+	if len(match.Side1) == 0 || len(match.Side1) != len(match.Side2) {
+		return Match{}, 0, queue, fmt.Errorf("the sides of the match have %d and %d players",
+			len(match.Side1), len(match.Side2))
+	}
+	winners, losers := match.Side1, match.Side2
+	wins := stays + 1
+	if match.Winner == 2 {
+		winners, losers = match.Side2, match.Side1
+		wins = 1
+	}
+
+	queue = append(append([]int{}, queue...), losers...)
+	if s.MaxStays > 0 && wins >= s.MaxStays {
+		next, rest, _ := Take(append(queue, winners...), len(winners))
+		return next, 0, rest, nil
+	}
+
+	next := Match{
+		Side1: append([]int{}, winners...),
+		Side2: append([]int{}, queue[:len(winners)]...),
+	}
+	return next, wins, queue[len(winners):], nil
+}
@@ -0,0 +1,133 @@
+package rotation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStayOnNext(t *testing.T) {
+	cases := []struct {
+		title         string
+		maxStays      int
+		match         Match
+		stays         int
+		queue         []int
+		expected      Match
+		expectedStays int
+		expectedQueue []int
+	}{
+		{
+			"HoldersStay",
+			3,
+			Match{Side1: []int{1, 2}, Side2: []int{3, 4}, Winner: 1},
+			1,
+			[]int{5, 6, 7},
+			Match{Side1: []int{1, 2}, Side2: []int{5, 6}},
+			2,
+			[]int{7, 3, 4},
+		},
+		{
+			"ChallengersTakeOver",
+			3,
+			Match{Side1: []int{1, 2}, Side2: []int{3, 4}, Winner: 2},
+			2,
+			[]int{5, 6},
+			Match{Side1: []int{3, 4}, Side2: []int{5, 6}},
+			1,
+			[]int{1, 2},
+		},
+		{
+			"MaxStaysReached",
+			3,
+			Match{Side1: []int{1}, Side2: []int{2}, Winner: 1},
+			2,
+			[]int{3, 4, 5},
+			Match{Side1: []int{3}, Side2: []int{4}},
+			0,
+			[]int{5, 2, 1},
+		},
+		{
+			"NoLimit",
+			0,
+			Match{Side1: []int{1}, Side2: []int{2}, Winner: 1},
+			10,
+			[]int{3},
+			Match{Side1: []int{1}, Side2: []int{3}},
+			11,
+			[]int{2},
+		},
+		{
+			"LosersComeBackWhenNobodyWaits",
+			0,
+			Match{Side1: []int{1}, Side2: []int{2}, Winner: 1},
+			0,
+			nil,
+			Match{Side1: []int{1}, Side2: []int{2}},
+			1,
+			[]int{},
+		},
+		{
+			"EveryoneLeavesAfterOneWin",
+			1,
+			Match{Side1: []int{1, 2}, Side2: []int{3, 4}, Winner: 2},
+			0,
+			[]int{5},
+			Match{Side1: []int{5, 1}, Side2: []int{2, 3}},
+			0,
+			[]int{4},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			next, stays, queue, err := StayOn{MaxStays: tc.maxStays}.Next(tc.match, tc.stays, tc.queue)
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			if !reflect.DeepEqual(tc.expected, next) {
+				t.Errorf("Unexpected next match, expected %+v got %+v", tc.expected, next)
+			}
+			if stays != tc.expectedStays {
+				t.Errorf("Unexpected stays, expected %d got %d", tc.expectedStays, stays)
+			}
+			if len(queue) != len(tc.expectedQueue) || (len(queue) > 0 && !reflect.DeepEqual(tc.expectedQueue, queue)) {
+				t.Errorf("Unexpected queue, expected %v got %v", tc.expectedQueue, queue)
+			}
+		})
+	}
+
+	if _, _, _, err := (StayOn{}).Next(Match{Side1: []int{1}, Side2: []int{2}}, 0, nil); err == nil {
+		t.Errorf("Expected error but got nil.")
+	}
+	if _, _, _, err := (StayOn{}).Next(Match{Side1: []int{1, 2}, Side2: []int{3}, Winner: 1}, 0, nil); err == nil {
+		t.Errorf("Expected error but got nil.")
+	}
+}
+
+func TestTake(t *testing.T) {
+	match, rest, ok := Take([]int{1, 2, 3, 4, 5}, 2)
+	if !ok {
+		t.Errorf("Unexpected ok, expected true got false")
+	}
+	if expected := (Match{Side1: []int{1, 2}, Side2: []int{3, 4}}); !reflect.DeepEqual(expected, match) {
+		t.Errorf("Unexpected match, expected %+v got %+v", expected, match)
+	}
+	if expected := []int{5}; !reflect.DeepEqual(expected, rest) {
+		t.Errorf("Unexpected rest, expected %v got %v", expected, rest)
+	}
+	if _, _, ok := Take([]int{1, 2, 3}, 2); ok {
+		t.Errorf("Unexpected ok, expected false got true")
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for _, mode := range Modes {
+		if parsed, err := ParseMode(string(mode)); err != nil || parsed != mode {
+			t.Errorf("Unexpected mode, expected %q got %q (%v)", mode, parsed, err)
+		}
+	}
+	if _, err := ParseMode("lockstep"); err == nil {
+		t.Errorf("Expected error but got nil.")
+	}
+}
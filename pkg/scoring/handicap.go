@@ -0,0 +1,86 @@
+package scoring
+
+import (
+	"fmt"
+	"math"
+)
+
+// HandicapRule decides how many points the weaker side of a match starts with, from the gap
+// between the average scores of the two sides.
+type HandicapRule struct {
+	// MinGap is the smallest score gap given a handicap. Closer matches start from 0-0.
+	MinGap float64
+	// PointsPerScore is the points given for every point of score gap.
+	PointsPerScore float64
+	// MaxPoints caps the handicap, so the stronger side still has to win the game.
+	MaxPoints int
+}
+
+// DefaultHandicapRule gives 2 points for every point of score gap from a gap of 1.5, up to half
+// of the points needed to win a game.
+var DefaultHandicapRule = HandicapRule{
+	MinGap:         1.5,
+	PointsPerScore: 2,
+	MaxPoints:      PointsToWin / 2,
+}
+
+// Handicap returns the side, 1 or 2, which starts with a handicap and its starting points, given
+// the average scores of the two sides. It returns 0 and 0 when no handicap is needed.
+func (r HandicapRule) Handicap(side1Score, side2Score float64) (int, int) {
+	gap := math.Abs(side1Score - side2Score)
+	if gap < r.MinGap {
+		return 0, 0
+	}
+	points := int(math.Round(gap * r.PointsPerScore))
+	if points > r.MaxPoints {
+		points = r.MaxPoints
+	}
+	if points <= 0 {
+		return 0, 0
+	}
+	if side1Score < side2Score {
+		return 1, points
+	}
+	return 2, points
+}
+
+// ValidateHandicap checks every game gives the handicapped side, 1 or 2, at least the points it
+// started the game with. Side 0 means no handicap.
+func (gs Games) ValidateHandicap(side, points int) error {
+	for _, game := range gs {
+		scored := game.Side1
+		if side == 2 {
+			scored = game.Side2
+		}
+		if side != 0 && scored < points {
+			return fmt.Errorf("game %s gives side %d fewer points than the %d it started with", game, side, points)
+		}
+	}
+	return nil
+}
+
+// PointsWithoutHandicap returns the total points won by each side over all the games, without
+// the points the handicapped side, 1 or 2, started every game with. A game is never counted
+// below 0 points for the side, and side 0 means no handicap.
+func (gs Games) PointsWithoutHandicap(side, points int) (int, int) {
+	side1, side2 := gs.Points()
+	if side == 0 || points <= 0 {
+		return side1, side2
+	}
+	for _, game := range gs {
+		scored := game.Side1
+		if side == 2 {
+			scored = game.Side2
+		}
+		head := points
+		if head > scored {
+			head = scored
+		}
+		if side == 1 {
+			side1 -= head
+		} else {
+			side2 -= head
+		}
+	}
+	return side1, side2
+}
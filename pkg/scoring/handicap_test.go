@@ -0,0 +1,85 @@
+package scoring
+
+import (
+	"testing"
+)
+
+func TestHandicap(t *testing.T) {
+	cases := []struct {
+		title          string
+		side1Score     float64
+		side2Score     float64
+		expectedSide   int
+		expectedPoints int
+	}{
+		{"Even", 2, 2, 0, 0},
+		{"BelowMinGap", 2, 3, 0, 0},
+		{"Side1Weaker", 1, 3, 1, 4},
+		{"Side2Weaker", 3.5, 0, 2, 7},
+		{"Rounded", 0, 1.8, 1, 4},
+		{"Capped", -4, 6, 1, 10},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			side, points := DefaultHandicapRule.Handicap(tc.side1Score, tc.side2Score)
+			if side != tc.expectedSide || points != tc.expectedPoints {
+				t.Errorf("Unexpected handicap, expected %d points to side %d got %d points to side %d",
+					tc.expectedPoints, tc.expectedSide, points, side)
+			}
+		})
+	}
+}
+
+func TestGamesPointsWithoutHandicap(t *testing.T) {
+	games := Games{{Side1: 21, Side2: 17}, {Side1: 3, Side2: 21}, {Side1: 21, Side2: 19}}
+	cases := []struct {
+		title         string
+		side          int
+		points        int
+		expectedSide1 int
+		expectedSide2 int
+	}{
+		{"NoHandicap", 0, 0, 45, 57},
+		{"Side1", 1, 3, 36, 57},
+		{"Side2", 2, 6, 45, 39},
+		{"MorePointsThanScored", 1, 5, 32, 57},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			side1, side2 := games.PointsWithoutHandicap(tc.side, tc.points)
+			if side1 != tc.expectedSide1 || side2 != tc.expectedSide2 {
+				t.Errorf("Unexpected points, expected %d-%d got %d-%d", tc.expectedSide1, tc.expectedSide2, side1, side2)
+			}
+		})
+	}
+}
+
+func TestGamesValidateHandicap(t *testing.T) {
+	games := Games{{Side1: 21, Side2: 17}, {Side1: 3, Side2: 21}}
+	cases := []struct {
+		title       string
+		side        int
+		points      int
+		expectedErr bool
+	}{
+		{"NoHandicap", 0, 0, false},
+		{"Side1", 1, 3, false},
+		{"Side1BelowStart", 1, 4, true},
+		{"Side2", 2, 17, false},
+		{"Side2BelowStart", 2, 18, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			err := games.ValidateHandicap(tc.side, tc.points)
+			if tc.expectedErr && err == nil {
+				t.Errorf("Expected error but got nil.")
+			}
+			if !tc.expectedErr && err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+			}
+		})
+	}
+}
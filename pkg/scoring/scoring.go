@@ -0,0 +1,169 @@
+// Package scoring validates and sums up badminton game scores under the rally point system:
+// a game is won by the first side reaching 21 points with a lead of 2, or by the side reaching
+// 30 points first. A match is a single game or the best of three games.
+package scoring
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// PointsToWin is the points a side needs to win a game, given it leads by MinLead.
+	PointsToWin = 21
+	// MinLead is the least lead a side needs to win a game before PointsCap.
+	MinLead = 2
+	// PointsCap is the points at which a game ends regardless of the lead.
+	PointsCap = 30
+	// MaxGames is the most games in a match, which is the best of three.
+	MaxGames = 3
+)
+
+// Game is the final score of a game.
+type Game struct {
+	Side1 int
+	Side2 int
+}
+
+// ParseGame parses a game score in the form of "21-17".
+func ParseGame(spec string) (Game, error) {
+	parts := strings.Split(strings.TrimSpace(spec), "-")
+	if len(parts) != 2 {
+		return Game{}, fmt.Errorf("invalid game score %q, points1-points2 is expected", spec)
+	}
+	side1, err1 := strconv.Atoi(strings.TrimSpace(parts[0]))
+	side2, err2 := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err1 != nil || err2 != nil {
+		return Game{}, fmt.Errorf("invalid game score %q, points must be integers", spec)
+	}
+	return Game{Side1: side1, Side2: side2}, nil
+}
+
+// Validate checks the game is a completed game under the rally point system.
+func (g Game) Validate() error {
+	if g.Side1 < 0 || g.Side2 < 0 {
+		return fmt.Errorf("game %s has negative points", g)
+	}
+	winner, loser := g.Side1, g.Side2
+	if loser > winner {
+		winner, loser = loser, winner
+	}
+	switch {
+	case winner < PointsToWin:
+		return fmt.Errorf("game %s is not completed, a side needs %d points to win", g, PointsToWin)
+	case winner > PointsCap:
+		return fmt.Errorf("game %s exceeds the cap of %d points", g, PointsCap)
+	case winner == PointsToWin && loser <= PointsToWin-MinLead:
+		return nil
+	case winner == PointsCap && loser == PointsCap-1:
+		return nil
+	case winner > PointsToWin && loser == winner-MinLead:
+		return nil
+	case winner-loser < MinLead:
+		return fmt.Errorf("game %s is not completed, a side needs a lead of %d to win", g, MinLead)
+	default:
+		end := loser + MinLead
+		if end < PointsToWin {
+			end = PointsToWin
+		}
+		return fmt.Errorf("game %s should have ended at %d points", g, end)
+	}
+}
+
+// Winner returns 1 or 2 for the side who won the game, or 0 for a tie.
+func (g Game) Winner() int {
+	if g.Side1 > g.Side2 {
+		return 1
+	}
+	if g.Side2 > g.Side1 {
+		return 2
+	}
+	return 0
+}
+
+// String formats the game in the form accepted by ParseGame.
+func (g Game) String() string {
+	return fmt.Sprintf("%d-%d", g.Side1, g.Side2)
+}
+
+// Games are the games of a match in the order they were played.
+type Games []Game
+
+// ParseGames parses the games of a match separated by commas or spaces, e.g. "21-17, 19-21, 21-15".
+func ParseGames(spec string) (Games, error) {
+	var games Games
+	for _, field := range strings.FieldsFunc(spec, func(r rune) bool { return r == ',' || r == ' ' }) {
+		game, err := ParseGame(field)
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, game)
+	}
+	return games, nil
+}
+
+// Validate checks every game is valid, and that the games make a completed match which is either
+// a single game or the best of three games, without any game played after the match was decided.
+func (gs Games) Validate() error {
+	if len(gs) == 0 {
+		return fmt.Errorf("no games are given")
+	}
+	if len(gs) > MaxGames {
+		return fmt.Errorf("%d games are given, at most %d games are expected", len(gs), MaxGames)
+	}
+	for _, game := range gs {
+		if err := game.Validate(); err != nil {
+			return err
+		}
+	}
+	if len(gs) == 1 {
+		return nil
+	}
+
+	wins := make([]int, 3)
+	for idx, game := range gs {
+		if wins[1] == 2 || wins[2] == 2 {
+			return fmt.Errorf("game %d (%s) is played after the match was decided", idx+1, game)
+		}
+		wins[game.Winner()]++
+	}
+	if wins[1] < 2 && wins[2] < 2 {
+		return fmt.Errorf("the match %s is not decided, a side needs to win 2 games", gs)
+	}
+	return nil
+}
+
+// Winner returns 1 or 2 for the side who won more games, or 0 if neither did.
+func (gs Games) Winner() int {
+	wins := make([]int, 3)
+	for _, game := range gs {
+		wins[game.Winner()]++
+	}
+	if wins[1] > wins[2] {
+		return 1
+	}
+	if wins[2] > wins[1] {
+		return 2
+	}
+	return 0
+}
+
+// Points returns the total points won by each side over all the games.
+func (gs Games) Points() (int, int) {
+	var side1, side2 int
+	for _, game := range gs {
+		side1 += game.Side1
+		side2 += game.Side2
+	}
+	return side1, side2
+}
+
+// String formats the games in the form accepted by ParseGames.
+func (gs Games) String() string {
+	entries := make([]string, len(gs))
+	for idx, game := range gs {
+		entries[idx] = game.String()
+	}
+	return strings.Join(entries, ", ")
+}
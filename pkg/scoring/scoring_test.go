@@ -0,0 +1,124 @@
+package scoring
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGameValidate(t *testing.T) {
+	cases := []struct {
+		title       string
+		game        Game
+		expectedErr bool
+	}{
+		{"Straight", Game{21, 17}, false},
+		{"Nil", Game{0, 21}, false},
+		{"LeadOfTwo", Game{21, 19}, false},
+		{"Deuce", Game{20, 22}, false},
+		{"LongDeuce", Game{29, 27}, false},
+		{"Cap", Game{30, 29}, false},
+		{"CapLeadOfTwo", Game{28, 30}, false},
+		{"NotReached", Game{20, 18}, true},
+		{"LeadOfOne", Game{21, 20}, true},
+		{"Tie", Game{21, 21}, true},
+		{"PlayedOn", Game{25, 10}, true},
+		{"PlayedOnAfterDeuce", Game{24, 21}, true},
+		{"OverCap", Game{31, 29}, true},
+		{"Negative", Game{21, -1}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			err := tc.game.Validate()
+			if tc.expectedErr && err == nil {
+				t.Errorf("Expected error but got nil.")
+			}
+			if !tc.expectedErr && err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+			}
+		})
+	}
+}
+
+func TestParseGames(t *testing.T) {
+	cases := []struct {
+		title       string
+		spec        string
+		expected    Games
+		expectedErr bool
+	}{
+		{"Single", "21-17", Games{{21, 17}}, false},
+		{"BestOfThree", "21-17, 19-21,21-15", Games{{21, 17}, {19, 21}, {21, 15}}, false},
+		{"Spaces", "21-17 22-20", Games{{21, 17}, {22, 20}}, false},
+		{"Empty", "", nil, false},
+		{"MissingPoints", "21-", nil, true},
+		{"NotNumber", "21-x", nil, true},
+		{"NoSeparator", "2117", nil, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			games, err := ParseGames(tc.spec)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("Expected error but got nil.")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			if !reflect.DeepEqual(tc.expected, games) {
+				t.Errorf("Unexpected games, expected %v got %v", tc.expected, games)
+			}
+		})
+	}
+}
+
+func TestGamesValidate(t *testing.T) {
+	cases := []struct {
+		title          string
+		games          Games
+		expectedErr    bool
+		expectedWinner int
+	}{
+		{"Single", Games{{17, 21}}, false, 2},
+		{"StraightGames", Games{{21, 17}, {22, 20}}, false, 1},
+		{"ThreeGames", Games{{21, 17}, {19, 21}, {15, 21}}, false, 2},
+		{"None", Games{}, true, 0},
+		{"Undecided", Games{{21, 17}, {19, 21}}, true, 0},
+		{"PlayedAfterDecided", Games{{21, 17}, {21, 19}, {15, 21}}, true, 1},
+		{"TooMany", Games{{21, 17}, {19, 21}, {15, 21}, {21, 0}}, true, 0},
+		{"InvalidGame", Games{{21, 17}, {21, 20}}, true, 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			err := tc.games.Validate()
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("Expected error but got nil.")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+			}
+			if winner := tc.games.Winner(); winner != tc.expectedWinner {
+				t.Errorf("Unexpected winner, expected %d got %d", tc.expectedWinner, winner)
+			}
+		})
+	}
+}
+
+func TestGamesPoints(t *testing.T) {
+	games := Games{{21, 17}, {19, 21}, {21, 15}}
+	side1, side2 := games.Points()
+	if side1 != 61 || side2 != 53 {
+		t.Errorf("Unexpected points, expected 61-53 got %d-%d", side1, side2)
+	}
+	if games.String() != "21-17, 19-21, 21-15" {
+		t.Errorf("Unexpected string, expected %q got %q", "21-17, 19-21, 21-15", games.String())
+	}
+}
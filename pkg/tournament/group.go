@@ -0,0 +1,346 @@
+package tournament
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Fixture is a match of a round-robin group.
+type Fixture struct {
+	ID    int `json:"id"`
+	Group int `json:"group"`
+	// Round is the 1-based round of the group the fixture belongs to under the circle method.
+	Round int `json:"round"`
+	// Slot is the 0-based time slot the fixture is scheduled in, see GroupStage.schedule.
+	Slot     int `json:"slot"`
+	Entrant1 int `json:"entrant1"`
+	Entrant2 int `json:"entrant2"`
+	// Winner is the side who won, 1 or 2, or 0 if the fixture has not been played.
+	Winner  int `json:"winner"`
+	Points1 int `json:"points1"`
+	Points2 int `json:"points2"`
+	// Ref is the ID the fixture is played under, set by the caller when the fixture is scheduled.
+	Ref int `json:"ref,omitempty"`
+}
+
+// GroupStage is a group stage where the entrants, seeded 1 to Entrants, are split into balanced
+// groups which each play a full round robin. The top Advance entrants of every group qualify
+// for a knockout.
+type GroupStage struct {
+	Entrants int `json:"entrants"`
+	// Groups are the seeds of the entrants of every group.
+	Groups   [][]int    `json:"groups"`
+	Advance  int        `json:"advance"`
+	Fixtures []*Fixture `json:"fixtures"`
+}
+
+// Standing is the record of an entrant in a group.
+type Standing struct {
+	Entrant       int
+	Played        int
+	Won           int
+	Lost          int
+	PointsFor     int
+	PointsAgainst int
+}
+
+// PointDifference returns the points won minus the points lost by the entrant.
+func (s Standing) PointDifference() int {
+	return s.PointsFor - s.PointsAgainst
+}
+
+// NewGroupStage splits the given number of entrants into groups and schedules their round robins
+// on the given number of courts. The groups are balanced by snake seeding, e.g. seeds 1, 4, 5
+// and 2, 3, 6 for 6 entrants in 2 groups.
+func NewGroupStage(entrants, groups, advance, courts int) (*GroupStage, error) {
+	if groups < 1 || entrants < 2*groups {
+		return nil, fmt.Errorf("%d entrants cannot be split into %d groups of at least 2", entrants, groups)
+	}
+	if advance < 1 || advance > entrants/groups {
+		return nil, fmt.Errorf("invalid number of entrants advancing from every group %d, expecting 1 to %d",
+			advance, entrants/groups)
+	}
+	if courts < 1 {
+		return nil, fmt.Errorf("a group stage needs at least 1 court, %d given", courts)
+	}
+
+	g := &GroupStage{Entrants: entrants, Groups: make([][]int, groups), Advance: advance}
+	for i := 0; i < entrants; i++ {
+		group := i % groups
+		if (i/groups)%2 == 1 {
+			group = groups - 1 - group
+		}
+		g.Groups[group] = append(g.Groups[group], i+1)
+	}
+
+	// The fixtures are listed round by round, interleaving the groups, so that the groups progress
+	// at the same pace.
+	var rounds [][][][2]int
+	maxRounds := 0
+	for _, group := range g.Groups {
+		groupRounds := CircleRounds(group)
+		rounds = append(rounds, groupRounds)
+		if len(groupRounds) > maxRounds {
+			maxRounds = len(groupRounds)
+		}
+	}
+	for r := 0; r < maxRounds; r++ {
+		for group := range g.Groups {
+			if r >= len(rounds[group]) {
+				continue
+			}
+			for _, pair := range rounds[group][r] {
+				g.Fixtures = append(g.Fixtures, &Fixture{
+					ID:       len(g.Fixtures),
+					Group:    group,
+					Round:    r + 1,
+					Entrant1: pair[0],
+					Entrant2: pair[1],
+				})
+			}
+		}
+	}
+	g.schedule(courts)
+	return g, nil
+}
+
+// CircleRounds returns the rounds of a round robin between the given entrants by the circle
+// method: the first entrant stays in place while the others rotate around it. With an odd
+// number of entrants, one sits out every round.
+func CircleRounds(entrants []int) [][][2]int {
+	circle := append([]int{}, entrants...)
+	if len(circle)%2 == 1 {
+		circle = append(circle, Bye)
+	}
+	n := len(circle)
+
+	var rounds [][][2]int
+	for r := 0; r < n-1; r++ {
+		var pairs [][2]int
+		for i := 0; i < n/2; i++ {
+			a, b := circle[i], circle[n-1-i]
+			if a == Bye || b == Bye {
+				continue
+			}
+			// Alternate the sides of the fixed entrant, which would otherwise always be side 1.
+			if i == 0 && r%2 == 1 {
+				a, b = b, a
+			}
+			pairs = append(pairs, [2]int{a, b})
+		}
+		rounds = append(rounds, pairs)
+		// Rotate every entrant but the first one step clockwise.
+		last := circle[n-1]
+		copy(circle[2:], circle[1:n-1])
+		circle[1] = last
+	}
+	return rounds
+}
+
+// schedule assigns the fixtures to time slots of at most courts fixtures each, in the order they
+// are listed. An entrant who has played in a slot rests in the next one, unless no fixture could
+// be played otherwise.
+func (g *GroupStage) schedule(courts int) {
+	lastSlot := make(map[int]int)
+	rested := func(entrant, slot int) bool {
+		last, ok := lastSlot[entrant]
+		return !ok || last < slot-1
+	}
+
+	pending := append([]*Fixture{}, g.Fixtures...)
+	for slot := 0; len(pending) > 0; slot++ {
+		playing := make(map[int]bool)
+		taken := make(map[int]bool)
+		for _, needRest := range []bool{true, false} {
+			if !needRest && len(taken) > 0 {
+				break
+			}
+			for idx, fixture := range pending {
+				if len(taken) == courts {
+					break
+				}
+				if taken[idx] || playing[fixture.Entrant1] || playing[fixture.Entrant2] {
+					continue
+				}
+				if needRest && !(rested(fixture.Entrant1, slot) && rested(fixture.Entrant2, slot)) {
+					continue
+				}
+				fixture.Slot = slot
+				playing[fixture.Entrant1], playing[fixture.Entrant2] = true, true
+				taken[idx] = true
+			}
+		}
+
+		var left []*Fixture
+		for idx, fixture := range pending {
+			if taken[idx] {
+				lastSlot[fixture.Entrant1], lastSlot[fixture.Entrant2] = slot, slot
+			} else {
+				left = append(left, fixture)
+			}
+		}
+		pending = left
+	}
+}
+
+// Report records that the given side, 1 or 2, won the fixture of the given ID with the given points.
+func (g *GroupStage) Report(id, winner, points1, points2 int) error {
+	if id < 0 || id >= len(g.Fixtures) {
+		return fmt.Errorf("unknown fixture %d", id)
+	}
+	if winner != 1 && winner != 2 {
+		return fmt.Errorf("invalid winner %d of fixture %d, 1 or 2 is expected", winner, id)
+	}
+	fixture := g.Fixtures[id]
+	fixture.Winner, fixture.Points1, fixture.Points2 = winner, points1, points2
+	return nil
+}
+
+// Done returns whether every fixture has been played.
+func (g *GroupStage) Done() bool {
+	for _, fixture := range g.Fixtures {
+		if fixture.Winner == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Pending returns the fixtures yet to be played and not scheduled yet, in the order of their slots.
+func (g *GroupStage) Pending() []*Fixture {
+	var ret []*Fixture
+	for _, fixture := range g.Fixtures {
+		if fixture.Winner == 0 && fixture.Ref == 0 {
+			ret = append(ret, fixture)
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Slot < ret[j].Slot
+	})
+	return ret
+}
+
+// Ready returns the pending fixtures of the earliest slot which has any, in the order they are
+// listed, so the fixtures are played slot by slot as scheduled. A fixture is left out while any of
+// its entrants is still playing a fixture of an earlier slot, or has just played one, i.e. its Ref
+// is in recent.
+func (g *GroupStage) Ready(recent map[int]bool) []*Fixture {
+	pending := g.Pending()
+	if len(pending) == 0 {
+		return nil
+	}
+	slot := pending[0].Slot
+
+	busy := make(map[int]bool)
+	for _, fixture := range g.Fixtures {
+		if fixture.Slot >= slot || fixture.Ref == 0 {
+			continue
+		}
+		if fixture.Winner == 0 || recent[fixture.Ref] {
+			busy[fixture.Entrant1], busy[fixture.Entrant2] = true, true
+		}
+	}
+
+	var ret []*Fixture
+	for _, fixture := range pending {
+		if fixture.Slot != slot {
+			break
+		}
+		if busy[fixture.Entrant1] || busy[fixture.Entrant2] {
+			continue
+		}
+		ret = append(ret, fixture)
+	}
+	return ret
+}
+
+// Standings returns the standings of a group, ranked by matches won, then by matches won against
+// the other entrants with as many wins (head-to-head), then by point difference, then by points
+// won, and finally by seed.
+func (g *GroupStage) Standings(group int) []Standing {
+	standingMap := make(map[int]*Standing)
+	var standings []*Standing
+	for _, entrant := range g.Groups[group] {
+		standing := &Standing{Entrant: entrant}
+		standingMap[entrant] = standing
+		standings = append(standings, standing)
+	}
+
+	var played []*Fixture
+	for _, fixture := range g.Fixtures {
+		if fixture.Group != group || fixture.Winner == 0 {
+			continue
+		}
+		played = append(played, fixture)
+		standing1, standing2 := standingMap[fixture.Entrant1], standingMap[fixture.Entrant2]
+		standing1.Played++
+		standing2.Played++
+		standing1.PointsFor += fixture.Points1
+		standing1.PointsAgainst += fixture.Points2
+		standing2.PointsFor += fixture.Points2
+		standing2.PointsAgainst += fixture.Points1
+		if fixture.Winner == 1 {
+			standing1.Won++
+			standing2.Lost++
+		} else {
+			standing2.Won++
+			standing1.Lost++
+		}
+	}
+
+	headToHead := make(map[int]int)
+	for _, fixture := range played {
+		winner, loser := fixture.Entrant1, fixture.Entrant2
+		if fixture.Winner == 2 {
+			winner, loser = loser, winner
+		}
+		if standingMap[winner].Won == standingMap[loser].Won {
+			headToHead[winner]++
+		}
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		if a.Won != b.Won {
+			return a.Won > b.Won
+		}
+		if headToHead[a.Entrant] != headToHead[b.Entrant] {
+			return headToHead[a.Entrant] > headToHead[b.Entrant]
+		}
+		if a.PointDifference() != b.PointDifference() {
+			return a.PointDifference() > b.PointDifference()
+		}
+		if a.PointsFor != b.PointsFor {
+			return a.PointsFor > b.PointsFor
+		}
+		return a.Entrant < b.Entrant
+	})
+
+	ret := make([]Standing, len(standings))
+	for idx, standing := range standings {
+		ret[idx] = *standing
+	}
+	return ret
+}
+
+// Qualifiers returns the entrants advancing to the knockout in the order of their knockout seeds:
+// the group winners first, then the runners-up and so on, each rank in the order of the groups.
+// With SeedOrder this keeps the entrants of a group apart in the first knockout round where possible.
+func (g *GroupStage) Qualifiers() ([]int, error) {
+	if !g.Done() {
+		return nil, fmt.Errorf("the group stage is not over")
+	}
+	standings := make([][]Standing, len(g.Groups))
+	for group := range g.Groups {
+		standings[group] = g.Standings(group)
+	}
+	var ret []int
+	for rank := 0; rank < g.Advance; rank++ {
+		for group := range g.Groups {
+			if rank < len(standings[group]) {
+				ret = append(ret, standings[group][rank].Entrant)
+			}
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,245 @@
+package tournament
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCircleRounds(t *testing.T) {
+	for _, count := range []int{2, 3, 4, 5, 8} {
+		var entrants []int
+		for i := 1; i <= count; i++ {
+			entrants = append(entrants, i)
+		}
+		rounds := CircleRounds(entrants)
+
+		met := make(map[[2]int]int)
+		for r, round := range rounds {
+			playing := make(map[int]bool)
+			for _, pair := range round {
+				if playing[pair[0]] || playing[pair[1]] {
+					t.Errorf("Unexpected entrant playing twice in round %d of %d entrants: %v", r+1, count, round)
+				}
+				playing[pair[0]], playing[pair[1]] = true, true
+				if pair[0] > pair[1] {
+					pair[0], pair[1] = pair[1], pair[0]
+				}
+				met[pair]++
+			}
+		}
+		expectedRounds := count - 1
+		if count%2 == 1 {
+			expectedRounds = count
+		}
+		if len(rounds) != expectedRounds {
+			t.Errorf("Unexpected rounds of %d entrants, expected %d got %d", count, expectedRounds, len(rounds))
+		}
+		if len(met) != count*(count-1)/2 {
+			t.Errorf("Unexpected pairs of %d entrants, expected %d got %d", count, count*(count-1)/2, len(met))
+		}
+		for pair, times := range met {
+			if times != 1 {
+				t.Errorf("Unexpected times %v met, expected 1 got %d", pair, times)
+			}
+		}
+	}
+}
+
+func TestNewGroupStage(t *testing.T) {
+	cases := []struct {
+		title            string
+		entrants         int
+		groups           int
+		advance          int
+		courts           int
+		expectedGroups   [][]int
+		expectedFixtures int
+		expectedSlots    int
+		expectedError    bool
+	}{
+		{"Snake", 6, 2, 1, 2, [][]int{{1, 4, 5}, {2, 3, 6}}, 6, 3, false},
+		{"TwoGroupsOfFour", 8, 2, 2, 2, [][]int{{1, 4, 5, 8}, {2, 3, 6, 7}}, 12, 6, false},
+		{"OneCourt", 4, 1, 2, 1, [][]int{{1, 2, 3, 4}}, 6, 6, false},
+		{"TooManyGroups", 5, 3, 1, 2, nil, 0, 0, true},
+		{"TooManyAdvancing", 8, 2, 5, 2, nil, 0, 0, true},
+		{"NoCourt", 8, 2, 1, 0, nil, 0, 0, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			g, err := NewGroupStage(tc.entrants, tc.groups, tc.advance, tc.courts)
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("Expected error but got nil.")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			if !reflect.DeepEqual(tc.expectedGroups, g.Groups) {
+				t.Errorf("Unexpected groups, expected %v got %v", tc.expectedGroups, g.Groups)
+			}
+			if len(g.Fixtures) != tc.expectedFixtures {
+				t.Errorf("Unexpected fixtures, expected %d got %d", tc.expectedFixtures, len(g.Fixtures))
+			}
+
+			slots := make(map[int][]*Fixture)
+			for _, fixture := range g.Fixtures {
+				slots[fixture.Slot] = append(slots[fixture.Slot], fixture)
+			}
+			if len(slots) != tc.expectedSlots {
+				t.Errorf("Unexpected slots, expected %d got %d", tc.expectedSlots, len(slots))
+			}
+			for slot, fixtures := range slots {
+				if len(fixtures) > tc.courts {
+					t.Errorf("Unexpected fixtures in slot %d, expected at most %d got %d", slot, tc.courts, len(fixtures))
+				}
+			}
+		})
+	}
+}
+
+func TestGroupStageRest(t *testing.T) {
+	// With 2 groups on 2 courts, the groups can take turns so nobody plays in consecutive slots.
+	g, err := NewGroupStage(8, 2, 1, 2)
+	if err != nil {
+		t.Fatalf("Not expecting error but got %v.", err)
+	}
+	lastSlot := make(map[int]int)
+	for _, fixture := range g.Pending() {
+		for _, entrant := range []int{fixture.Entrant1, fixture.Entrant2} {
+			if last, ok := lastSlot[entrant]; ok && last >= fixture.Slot-1 {
+				t.Errorf("Unexpected entrant %d playing in slot %d after slot %d", entrant, fixture.Slot, last)
+			}
+			lastSlot[entrant] = fixture.Slot
+		}
+	}
+}
+
+func TestStandings(t *testing.T) {
+	g, err := NewGroupStage(4, 1, 2, 2)
+	if err != nil {
+		t.Fatalf("Not expecting error but got %v.", err)
+	}
+	// 1 beats 2 and 4, 2 beats 3 and 4, 3 beats 1 and 4: all three have 2 wins and 1 win
+	// among themselves, so the point difference decides. 4 loses every match.
+	results := map[[2]int][3]int{
+		{1, 2}: {1, 21, 19},
+		{1, 4}: {1, 21, 10},
+		{2, 3}: {1, 21, 5},
+		{2, 4}: {1, 21, 10},
+		{3, 1}: {1, 21, 19},
+		{3, 4}: {1, 21, 10},
+	}
+	for _, fixture := range g.Fixtures {
+		result, ok := results[[2]int{fixture.Entrant1, fixture.Entrant2}]
+		winner := result[0]
+		points1, points2 := result[1], result[2]
+		if !ok {
+			result = results[[2]int{fixture.Entrant2, fixture.Entrant1}]
+			winner = 3 - result[0]
+			points1, points2 = result[2], result[1]
+		}
+		if err := g.Report(fixture.ID, winner, points1, points2); err != nil {
+			t.Fatalf("Not expecting error but got %v.", err)
+		}
+	}
+
+	var ranking []int
+	for _, standing := range g.Standings(0) {
+		ranking = append(ranking, standing.Entrant)
+	}
+	if expected := []int{2, 1, 3, 4}; !reflect.DeepEqual(expected, ranking) {
+		t.Errorf("Unexpected ranking, expected %v got %v", expected, ranking)
+	}
+
+	// Once 4 beats 2 21-10 instead, 1 and 3 have 2 wins and 3 beat 1, while 2 and 4 have 1 win
+	// and 4 beat 2. Head-to-head goes before the point difference.
+	for _, fixture := range g.Fixtures {
+		if fixture.Entrant1 == 4 && fixture.Entrant2 == 2 {
+			g.Report(fixture.ID, 1, 21, 10)
+		} else if fixture.Entrant1 == 2 && fixture.Entrant2 == 4 {
+			g.Report(fixture.ID, 2, 10, 21)
+		}
+	}
+	ranking = nil
+	for _, standing := range g.Standings(0) {
+		ranking = append(ranking, standing.Entrant)
+	}
+	if expected := []int{3, 1, 4, 2}; !reflect.DeepEqual(expected, ranking) {
+		t.Errorf("Unexpected ranking, expected %v got %v", expected, ranking)
+	}
+}
+
+func TestQualifiers(t *testing.T) {
+	g, err := NewGroupStage(8, 2, 2, 2)
+	if err != nil {
+		t.Fatalf("Not expecting error but got %v.", err)
+	}
+	if _, err := g.Qualifiers(); err == nil {
+		t.Errorf("Expected error but got nil.")
+	}
+	// The better seed always wins.
+	for _, fixture := range g.Fixtures {
+		winner := 1
+		if fixture.Entrant2 < fixture.Entrant1 {
+			winner = 2
+		}
+		g.Report(fixture.ID, winner, 0, 0)
+	}
+	if !g.Done() {
+		t.Errorf("Unexpected group stage not done")
+	}
+	qualifiers, err := g.Qualifiers()
+	if err != nil {
+		t.Errorf("Not expecting error but got %v.", err)
+	}
+	if expected := []int{1, 2, 4, 3}; !reflect.DeepEqual(expected, qualifiers) {
+		t.Errorf("Unexpected qualifiers, expected %v got %v", expected, qualifiers)
+	}
+
+	if err := g.Report(len(g.Fixtures), 1, 0, 0); err == nil {
+		t.Errorf("Expected error but got nil.")
+	}
+	if err := g.Report(0, 0, 0, 0); err == nil {
+		t.Errorf("Expected error but got nil.")
+	}
+}
+
+func TestGroupStageReady(t *testing.T) {
+	// 4 entrants in 1 group on 2 courts play 2 fixtures in every slot.
+	g, err := NewGroupStage(4, 1, 1, 2)
+	if err != nil {
+		t.Fatalf("Not expecting error but got %v.", err)
+	}
+	slotFixtures := func(slot int) []*Fixture {
+		var ret []*Fixture
+		for _, fixture := range g.Fixtures {
+			if fixture.Slot == slot {
+				ret = append(ret, fixture)
+			}
+		}
+		return ret
+	}
+
+	ready := g.Ready(nil)
+	if len(ready) != 2 || ready[0].Slot != 0 || ready[1].Slot != 0 {
+		t.Fatalf("Unexpected ready fixtures, expected both fixtures of slot 0 got %v", ready)
+	}
+	ready[0].Ref, ready[1].Ref = 1, 2
+	if ready := g.Ready(nil); len(ready) != 0 {
+		t.Errorf("Unexpected ready fixtures while slot 0 is being played, expected none got %v", ready)
+	}
+
+	// The first fixture of slot 0 is over, but its entrants have just played it.
+	g.Report(slotFixtures(0)[0].ID, 1, 21, 10)
+	if ready := g.Ready(map[int]bool{1: true}); len(ready) != 0 {
+		t.Errorf("Unexpected ready fixtures after slot 0 is played in the current round, expected none got %v", ready)
+	}
+	g.Report(slotFixtures(0)[1].ID, 2, 15, 21)
+	if ready := g.Ready(nil); len(ready) != 2 || ready[0].Slot != 1 || ready[1].Slot != 1 {
+		t.Errorf("Unexpected ready fixtures, expected both fixtures of slot 1 got %v", ready)
+	}
+}
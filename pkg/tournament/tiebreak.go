@@ -0,0 +1,83 @@
+package tournament
+
+import (
+	"sort"
+)
+
+// Result is the result of a match between two sides of one or two players each, for the
+// tiebreakers of Swiss-system standings.
+type Result struct {
+	Side1 []int
+	Side2 []int
+	// Winner is the side who won, 1 or 2.
+	Winner int
+}
+
+// Tiebreaks are the tiebreakers of a player in Swiss-system standings. In doubles, the score
+// of an opponent is the average score of the opposing side.
+type Tiebreaks struct {
+	// Buchholz is the sum of the scores of the opponents.
+	Buchholz float64
+	// MedianBuchholz is the Buchholz without the highest and the lowest opponent scores, when
+	// there are more than 2 opponents.
+	MedianBuchholz float64
+	// SonnebornBerger is the sum of the scores of the opponents beaten.
+	SonnebornBerger float64
+}
+
+// ComputeTiebreaks returns the tiebreaks of every player with a score, from the final scores
+// of the players and the results of their matches.
+func ComputeTiebreaks(scores map[int]float64, results []Result) map[int]Tiebreaks {
+	opponentScores := make(map[int][]float64)
+	ret := make(map[int]Tiebreaks)
+	for _, result := range results {
+		for idx, side := range [][]int{result.Side1, result.Side2} {
+			opposing := result.Side2
+			if idx == 1 {
+				opposing = result.Side1
+			}
+			opponentScore := averageScore(scores, opposing)
+			for _, id := range side {
+				if _, ok := scores[id]; !ok {
+					continue
+				}
+				opponentScores[id] = append(opponentScores[id], opponentScore)
+				if result.Winner == idx+1 {
+					tiebreaks := ret[id]
+					tiebreaks.SonnebornBerger += opponentScore
+					ret[id] = tiebreaks
+				}
+			}
+		}
+	}
+
+	for id := range scores {
+		tiebreaks := ret[id]
+		opponents := opponentScores[id]
+		sort.Float64s(opponents)
+		for idx, score := range opponents {
+			tiebreaks.Buchholz += score
+			if len(opponents) <= 2 || (idx > 0 && idx < len(opponents)-1) {
+				tiebreaks.MedianBuchholz += score
+			}
+		}
+		ret[id] = tiebreaks
+	}
+	return ret
+}
+
+// averageScore returns the average score of the players with a score.
+func averageScore(scores map[int]float64, ids []int) float64 {
+	var total float64
+	var count int
+	for _, id := range ids {
+		if score, ok := scores[id]; ok {
+			total += score
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / float64(count)
+}
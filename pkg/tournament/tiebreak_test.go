@@ -0,0 +1,46 @@
+package tournament
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComputeTiebreaks(t *testing.T) {
+	scores := map[int]float64{1: 3, 2: 1, 3: 2, 4: 0, 5: 1, 6: -1}
+	results := []Result{
+		{Side1: []int{1}, Side2: []int{2}, Winner: 1},
+		{Side1: []int{1}, Side2: []int{3}, Winner: 1},
+		{Side1: []int{4}, Side2: []int{1}, Winner: 2},
+		{Side1: []int{2}, Side2: []int{3}, Winner: 2},
+		// Doubles, where the opposing side scores the average of its players.
+		{Side1: []int{5, 6}, Side2: []int{3, 4}, Winner: 1},
+		// Players without a score are ignored.
+		{Side1: []int{7}, Side2: []int{6}, Winner: 1},
+	}
+	cases := []struct {
+		player   int
+		expected Tiebreaks
+	}{
+		// Opponents scored 1, 2 and 0, and all of them were beaten.
+		{1, Tiebreaks{Buchholz: 3, MedianBuchholz: 1, SonnebornBerger: 3}},
+		// Opponents scored 3 and 2, and none was beaten.
+		{2, Tiebreaks{Buchholz: 5, MedianBuchholz: 5, SonnebornBerger: 0}},
+		// Opponents scored 3, 1 and 0 (the average of 5 and 6), and 2 was beaten.
+		{3, Tiebreaks{Buchholz: 4, MedianBuchholz: 1, SonnebornBerger: 1}},
+		// Opponents scored 1 (the average of 3 and 4), who were beaten, and 0 (7 without a score).
+		{6, Tiebreaks{Buchholz: 1, MedianBuchholz: 1, SonnebornBerger: 1}},
+	}
+
+	tiebreaks := ComputeTiebreaks(scores, results)
+	if _, ok := tiebreaks[7]; ok {
+		t.Errorf("Unexpected tiebreaks of a player without a score")
+	}
+	for _, tc := range cases {
+		got := tiebreaks[tc.player]
+		if math.Abs(got.Buchholz-tc.expected.Buchholz) > 1e-9 ||
+			math.Abs(got.MedianBuchholz-tc.expected.MedianBuchholz) > 1e-9 ||
+			math.Abs(got.SonnebornBerger-tc.expected.SonnebornBerger) > 1e-9 {
+			t.Errorf("Unexpected tiebreaks of player %d, expected %+v got %+v", tc.player, tc.expected, got)
+		}
+	}
+}
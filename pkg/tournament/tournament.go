@@ -0,0 +1,346 @@
+// Package tournament builds single-elimination and double-elimination brackets from seeded
+// entrants, which are players or pairs, and advances the winners as results are reported.
+package tournament
+
+import (
+	"fmt"
+)
+
+// Kind is the kind of a tournament.
+type Kind string
+
+// The kinds of tournaments.
+const (
+	// SingleElimination knocks an entrant out after one loss.
+	SingleElimination Kind = "single"
+	// DoubleElimination knocks an entrant out after two losses. The losers of the winners bracket
+	// drop into a losers bracket, whose champion meets the winners bracket champion in the grand
+	// final, which is played again if the losers bracket champion wins the first one.
+	DoubleElimination Kind = "double"
+)
+
+// Kinds are all the kinds of tournaments.
+var Kinds = []Kind{SingleElimination, DoubleElimination}
+
+// ParseKind returns the Kind of the given name.
+func ParseKind(name string) (Kind, error) {
+	for _, kind := range Kinds {
+		if string(kind) == name {
+			return kind, nil
+		}
+	}
+	return "", fmt.Errorf("unknown tournament kind %q, expecting one of %v", name, Kinds)
+}
+
+// The brackets a match can be in.
+const (
+	WinnersBracket = "W"
+	LosersBracket  = "L"
+	GrandFinal     = "F"
+)
+
+// Entrants in a match are identified by their 1-based seeds. Unknown is an entrant yet to be
+// decided by an earlier match, and Bye is the empty opponent of an entrant who advances for free.
+const (
+	Unknown = 0
+	Bye     = -1
+)
+
+// Slot is the side of a match an entrant goes to.
+type Slot struct {
+	Match int `json:"match"`
+	Side  int `json:"side"`
+}
+
+// Match is a match in a bracket.
+type Match struct {
+	ID      int    `json:"id"`
+	Bracket string `json:"bracket"`
+	// Round is the 1-based round within the bracket.
+	Round    int `json:"round"`
+	Entrant1 int `json:"entrant1"`
+	Entrant2 int `json:"entrant2"`
+	// Winner is the side who won, 1 or 2, or 0 if the match has not been decided.
+	Winner int `json:"winner"`
+	// Skipped is set for the second grand final when it is not needed.
+	Skipped  bool  `json:"skipped,omitempty"`
+	WinnerTo *Slot `json:"winner_to,omitempty"`
+	LoserTo  *Slot `json:"loser_to,omitempty"`
+	// Ref is the ID the match is played under, set by the caller when the match is scheduled.
+	Ref int `json:"ref,omitempty"`
+}
+
+// Entrant returns the entrant on the given side.
+func (m *Match) Entrant(side int) int {
+	if side == 1 {
+		return m.Entrant1
+	}
+	return m.Entrant2
+}
+
+func (m *Match) setEntrant(side, entrant int) {
+	if side == 1 {
+		m.Entrant1 = entrant
+	} else {
+		m.Entrant2 = entrant
+	}
+}
+
+// Ready returns whether the match can be played, i.e. both entrants are known and it is not decided.
+func (m *Match) Ready() bool {
+	return m.Winner == 0 && !m.Skipped && m.Entrant1 > 0 && m.Entrant2 > 0
+}
+
+// Bracket is a tournament bracket. Matches are indexed by their IDs.
+type Bracket struct {
+	Kind     Kind     `json:"kind"`
+	Entrants int      `json:"entrants"`
+	Matches  []*Match `json:"matches"`
+}
+
+// SeedOrder returns the seeds in the order they are placed in the first round of a bracket of the
+// given size, a power of 2, so that the top seeds can only meet in the latest rounds, e.g.
+// 1, 8, 4, 5, 2, 7, 3, 6 for 8.
+func SeedOrder(size int) []int {
+	order := []int{1}
+	for len(order) < size {
+		next := make([]int, 0, len(order)*2)
+		for _, seed := range order {
+			next = append(next, seed, len(order)*2+1-seed)
+		}
+		order = next
+	}
+	return order
+}
+
+// New builds a bracket of the given kind for the given number of entrants, seeded 1 to entrants.
+// When the number is not a power of 2, the top seeds get byes in the first round.
+func New(kind Kind, entrants int) (*Bracket, error) {
+	if entrants < 2 {
+		return nil, fmt.Errorf("a tournament needs at least 2 entrants, %d given", entrants)
+	}
+	if kind != SingleElimination && kind != DoubleElimination {
+		return nil, fmt.Errorf("unknown tournament kind %q", kind)
+	}
+
+	size, rounds := 1, 0
+	for size < entrants {
+		size *= 2
+		rounds++
+	}
+
+	b := &Bracket{Kind: kind, Entrants: entrants}
+	winners := b.addRounds(WinnersBracket, rounds, size/2)
+	for idx, seed := range SeedOrder(size) {
+		if seed > entrants {
+			seed = Bye
+		}
+		winners[0][idx/2].setEntrant(idx%2+1, seed)
+	}
+	for r := 0; r+1 < rounds; r++ {
+		for idx, match := range winners[r] {
+			match.WinnerTo = &Slot{Match: winners[r+1][idx/2].ID, Side: idx%2 + 1}
+		}
+	}
+
+	if kind == DoubleElimination {
+		b.addLosersBracket(winners, rounds, size)
+	}
+	b.resolveByes()
+	return b, nil
+}
+
+// addRounds adds the given number of rounds to a bracket, halving the matches every round.
+func (b *Bracket) addRounds(bracket string, rounds, firstRoundMatches int) [][]*Match {
+	var ret [][]*Match
+	count := firstRoundMatches
+	for r := 1; r <= rounds; r++ {
+		var matches []*Match
+		for i := 0; i < count; i++ {
+			matches = append(matches, b.addMatch(bracket, r))
+		}
+		ret = append(ret, matches)
+		count /= 2
+	}
+	return ret
+}
+
+func (b *Bracket) addMatch(bracket string, round int) *Match {
+	match := &Match{ID: len(b.Matches), Bracket: bracket, Round: round}
+	b.Matches = append(b.Matches, match)
+	return match
+}
+
+// addLosersBracket adds the losers bracket and the grand finals. The losers bracket alternates
+// between rounds where the losers of a winners bracket round drop in, and rounds where the
+// survivors play each other. The dropping losers are taken in reverse order every other time,
+// so they are less likely to meet the entrants they have just played.
+func (b *Bracket) addLosersBracket(winners [][]*Match, rounds, size int) {
+	final := b.addMatch(GrandFinal, 1)
+	b.addMatch(GrandFinal, 2)
+	winners[rounds-1][0].WinnerTo = &Slot{Match: final.ID, Side: 1}
+
+	if rounds == 1 {
+		winners[0][0].LoserTo = &Slot{Match: final.ID, Side: 2}
+		return
+	}
+
+	// The first losers round pairs the losers of the first winners round.
+	var survivors []*Match
+	for i := 0; i < size/4; i++ {
+		match := b.addMatch(LosersBracket, 1)
+		winners[0][2*i].LoserTo = &Slot{Match: match.ID, Side: 1}
+		winners[0][2*i+1].LoserTo = &Slot{Match: match.ID, Side: 2}
+		survivors = append(survivors, match)
+	}
+
+	round := 1
+	for w := 1; w < rounds; w++ {
+		// The losers of the next winners round drop in.
+		round++
+		var dropped []*Match
+		for i, survivor := range survivors {
+			match := b.addMatch(LosersBracket, round)
+			survivor.WinnerTo = &Slot{Match: match.ID, Side: 1}
+			from := i
+			if w%2 == 1 {
+				from = len(survivors) - 1 - i
+			}
+			winners[w][from].LoserTo = &Slot{Match: match.ID, Side: 2}
+			dropped = append(dropped, match)
+		}
+		survivors = dropped
+		if len(survivors) == 1 {
+			break
+		}
+
+		// The survivors play each other.
+		round++
+		var paired []*Match
+		for i := 0; i < len(survivors); i += 2 {
+			match := b.addMatch(LosersBracket, round)
+			survivors[i].WinnerTo = &Slot{Match: match.ID, Side: 1}
+			survivors[i+1].WinnerTo = &Slot{Match: match.ID, Side: 2}
+			paired = append(paired, match)
+		}
+		survivors = paired
+	}
+	survivors[0].WinnerTo = &Slot{Match: final.ID, Side: 2}
+}
+
+// place puts an entrant into a slot.
+func (b *Bracket) place(slot *Slot, entrant int) {
+	if slot != nil {
+		b.Matches[slot.Match].setEntrant(slot.Side, entrant)
+	}
+}
+
+// decide records the winner of a match and moves its entrants on.
+func (b *Bracket) decide(match *Match, winner int) {
+	match.Winner = winner
+	b.place(match.WinnerTo, match.Entrant(winner))
+	b.place(match.LoserTo, match.Entrant(3-winner))
+
+	if match.Bracket == GrandFinal && match.Round == 1 {
+		reset := b.grandFinal(2)
+		if winner == 1 {
+			// The winners bracket champion has not lost at all, so there is no need for a reset.
+			reset.Skipped = true
+		} else {
+			reset.Entrant1, reset.Entrant2 = match.Entrant1, match.Entrant2
+		}
+	}
+}
+
+// grandFinal returns the grand final of the given round, or nil for single elimination.
+func (b *Bracket) grandFinal(round int) *Match {
+	for _, match := range b.Matches {
+		if match.Bracket == GrandFinal && match.Round == round {
+			return match
+		}
+	}
+	return nil
+}
+
+// resolveByes decides every match against a bye, until none is left.
+func (b *Bracket) resolveByes() {
+	for changed := true; changed; {
+		changed = false
+		for _, match := range b.Matches {
+			if match.Winner != 0 || match.Skipped || match.Entrant1 == Unknown || match.Entrant2 == Unknown {
+				continue
+			}
+			if match.Entrant1 == Bye {
+				b.decide(match, 2)
+				changed = true
+			} else if match.Entrant2 == Bye {
+				b.decide(match, 1)
+				changed = true
+			}
+		}
+	}
+}
+
+// Report records that the given side, 1 or 2, won the match of the given ID, and advances the
+// entrants of the match.
+func (b *Bracket) Report(id, winner int) error {
+	if id < 0 || id >= len(b.Matches) {
+		return fmt.Errorf("unknown bracket match %d", id)
+	}
+	if winner != 1 && winner != 2 {
+		return fmt.Errorf("invalid winner %d of bracket match %d, 1 or 2 is expected", winner, id)
+	}
+	match := b.Matches[id]
+	if !match.Ready() {
+		return fmt.Errorf("bracket match %d is not ready to be reported", id)
+	}
+	b.decide(match, winner)
+	b.resolveByes()
+	return nil
+}
+
+// Ready returns the matches which can be played now, in the order they should be played.
+func (b *Bracket) Ready() []*Match {
+	var ret []*Match
+	for _, match := range b.Matches {
+		if match.Ready() {
+			ret = append(ret, match)
+		}
+	}
+	return ret
+}
+
+// Champion returns the seed of the tournament winner, or Unknown if the tournament is not over.
+func (b *Bracket) Champion() int {
+	if b.Kind == SingleElimination {
+		final := b.Matches[len(b.Matches)-1]
+		if final.Winner == 0 {
+			return Unknown
+		}
+		return final.Entrant(final.Winner)
+	}
+
+	final, reset := b.grandFinal(1), b.grandFinal(2)
+	if reset.Winner != 0 {
+		return reset.Entrant(reset.Winner)
+	}
+	if reset.Skipped {
+		return final.Entrant(final.Winner)
+	}
+	return Unknown
+}
+
+// Rounds returns the matches of a bracket grouped by round, in the order of the rounds.
+func (b *Bracket) Rounds(bracket string) [][]*Match {
+	var ret [][]*Match
+	for _, match := range b.Matches {
+		if match.Bracket != bracket {
+			continue
+		}
+		for len(ret) < match.Round {
+			ret = append(ret, nil)
+		}
+		ret[match.Round-1] = append(ret[match.Round-1], match)
+	}
+	return ret
+}
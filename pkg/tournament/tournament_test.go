@@ -0,0 +1,174 @@
+package tournament
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSeedOrder(t *testing.T) {
+	cases := []struct {
+		size     int
+		expected []int
+	}{
+		{1, []int{1}},
+		{2, []int{1, 2}},
+		{4, []int{1, 4, 2, 3}},
+		{8, []int{1, 8, 4, 5, 2, 7, 3, 6}},
+	}
+	for _, tc := range cases {
+		if order := SeedOrder(tc.size); !reflect.DeepEqual(tc.expected, order) {
+			t.Errorf("Unexpected seed order of %d, expected %v got %v", tc.size, tc.expected, order)
+		}
+	}
+}
+
+// play reports every ready match until the tournament is over, where the better seed always wins
+// unless the match is one of the upsets, given by their IDs. It returns how many matches every
+// seed lost.
+func play(t *testing.T, b *Bracket, upsets map[int]bool) map[int]int {
+	losses := make(map[int]int)
+	for steps := 0; ; steps++ {
+		ready := b.Ready()
+		if len(ready) == 0 {
+			return losses
+		}
+		if steps > 100 {
+			t.Fatalf("The tournament does not end")
+		}
+		match := ready[0]
+		winner := 1
+		if match.Entrant2 < match.Entrant1 {
+			winner = 2
+		}
+		if upsets[match.ID] {
+			winner = 3 - winner
+		}
+		losses[match.Entrant(3-winner)]++
+		if err := b.Report(match.ID, winner); err != nil {
+			t.Fatalf("Not expecting error but got %v.", err)
+		}
+	}
+}
+
+func TestSingleElimination(t *testing.T) {
+	cases := []struct {
+		title            string
+		entrants         int
+		upsets           map[int]bool
+		expectedMatches  int
+		expectedByes     int
+		expectedChampion int
+	}{
+		{"Two", 2, nil, 1, 0, 1},
+		{"PowerOfTwo", 8, nil, 7, 0, 1},
+		{"Byes", 5, nil, 4, 3, 1},
+		{"Upset", 6, map[int]bool{6: true}, 5, 2, 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			b, err := New(SingleElimination, tc.entrants)
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			byes := 0
+			for _, match := range b.Matches {
+				if match.Entrant1 == Bye || match.Entrant2 == Bye {
+					byes++
+				}
+			}
+			if byes != tc.expectedByes {
+				t.Errorf("Unexpected byes, expected %d got %d", tc.expectedByes, byes)
+			}
+
+			losses := play(t, b, tc.upsets)
+			played := 0
+			for _, count := range losses {
+				played += count
+			}
+			if played != tc.expectedMatches {
+				t.Errorf("Unexpected matches played, expected %d got %d", tc.expectedMatches, played)
+			}
+			if champion := b.Champion(); champion != tc.expectedChampion {
+				t.Errorf("Unexpected champion, expected %d got %d", tc.expectedChampion, champion)
+			}
+		})
+	}
+}
+
+func TestDoubleElimination(t *testing.T) {
+	cases := []struct {
+		title            string
+		entrants         int
+		upsets           map[int]bool
+		expectedChampion int
+		expectedReset    bool
+	}{
+		{"Two", 2, nil, 1, false},
+		{"TwoWithReset", 2, map[int]bool{1: true}, 1, true},
+		{"Four", 4, nil, 1, false},
+		{"Eight", 8, nil, 1, false},
+		{"Byes", 6, nil, 1, false},
+		{"Sixteen", 16, nil, 1, false},
+		{"LosersBracketChampion", 16, map[int]bool{0: true}, 1, true},
+		{"WinnersFinalUpset", 8, map[int]bool{6: true}, 1, true},
+		{"ResetLost", 4, map[int]bool{2: true, 4: true}, 2, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			b, err := New(DoubleElimination, tc.entrants)
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+
+			losses := play(t, b, tc.upsets)
+			champion := b.Champion()
+			if champion != tc.expectedChampion {
+				t.Errorf("Unexpected champion, expected %d got %d", tc.expectedChampion, champion)
+			}
+			for seed := 1; seed <= tc.entrants; seed++ {
+				expected := 2
+				if seed == champion {
+					expected = losses[seed]
+					if expected > 1 {
+						t.Errorf("Unexpected losses of the champion %d, expected at most 1 got %d", seed, expected)
+					}
+				}
+				if losses[seed] != expected {
+					t.Errorf("Unexpected losses of seed %d, expected %d got %d", seed, expected, losses[seed])
+				}
+			}
+			if reset := b.grandFinal(2); reset.Skipped == tc.expectedReset {
+				t.Errorf("Unexpected grand final reset, expected %v got %v", tc.expectedReset, !reset.Skipped)
+			}
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	b, _ := New(SingleElimination, 3)
+	cases := []struct {
+		title  string
+		id     int
+		winner int
+	}{
+		{"UnknownMatch", 5, 1},
+		{"InvalidWinner", 1, 3},
+		{"DecidedByBye", 0, 1},
+		{"NotReady", 2, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			if err := b.Report(tc.id, tc.winner); err == nil {
+				t.Errorf("Expected error but got nil.")
+			}
+		})
+	}
+
+	if _, err := New(DoubleElimination, 1); err == nil {
+		t.Errorf("Expected error but got nil.")
+	}
+}
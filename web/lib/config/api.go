@@ -0,0 +1,6 @@
+package config
+
+// APIKey is the key API clients must give in the admin header to create events. Creating events
+// through the API is disabled when it is empty.
+// The variable is supposed to be set once when the web server starts.
+var APIKey string
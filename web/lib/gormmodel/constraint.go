@@ -0,0 +1,21 @@
+package gormmodel
+
+import (
+	"gorm.io/gorm"
+)
+
+// PlayerConstraint represents a record in the player_constraint table, a hard constraint
+// between two players that every arrangement of the event must honour.
+type PlayerConstraint struct {
+	gorm.Model
+	Eid int
+	// Kind is one of the constraint kinds defined in pkg/arranger, e.g. "never_partner".
+	Kind string
+	Pid1 int
+	Pid2 int
+}
+
+// TableName overrides the default plural-form table name.
+func (PlayerConstraint) TableName() string {
+	return "player_constraint"
+}
@@ -0,0 +1,36 @@
+package gormmodel
+
+import (
+	"gorm.io/gorm"
+)
+
+// CourtState represents a record in the court_state table, what a court is doing in an event
+// whose matches are not scheduled in lockstep rounds.
+type CourtState struct {
+	gorm.Model
+	Eid   int
+	Court int
+	// Mid is the ID of the current match on the court.
+	Mid int
+	// Stays is how many matches in a row side 1 of the current match has won on the court.
+	Stays int
+}
+
+// TableName overrides the default plural-form table name.
+func (CourtState) TableName() string {
+	return "court_state"
+}
+
+// QueuedPlayer represents a record in the queued_player table, a player waiting for a court.
+type QueuedPlayer struct {
+	gorm.Model
+	Eid int
+	Pid int
+	// Position is the 0-based position of the player in the waiting queue of the event.
+	Position int
+}
+
+// TableName overrides the default plural-form table name.
+func (QueuedPlayer) TableName() string {
+	return "queued_player"
+}
@@ -16,6 +16,34 @@ type Event struct {
 	CurrentRound int
 	AdminKey     string
 	Internal     bool
+	// Arranger is the name of the arranger strategy used to schedule rounds,
+	// empty for the default one.
+	Arranger string
+	// CostModel holds the weights used to evaluate arrangements, in the form parsed by
+	// arranger.ParseCostModel. Empty for the default weights.
+	CostModel string
+	// Banding is how players are split into score bands, in the form parsed by
+	// arranger.ParseBanding. Empty for hierarchical clustering.
+	Banding string
+	// Format decides which player categories may be combined in doubles matches,
+	// see arranger.Format. Empty for no restriction.
+	Format string
+	// BalanceSides splits doubles matches into the sides with the closest combined scores,
+	// see arranger.Input.BalanceSides.
+	BalanceSides bool
+	// CatchUp is the policy crediting players who check in late with games, see
+	// arranger.CatchUpPolicy. Empty for no credit.
+	CatchUp string
+	// Rating is the name of the rating system turning match results into scores, see
+	// rating.Lookup. Empty for the classic sum of +1 for a win and -1 for a loss.
+	Rating string
+	// Handicap gives the weaker side of uneven matches a head start, see scoring.DefaultHandicapRule.
+	Handicap bool
+	// Mode is how the matches are scheduled, see rotation.Mode. Empty for lockstep rounds.
+	Mode string
+	// MaxStays is the most matches in a row a side can win on a court under rotation.WinnersStayOn,
+	// 0 for no limit.
+	MaxStays int
 }
 
 // TableName overrides the default plural-form table name.
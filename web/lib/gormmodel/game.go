@@ -0,0 +1,21 @@
+package gormmodel
+
+import (
+	"gorm.io/gorm"
+)
+
+// Game represents a record in the game table, the final score of one game in a match.
+type Game struct {
+	gorm.Model
+	Eid int
+	Mid int
+	// Number is the 1-based order of the game in the match.
+	Number      int
+	Side1Points int
+	Side2Points int
+}
+
+// TableName overrides the default plural-form table name.
+func (Game) TableName() string {
+	return "game"
+}
@@ -0,0 +1,24 @@
+package gormmodel
+
+import (
+	"gorm.io/gorm"
+)
+
+// GroupStage represents a record in the group_stage table, the round-robin groups played within
+// an event before a knockout. An event has at most one group stage.
+type GroupStage struct {
+	gorm.Model
+	Eid int
+	// Entrants is the JSON encoded player IDs of the entrants in the order of their seeds,
+	// in the same form as Tournament.Entrants.
+	Entrants string
+	// Knockout is the kind of the tournament the qualifiers advance to, see tournament.Kind.
+	Knockout string
+	// Stage is the JSON encoded tournament.GroupStage, holding the results reported so far.
+	Stage string
+}
+
+// TableName overrides the default plural-form table name.
+func (GroupStage) TableName() string {
+	return "group_stage"
+}
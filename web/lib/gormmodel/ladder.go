@@ -0,0 +1,63 @@
+package gormmodel
+
+import (
+	"gorm.io/gorm"
+)
+
+// Represents the ENUM of the status field of a challenge record.
+const (
+	CHALLENGEOPEN    = "OPEN"
+	CHALLENGEPLAYED  = "PLAYED"
+	CHALLENGEEXPIRED = "EXPIRED"
+)
+
+// Ladder represents a record in the ladder table, a club ladder which persists across events.
+type Ladder struct {
+	gorm.Model
+	Key      string
+	Name     string
+	AdminKey string
+	// Reach is how many places above their own a player may challenge.
+	Reach int
+	// ExpiryDays is how many days a challenge may wait to be scheduled before it lapses, 0 for never.
+	ExpiryDays int
+}
+
+// TableName overrides the default plural-form table name.
+func (Ladder) TableName() string {
+	return "ladder"
+}
+
+// LadderRung represents a record in the ladder_rung table, the position of a player on a ladder.
+// Players are identified by their names, as they are entered again for every event.
+type LadderRung struct {
+	gorm.Model
+	Lid  int
+	Name string
+	// Position is the 1-based position of the player, 1 at the top.
+	Position int
+}
+
+// TableName overrides the default plural-form table name.
+func (LadderRung) TableName() string {
+	return "ladder_rung"
+}
+
+// Challenge represents a record in the challenge table, a challenge between two players of a ladder.
+type Challenge struct {
+	gorm.Model
+	Lid        int
+	Challenger string
+	Defender   string
+	Status     string
+	// Mid is the ID of the match the challenge is played in, 0 until it is scheduled. The
+	// challenger plays on side 1.
+	Mid int
+	// ChallengerWon is set when the challenge has been played and the challenger won.
+	ChallengerWon bool
+}
+
+// TableName overrides the default plural-form table name.
+func (Challenge) TableName() string {
+	return "challenge"
+}
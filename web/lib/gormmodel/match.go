@@ -20,8 +20,14 @@ type Match struct {
 	Sid2   int
 	Court  int
 	Status string
-	Side1  *Side `gorm:"foreignKey:sid1"`
-	Side2  *Side `gorm:"foreignKey:sid2"`
+	// HandicapSide is the side, 1 or 2, who starts every game with HandicapPoints,
+	// or 0 if the match has no handicap.
+	HandicapSide   int
+	HandicapPoints int
+	Side1          *Side `gorm:"foreignKey:sid1"`
+	Side2          *Side `gorm:"foreignKey:sid2"`
+	// Games are the recorded game scores in order, empty if only the winner was reported.
+	Games []Game `gorm:"foreignKey:mid"`
 }
 
 // TableName overrides the default plural-form table name.
@@ -0,0 +1,24 @@
+package gormmodel
+
+import (
+	"gorm.io/gorm"
+)
+
+// PlannedRound represents a record in the planned_round table, a round arranged ahead of time
+// by the multi-round planner. It's only used when the same players are available as when it
+// was planned.
+type PlannedRound struct {
+	gorm.Model
+	Eid   int
+	Round int
+	// Active is the comma separated, sorted IDs of the players available when the round was planned.
+	Active string
+	// Arrangement is the JSON encoded player IDs of the planned matches, in the form of
+	// [[[side1 pids], [side2 pids]], ...].
+	Arrangement string
+}
+
+// TableName overrides the default plural-form table name.
+func (PlannedRound) TableName() string {
+	return "planned_round"
+}
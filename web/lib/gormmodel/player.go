@@ -12,6 +12,10 @@ type Player struct {
 	Priority     float32
 	InitialScore float32
 	InBreak      bool
+	// Category is one of the player categories defined in pkg/model, e.g. "M" or "F".
+	Category string
+	// CheckInRound is the round in which the player first came off break, 0 if they have not yet.
+	CheckInRound int
 }
 
 // TableName overrides the default plural-form table name.
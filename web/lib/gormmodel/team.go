@@ -0,0 +1,19 @@
+package gormmodel
+
+import (
+	"gorm.io/gorm"
+)
+
+// Team represents a record in the team table, a fixed partnership of two players
+// who always play on the same side.
+type Team struct {
+	gorm.Model
+	Eid  int
+	Pid1 int
+	Pid2 int
+}
+
+// TableName overrides the default plural-form table name.
+func (Team) TableName() string {
+	return "team"
+}
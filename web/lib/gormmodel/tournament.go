@@ -0,0 +1,24 @@
+package gormmodel
+
+import (
+	"gorm.io/gorm"
+)
+
+// Tournament represents a record in the tournament table, an elimination bracket played
+// within an event. An event has at most one tournament.
+type Tournament struct {
+	gorm.Model
+	Eid int
+	// Kind is one of the tournament kinds defined in pkg/tournament, e.g. "single".
+	Kind string
+	// Entrants is the JSON encoded player IDs of the entrants in the order of their seeds,
+	// in the form of [[pid], [pid1, pid2], ...].
+	Entrants string
+	// Bracket is the JSON encoded tournament.Bracket, holding the results reported so far.
+	Bracket string
+}
+
+// TableName overrides the default plural-form table name.
+func (Tournament) TableName() string {
+	return "tournament"
+}
@@ -0,0 +1,54 @@
+package util
+
+import (
+	"log"
+
+	"github.com/yushenli/badminton_match_table/pkg/arranger"
+	"github.com/yushenli/badminton_match_table/pkg/model"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"gorm.io/gorm"
+)
+
+// PopulateConstraints fetches all player constraints under an event.
+func PopulateConstraints(eid int) ([]gormmodel.PlayerConstraint, error) {
+	return PopulateConstraintsIn(config.DB, eid)
+}
+
+// PopulateConstraintsIn is PopulateConstraints reading through the given database handle, e.g. a
+// transaction.
+func PopulateConstraintsIn(db *gorm.DB, eid int) ([]gormmodel.PlayerConstraint, error) {
+	var constraints []gormmodel.PlayerConstraint
+	ret := db.Where("eid = ?", eid).Find(&constraints)
+	if ret.Error != nil {
+		log.Printf("Failed to list constraints under event %d: %v", eid, ret.Error)
+		return nil, ret.Error
+	}
+	return constraints, nil
+}
+
+// ToArrangerConstraints converts the player constraints into arranger.Constraints between the
+// given model.Player objects. Constraints involving players not in players are skipped, as they
+// cannot be broken. Constraints of unknown kinds are skipped with a log.
+func ToArrangerConstraints(constraints []gormmodel.PlayerConstraint, players model.PlayerSlice) arranger.Constraints {
+	playerMap := make(map[int]*model.Player)
+	for idx := range players {
+		playerMap[players[idx].ID] = players[idx]
+	}
+
+	var ret arranger.Constraints
+	for _, constraint := range constraints {
+		kind, err := arranger.ParseConstraintKind(constraint.Kind)
+		if err != nil {
+			log.Printf("Skipping constraint %d: %v", constraint.ID, err)
+			continue
+		}
+		player1, ok1 := playerMap[constraint.Pid1]
+		player2, ok2 := playerMap[constraint.Pid2]
+		if !ok1 || !ok2 {
+			continue
+		}
+		ret = append(ret, arranger.Constraint{Kind: kind, Player1: player1, Player2: player2})
+	}
+	return ret
+}
@@ -0,0 +1,106 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/yushenli/badminton_match_table/pkg/tournament"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"gorm.io/gorm"
+)
+
+// PopulateGroupStage fetches the group stage under an event, or nil if the event does not have one.
+func PopulateGroupStage(eid int) (*gormmodel.GroupStage, error) {
+	var record gormmodel.GroupStage
+	ret := config.DB.Where("eid = ?", eid).First(&record)
+	if errors.Is(ret.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if ret.Error != nil {
+		log.Printf("Failed to fetch the group stage under event %d: %v", eid, ret.Error)
+		return nil, ret.Error
+	}
+	return &record, nil
+}
+
+// DecodeGroupStage decodes the group stage and the player IDs of the entrants of a group stage record.
+func DecodeGroupStage(record gormmodel.GroupStage) (*tournament.GroupStage, [][]int, error) {
+	var stage tournament.GroupStage
+	err := json.Unmarshal([]byte(record.Stage), &stage)
+	if err != nil {
+		return nil, nil, fmt.Errorf("malformed group stage %d: %v", record.ID, err)
+	}
+	var entrants [][]int
+	err = json.Unmarshal([]byte(record.Entrants), &entrants)
+	if err != nil {
+		return nil, nil, fmt.Errorf("malformed entrants of group stage %d: %v", record.ID, err)
+	}
+	if len(entrants) != stage.Entrants {
+		return nil, nil, fmt.Errorf("group stage %d has %d entrants but groups of %d", record.ID, len(entrants), stage.Entrants)
+	}
+	return &stage, entrants, nil
+}
+
+// EncodeGroupStage encodes the group stage and the entrants into a group stage record.
+func EncodeGroupStage(record *gormmodel.GroupStage, stage *tournament.GroupStage, entrants [][]int) error {
+	encodedStage, err := json.Marshal(stage)
+	if err != nil {
+		return err
+	}
+	encodedEntrants, err := json.Marshal(entrants)
+	if err != nil {
+		return err
+	}
+	record.Stage = string(encodedStage)
+	record.Entrants = string(encodedEntrants)
+	return nil
+}
+
+// ReportGroupResults reports the results of the fixtures which have been scheduled as the given
+// matches, with the points of their recorded games. A scheduled fixture whose match no longer
+// exists is unlinked so it can be scheduled again. Returns the number of reported fixtures.
+func ReportGroupResults(stage *tournament.GroupStage, matches []gormmodel.Match) (int, error) {
+	matchMap := make(map[int]*gormmodel.Match)
+	for idx := range matches {
+		matchMap[int(matches[idx].ID)] = &matches[idx]
+	}
+
+	reported := 0
+	for _, fixture := range stage.Fixtures {
+		if fixture.Ref == 0 || fixture.Winner != 0 {
+			continue
+		}
+		match, ok := matchMap[fixture.Ref]
+		if !ok {
+			fixture.Ref = 0
+			continue
+		}
+
+		winner := 0
+		switch match.Status {
+		case gormmodel.SIDE1WON:
+			winner = 1
+		case gormmodel.SIDE2WON:
+			winner = 2
+		}
+		if winner == 0 {
+			continue
+		}
+		points1, points2 := MatchPoints(match)
+		err := stage.Report(fixture.ID, winner, points1, points2)
+		if err != nil {
+			return reported, err
+		}
+		reported++
+	}
+	return reported, nil
+}
+
+// FromFixture converts a fixture into a Match object under gormmodel in the current round of
+// the event, on the given court. The Sides in the Match object will be filled.
+func FromFixture(fixture *tournament.Fixture, entrants [][]int, event gormmodel.Event, court int) gormmodel.Match {
+	return entrantsMatch(entrants[fixture.Entrant1-1], entrants[fixture.Entrant2-1], event, court)
+}
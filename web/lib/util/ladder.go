@@ -0,0 +1,77 @@
+package util
+
+import (
+	"log"
+	"time"
+
+	"github.com/yushenli/badminton_match_table/pkg/ladder"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"gorm.io/gorm"
+)
+
+// PopulateLadderRungs fetches the ranking of a ladder, from the top down.
+func PopulateLadderRungs(lid int) (ladder.Ladder, error) {
+	var rungs []gormmodel.LadderRung
+	ret := config.DB.Where("lid = ?", lid).Order("position").Find(&rungs)
+	if ret.Error != nil {
+		log.Printf("Failed to list the rungs of ladder %d: %v", lid, ret.Error)
+		return nil, ret.Error
+	}
+	var ranking ladder.Ladder
+	for _, rung := range rungs {
+		ranking = append(ranking, rung.Name)
+	}
+	return ranking, nil
+}
+
+// SaveLadderRungs replaces the ranking of a ladder.
+func SaveLadderRungs(tx *gorm.DB, lid int, ranking ladder.Ladder) error {
+	ret := tx.Unscoped().Where("lid = ?", lid).Delete(&gormmodel.LadderRung{})
+	if ret.Error != nil {
+		return ret.Error
+	}
+	if len(ranking) == 0 {
+		return nil
+	}
+	rungs := make([]gormmodel.LadderRung, len(ranking))
+	for idx, name := range ranking {
+		rungs[idx] = gormmodel.LadderRung{Lid: lid, Name: name, Position: idx + 1}
+	}
+	return tx.Create(&rungs).Error
+}
+
+// PopulateChallenges fetches the challenges of a ladder in the given status, the oldest first.
+func PopulateChallenges(lid int, status string) ([]gormmodel.Challenge, error) {
+	var challenges []gormmodel.Challenge
+	ret := config.DB.Where("lid = ?", lid).Where("status = ?", status).Order("created_at, id").Find(&challenges)
+	if ret.Error != nil {
+		log.Printf("Failed to list the %s challenges of ladder %d: %v", status, lid, ret.Error)
+		return nil, ret.Error
+	}
+	return challenges, nil
+}
+
+// LadderRules returns the rules for the challenges of a ladder.
+func LadderRules(record gormmodel.Ladder) ladder.Rules {
+	return ladder.Rules{
+		Reach:  record.Reach,
+		Expiry: time.Duration(record.ExpiryDays) * 24 * time.Hour,
+	}
+}
+
+// ToLadderChallenge converts a challenge under gormmodel into a ladder.Challenge.
+func ToLadderChallenge(challenge gormmodel.Challenge) ladder.Challenge {
+	return ladder.Challenge{
+		Challenger: challenge.Challenger,
+		Defender:   challenge.Defender,
+		Created:    challenge.CreatedAt,
+		Scheduled:  challenge.Mid != 0,
+	}
+}
+
+// ChallengeMatch returns the singles match of a challenge in the current round of the event, on the
+// given court, where the challenger plays on side 1. The Sides in the Match object will be filled.
+func ChallengeMatch(challengerID, defenderID int, event gormmodel.Event, court int) gormmodel.Match {
+	return entrantsMatch([]int{challengerID}, []int{defenderID}, event, court)
+}
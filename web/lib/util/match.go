@@ -2,12 +2,14 @@ package util
 
 import (
 	"github.com/yushenli/badminton_match_table/pkg/model"
+	"github.com/yushenli/badminton_match_table/pkg/scoring"
 	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
 )
 
 // FromArrangerMatchArrangement converts a MatchArrangement provided by the arrenger
 // into Match objects under gormmodel. The Sides in Match objects and Players in Side
-// objects will be filled.
+// objects will be filled. If the event gives handicaps, they are decided by the scores
+// of the players.
 func FromArrangerMatchArrangement(arrangement model.MatchArrangement, event gormmodel.Event) []gormmodel.Match {
 	matches := make([]gormmodel.Match, len(arrangement))
 
@@ -31,7 +33,66 @@ func FromArrangerMatchArrangement(arrangement model.MatchArrangement, event gorm
 			matches[idx].Side1.Pid2 = &arrangerMatch.Side1.Player2.ID
 			matches[idx].Side2.Pid2 = &arrangerMatch.Side2.Player2.ID
 		}
+
+		if event.Handicap {
+			matches[idx].HandicapSide, matches[idx].HandicapPoints = scoring.DefaultHandicapRule.Handicap(
+				averageScore(arrangerMatch.Side1), averageScore(arrangerMatch.Side2))
+		}
 	}
 
 	return matches
 }
+
+// MatchPlayerIDs returns the IDs of all the players in a match. The sides of the match
+// are expected to have been populated.
+func MatchPlayerIDs(match *gormmodel.Match) []int {
+	var ids []int
+	for _, side := range []*gormmodel.Side{match.Side1, match.Side2} {
+		if side == nil {
+			continue
+		}
+		ids = append(ids, side.Pid1)
+		if side.Pid2 != nil {
+			ids = append(ids, *side.Pid2)
+		}
+	}
+	return ids
+}
+
+// averageScore returns the average score of the players of a side.
+func averageScore(side model.Side) float64 {
+	if side.Player2 == nil {
+		return float64(side.Player1.Score)
+	}
+	return float64(side.Player1.Score+side.Player2.Score) / 2
+}
+
+// MatchGames converts the recorded games of a match to scoring.Games.
+func MatchGames(match *gormmodel.Match) scoring.Games {
+	var games scoring.Games
+	for _, game := range match.Games {
+		games = append(games, scoring.Game{Side1: game.Side1Points, Side2: game.Side2Points})
+	}
+	return games
+}
+
+// MatchPoints returns the total points won by each side of a match in play, without the points
+// the handicapped side started every game with.
+func MatchPoints(match *gormmodel.Match) (int, int) {
+	return MatchGames(match).PointsWithoutHandicap(match.HandicapSide, match.HandicapPoints)
+}
+
+// ToGames converts scoring.Games into Game objects of the given match under gormmodel.
+func ToGames(games scoring.Games, match gormmodel.Match) []gormmodel.Game {
+	ret := make([]gormmodel.Game, len(games))
+	for idx, game := range games {
+		ret[idx] = gormmodel.Game{
+			Eid:         match.Eid,
+			Mid:         int(match.ID),
+			Number:      idx + 1,
+			Side1Points: game.Side1,
+			Side2Points: game.Side2,
+		}
+	}
+	return ret
+}
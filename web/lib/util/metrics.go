@@ -0,0 +1,54 @@
+package util
+
+import (
+	"github.com/yushenli/badminton_match_table/pkg/metrics"
+	"github.com/yushenli/badminton_match_table/pkg/rating"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+)
+
+// ComputeMetrics computes the metrics of an event from the matches of each round. The score gap
+// of a match is measured with the scores the players had when the round was arranged, as given
+// by the rating system of the event.
+func ComputeMetrics(event gormmodel.Event, system rating.System, playerMap map[int]*PlayerWithCounter,
+	matchesByRound [][]*gormmodel.Match) metrics.Metrics {
+	ratingsByRound := RatingsByRound(system, playerMap, matchesByRound)
+	sideScore := func(side *gormmodel.Side, ratings map[int]rating.Rating) float64 {
+		score := system.Score(ratings[side.Pid1])
+		if side.Pid2 == nil {
+			return score
+		}
+		return (score + system.Score(ratings[*side.Pid2])) / 2
+	}
+	sidePlayerIDs := func(side *gormmodel.Side) []int {
+		ids := []int{side.Pid1}
+		if side.Pid2 != nil {
+			ids = append(ids, *side.Pid2)
+		}
+		return ids
+	}
+
+	var ids []int
+	for pid := range playerMap {
+		ids = append(ids, pid)
+	}
+
+	var rounds [][]metrics.Match
+	for round, matches := range matchesByRound {
+		var roundMatches []metrics.Match
+		for _, match := range matches {
+			if match.Side1 == nil || match.Side2 == nil {
+				continue
+			}
+			roundMatches = append(roundMatches, metrics.Match{
+				Court:      match.Court,
+				Side1:      sidePlayerIDs(match.Side1),
+				Side2:      sidePlayerIDs(match.Side2),
+				Side1Score: sideScore(match.Side1, ratingsByRound[round]),
+				Side2Score: sideScore(match.Side2, ratingsByRound[round]),
+			})
+		}
+		rounds = append(rounds, roundMatches)
+	}
+
+	return metrics.Compute(ids, rounds, event.Courts)
+}
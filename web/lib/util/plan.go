@@ -0,0 +1,114 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yushenli/badminton_match_table/pkg/arranger"
+	"github.com/yushenli/badminton_match_table/pkg/model"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+)
+
+// PopulatePlannedRounds fetches the planned rounds under an event from the given round on,
+// ordered by round.
+func PopulatePlannedRounds(eid, fromRound int) ([]gormmodel.PlannedRound, error) {
+	var rounds []gormmodel.PlannedRound
+	ret := config.DB.Where("eid = ?", eid).Where("round >= ?", fromRound).Order("round").Find(&rounds)
+	if ret.Error != nil {
+		log.Printf("Failed to list planned rounds under event %d: %v", eid, ret.Error)
+		return nil, ret.Error
+	}
+	return rounds, nil
+}
+
+// ActivePlayersKey returns the sorted, comma separated IDs of the players, to tell whether
+// the same players are available as when a round was planned.
+func ActivePlayersKey(players model.PlayerSlice) string {
+	var ids []int
+	for _, player := range players {
+		ids = append(ids, player.ID)
+	}
+	sort.Ints(ids)
+
+	var sb strings.Builder
+	for idx, id := range ids {
+		if idx > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(strconv.Itoa(id))
+	}
+	return sb.String()
+}
+
+// ToPlannedRounds converts a plan starting from the current round of the event into
+// PlannedRound records, where activeKey is the ActivePlayersKey of the players it's planned for.
+func ToPlannedRounds(plan arranger.Plan, event gormmodel.Event, activeKey string) ([]gormmodel.PlannedRound, error) {
+	var rounds []gormmodel.PlannedRound
+	for idx, arrangement := range plan {
+		var matches [][2][]int
+		for _, match := range arrangement {
+			var ids [2][]int
+			for i, side := range []model.Side{match.Side1, match.Side2} {
+				ids[i] = append(ids[i], side.Player1.ID)
+				if side.Player2 != nil {
+					ids[i] = append(ids[i], side.Player2.ID)
+				}
+			}
+			matches = append(matches, ids)
+		}
+		encoded, err := json.Marshal(matches)
+		if err != nil {
+			return nil, err
+		}
+		rounds = append(rounds, gormmodel.PlannedRound{
+			Eid:         int(event.ID),
+			Round:       event.CurrentRound + idx,
+			Active:      activeKey,
+			Arrangement: string(encoded),
+		})
+	}
+	return rounds, nil
+}
+
+// FromPlannedRound converts a PlannedRound record back into a MatchArrangement of the given players.
+// Returns error if the record is malformed or refers to a player not in players.
+func FromPlannedRound(round gormmodel.PlannedRound, players model.PlayerSlice) (model.MatchArrangement, error) {
+	var matches [][2][]int
+	err := json.Unmarshal([]byte(round.Arrangement), &matches)
+	if err != nil {
+		return nil, fmt.Errorf("malformed planned round %d: %v", round.Round, err)
+	}
+
+	playerMap := make(map[int]*model.Player)
+	for _, player := range players {
+		playerMap[player.ID] = player
+	}
+
+	var arrangement model.MatchArrangement
+	for _, ids := range matches {
+		var sides [2]model.Side
+		for i := range ids {
+			if len(ids[i]) == 0 || len(ids[i]) > 2 {
+				return nil, fmt.Errorf("malformed planned round %d: side of %d players", round.Round, len(ids[i]))
+			}
+			for j, id := range ids[i] {
+				player, ok := playerMap[id]
+				if !ok {
+					return nil, fmt.Errorf("planned round %d refers to unavailable player %d", round.Round, id)
+				}
+				if j == 0 {
+					sides[i].Player1 = player
+				} else {
+					sides[i].Player2 = player
+				}
+			}
+		}
+		arrangement = append(arrangement, model.Match{Side1: sides[0], Side2: sides[1]})
+	}
+	return arrangement, nil
+}
@@ -3,7 +3,10 @@ package util
 import (
 	"log"
 
+	"github.com/yushenli/badminton_match_table/pkg/arranger"
 	"github.com/yushenli/badminton_match_table/pkg/model"
+	"github.com/yushenli/badminton_match_table/pkg/rating"
+	"github.com/yushenli/badminton_match_table/pkg/tournament"
 	"github.com/yushenli/badminton_match_table/web/lib/config"
 	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
 	"gorm.io/gorm"
@@ -16,13 +19,30 @@ type PlayerWithCounter struct {
 	Win   int
 	Loss  int
 	Score float32
+	// PointsWon and PointsLost sum up the recorded game scores of the player's matches.
+	PointsWon  int
+	PointsLost int
+	// Rating is only filled when the event uses a rating system other than the classic one.
+	Rating *rating.Rating
+	// Tiebreaks rank players with the same score, see FillPlayerTiebreaks.
+	Tiebreaks tournament.Tiebreaks
+}
+
+// PointDifference returns the points won minus the points lost by the player.
+func (p PlayerWithCounter) PointDifference() int {
+	return p.PointsWon - p.PointsLost
 }
 
 // PopulatePlayers fetches all sides under an event and put them in a slice as well as a unique-key based map
 func PopulatePlayers(eid int) ([]PlayerWithCounter, map[int]*PlayerWithCounter, error) {
+	return PopulatePlayersIn(config.DB, eid)
+}
+
+// PopulatePlayersIn is PopulatePlayers reading through the given database handle, e.g. a transaction.
+func PopulatePlayersIn(db *gorm.DB, eid int) ([]PlayerWithCounter, map[int]*PlayerWithCounter, error) {
 	var players []PlayerWithCounter
 	playerMap := make(map[int]*PlayerWithCounter)
-	ret := config.DB.Where("eid = ?", eid).Find(&players)
+	ret := db.Where("eid = ?", eid).Find(&players)
 	if ret.Error != nil {
 		log.Printf("Failed to list players under event %d: %v", eid, ret.Error)
 		return nil, nil, ret.Error
@@ -38,13 +58,19 @@ func PopulatePlayers(eid int) ([]PlayerWithCounter, map[int]*PlayerWithCounter,
 // PopulateSides fetches all sides under an event and put them in a slice as well as a unique-key based map
 // The player pointers inside the side objects will point to the players.
 func PopulateSides(eid int, playerMap map[int]*PlayerWithCounter, execludeRound *int) ([]gormmodel.Side, map[int]*gormmodel.Side, error) {
+	return PopulateSidesIn(config.DB, eid, playerMap, execludeRound)
+}
+
+// PopulateSidesIn is PopulateSides reading through the given database handle, e.g. a transaction.
+func PopulateSidesIn(db *gorm.DB, eid int, playerMap map[int]*PlayerWithCounter, execludeRound *int) (
+	[]gormmodel.Side, map[int]*gormmodel.Side, error) {
 	var sides []gormmodel.Side
 	sideMap := make(map[int]*gormmodel.Side)
 	var ret *gorm.DB
 	if execludeRound == nil {
-		ret = config.DB.Where("eid = ?", eid).Find(&sides)
+		ret = db.Where("eid = ?", eid).Find(&sides)
 	} else {
-		ret = config.DB.Joins("JOIN `match` ON `match`.sid1 = side.ID OR `match`.sid2 = side.ID").
+		ret = db.Joins("JOIN `match` ON `match`.sid1 = side.ID OR `match`.sid2 = side.ID").
 			Where("side.eid = ?", eid).Where("round != ?", *execludeRound).Find(&sides)
 	}
 	if ret.Error != nil {
@@ -68,10 +94,17 @@ func PopulateSides(eid int, playerMap map[int]*PlayerWithCounter, execludeRound
 // PopulateMatches fetches all matches under an event and put them in a slice as well as a unique-key based map
 // The side pointers inside the match objects will point to the sides.
 func PopulateMatches(eid, currentRound int, sideMap map[int]*gormmodel.Side) ([]gormmodel.Match, [][]*gormmodel.Match, error) {
+	return PopulateMatchesIn(config.DB, eid, currentRound, sideMap)
+}
+
+// PopulateMatchesIn is PopulateMatches reading through the given database handle, e.g. a transaction.
+func PopulateMatchesIn(db *gorm.DB, eid, currentRound int, sideMap map[int]*gormmodel.Side) (
+	[]gormmodel.Match, [][]*gormmodel.Match, error) {
 	var matches []gormmodel.Match
 	matchesByRound := make([][]*gormmodel.Match, currentRound)
 
-	ret := config.DB.Where("eid = ?", eid).Order("round").Order("court").Find(&matches)
+	ret := db.Where("eid = ?", eid).Order("round").Order("court").
+		Preload("Games", func(db *gorm.DB) *gorm.DB { return db.Order("number") }).Find(&matches)
 	if ret.Error != nil {
 		log.Printf("Failed to list matches under event %d: %v", eid, ret.Error)
 		return nil, nil, ret.Error
@@ -124,6 +157,134 @@ func FillPlayerCounter(playerMap map[int]*PlayerWithCounter, sides []gormmodel.S
 	}
 }
 
+// RatingsByRound computes the ratings of the given players with the rating system, by replaying
+// the completed matches round by round starting from the initial scores. The i-th element of the
+// returned slice holds the ratings before the (i+1)-th round, and the last element holds the
+// ratings after all the rounds.
+func RatingsByRound(system rating.System, playerMap map[int]*PlayerWithCounter, matchesByRound [][]*gormmodel.Match) []map[int]rating.Rating {
+	ratings := make(map[int]rating.Rating)
+	for pid, player := range playerMap {
+		ratings[pid] = system.Initial(float64(player.InitialScore))
+	}
+
+	sidePlayerIDs := func(side *gormmodel.Side) []int {
+		var ids []int
+		if _, ok := playerMap[side.Pid1]; ok {
+			ids = append(ids, side.Pid1)
+		}
+		if side.Pid2 == nil {
+			return ids
+		}
+		if _, ok := playerMap[*side.Pid2]; ok {
+			ids = append(ids, *side.Pid2)
+		}
+		return ids
+	}
+
+	ret := []map[int]rating.Rating{ratings}
+	for _, matches := range matchesByRound {
+		var results []rating.Match
+		for _, match := range matches {
+			// Sides of rounds not populated, or not completed yet, are skipped.
+			if match.Side1 == nil || match.Side2 == nil || match.Side1.Score == match.Side2.Score {
+				continue
+			}
+			side1, side2 := sidePlayerIDs(match.Side1), sidePlayerIDs(match.Side2)
+			if len(side1) == 0 || len(side2) == 0 {
+				continue
+			}
+			points1, points2 := MatchPoints(match)
+			results = append(results, rating.Match{Side1: side1, Side2: side2, Side1Won: match.Side1.Score > match.Side2.Score,
+				Side1Points: points1, Side2Points: points2})
+		}
+		if len(results) > 0 {
+			ratings = system.Update(ratings, results)
+		}
+		ret = append(ret, ratings)
+	}
+	return ret
+}
+
+// FillPlayerRatings replaces the scores of the given players with the ratings computed by the
+// rating system, see RatingsByRound. It should be called after FillPlayerCounter, which still
+// counts the games.
+func FillPlayerRatings(system rating.System, playerMap map[int]*PlayerWithCounter, matchesByRound [][]*gormmodel.Match) {
+	ratingsByRound := RatingsByRound(system, playerMap, matchesByRound)
+	ratings := ratingsByRound[len(ratingsByRound)-1]
+	for pid, player := range playerMap {
+		r := ratings[pid]
+		player.Rating = &r
+		player.Score = float32(system.Score(r))
+	}
+}
+
+// FillPlayerPoints calculates the points won and lost by the given players from the recorded
+// game scores of the matches.
+func FillPlayerPoints(playerMap map[int]*PlayerWithCounter, matches []gormmodel.Match) {
+	addPoints := func(side *gormmodel.Side, won, lost int) {
+		if player, ok := playerMap[side.Pid1]; ok {
+			player.PointsWon += won
+			player.PointsLost += lost
+		}
+		if side.Pid2 == nil {
+			return
+		}
+		if player, ok := playerMap[*side.Pid2]; ok {
+			player.PointsWon += won
+			player.PointsLost += lost
+		}
+	}
+
+	for idx := range matches {
+		if matches[idx].Side1 == nil || matches[idx].Side2 == nil {
+			continue
+		}
+		points1, points2 := MatchPoints(&matches[idx])
+		addPoints(matches[idx].Side1, points1, points2)
+		addPoints(matches[idx].Side2, points2, points1)
+	}
+}
+
+// FillPlayerTiebreaks calculates the Swiss-system tiebreaks of the given players from their
+// scores and the decided matches. The scores are expected to be final, i.e. counters and
+// ratings have been filled.
+func FillPlayerTiebreaks(playerMap map[int]*PlayerWithCounter, matches []gormmodel.Match) {
+	scores := make(map[int]float64)
+	for pid, player := range playerMap {
+		scores[pid] = float64(player.Score)
+	}
+
+	var results []tournament.Result
+	for idx := range matches {
+		match := &matches[idx]
+		if match.Side1 == nil || match.Side2 == nil {
+			continue
+		}
+		winner := 0
+		switch match.Status {
+		case gormmodel.SIDE1WON:
+			winner = 1
+		case gormmodel.SIDE2WON:
+			winner = 2
+		}
+		if winner == 0 {
+			continue
+		}
+		result := tournament.Result{Side1: []int{match.Side1.Pid1}, Side2: []int{match.Side2.Pid1}, Winner: winner}
+		if match.Side1.Pid2 != nil {
+			result.Side1 = append(result.Side1, *match.Side1.Pid2)
+		}
+		if match.Side2.Pid2 != nil {
+			result.Side2 = append(result.Side2, *match.Side2.Pid2)
+		}
+		results = append(results, result)
+	}
+
+	for pid, tiebreaks := range tournament.ComputeTiebreaks(scores, results) {
+		playerMap[pid].Tiebreaks = tiebreaks
+	}
+}
+
 // FilterActivePlayers returns a slice of pointers to only the ones not in break in the given players slice.
 func FilterActivePlayers(players []PlayerWithCounter) []*PlayerWithCounter {
 	var keptPlayers []*PlayerWithCounter
@@ -147,7 +308,9 @@ func ToArrangerPlayers(players []*PlayerWithCounter) model.PlayerSlice {
 			Priority:  player.Priority,
 			Score:     player.Score,
 			Matches:   float32(player.Games),
+			Partners:  make(map[*model.Player]int),
 			Opponents: make(map[*model.Player]int),
+			Category:  player.Category,
 		})
 	}
 	return ret
@@ -164,37 +327,167 @@ func ToArrangerPlayersP(players []PlayerWithCounter) model.PlayerSlice {
 			Priority:  player.Priority,
 			Score:     player.Score,
 			Matches:   float32(player.Games),
+			Partners:  make(map[*model.Player]int),
 			Opponents: make(map[*model.Player]int),
+			Category:  player.Category,
 		})
 	}
 	return ret
 }
 
-// FillArrangerPlayersOpponents populates the Opponents field for all model.Player objects
-// using their match history in the sides data.
-func FillArrangerPlayersOpponents(players model.PlayerSlice, sides []gormmodel.Side) {
+// FillArrangerPlayersHistory populates the Partners and Opponents fields for all model.Player objects
+// using their match history in the sides data. Sides belonging to the same match are opponents.
+func FillArrangerPlayersHistory(players model.PlayerSlice, sides []gormmodel.Side) {
 	playerMap := make(map[int]*model.Player)
 	for idx := range players {
 		playerMap[players[idx].ID] = players[idx]
 	}
 
-	for _, side := range sides {
+	// Players may be in their breaks and won't exist in the above map.
+	// For separation purpose, there is no need to include those players in break.
+	sidePlayers := func(side gormmodel.Side) []*model.Player {
+		var ret []*model.Player
+		if player, ok := playerMap[side.Pid1]; ok {
+			ret = append(ret, player)
+		}
 		if side.Pid2 == nil {
-			continue
+			return ret
+		}
+		if player, ok := playerMap[*side.Pid2]; ok {
+			ret = append(ret, player)
 		}
+		return ret
+	}
+
+	sidesByMatch := make(map[int][]gormmodel.Side)
+	for _, side := range sides {
+		sidesByMatch[side.Mid] = append(sidesByMatch[side.Mid], side)
+
+		players := sidePlayers(side)
+		if len(players) == 2 {
+			players[0].Partners[players[1]]++
+			players[1].Partners[players[0]]++
+		}
+	}
 
-		// Opponents may be in their breaks and won't exist in the above map.
-		// For separation purpose, there is no need to include those players in break.
-		if _, ok := playerMap[side.Pid1]; !ok {
+	for _, matchSides := range sidesByMatch {
+		if len(matchSides) != 2 {
 			continue
 		}
-		if _, ok := playerMap[*side.Pid2]; !ok {
+		for _, player1 := range sidePlayers(matchSides[0]) {
+			for _, player2 := range sidePlayers(matchSides[1]) {
+				player1.Opponents[player2]++
+				player2.Opponents[player1]++
+			}
+		}
+	}
+}
+
+// FillArrangerPlayersRoundsWaited populates the RoundsWaited and RoundsSatOut fields for all model.Player
+// objects, by going through the rounds before currentRound. RoundsWaited counts the rounds since the last
+// round in which each player played, and RoundsSatOut counts the rounds each player did not play since
+// their first match.
+func FillArrangerPlayersRoundsWaited(players model.PlayerSlice, matchesByRound [][]*gormmodel.Match, currentRound int) {
+	for _, player := range players {
+		player.RoundsWaited = 0
+		player.RoundsSatOut = 0
+	}
+
+	hasPlayed := make(map[int]bool)
+	for round := 1; round < currentRound; round++ {
+		played := make(map[int]bool)
+		if round <= len(matchesByRound) {
+			for _, match := range matchesByRound[round-1] {
+				for _, pid := range MatchPlayerIDs(match) {
+					played[pid] = true
+				}
+			}
+		}
+		for _, player := range players {
+			if played[player.ID] {
+				hasPlayed[player.ID] = true
+				player.RoundsWaited = 0
+				continue
+			}
+			player.RoundsWaited++
+			if hasPlayed[player.ID] {
+				player.RoundsSatOut++
+			}
+		}
+	}
+}
+
+// FillArrangerPlayersCatchUp adds to the Matches field of the model.Player objects who checked in
+// after the first round the games credited by the catch-up policy. The field they are compared
+// with consists of all the given players, whose check-in round is taken as the first round they
+// played if it was not recorded.
+func FillArrangerPlayersCatchUp(policy arranger.CatchUpPolicy, arrangerPlayers model.PlayerSlice,
+	players []PlayerWithCounter, matchesByRound [][]*gormmodel.Match) {
+	if policy == arranger.CatchUpNone {
+		return
+	}
+
+	playedByRound := make([]map[int]bool, len(matchesByRound))
+	for idx, matches := range matchesByRound {
+		playedByRound[idx] = make(map[int]bool)
+		for _, match := range matches {
+			for _, pid := range MatchPlayerIDs(match) {
+				playedByRound[idx][pid] = true
+			}
+		}
+	}
+
+	checkInRounds := make(map[int]int)
+	for _, player := range players {
+		checkInRound := player.CheckInRound
+		for round := 1; checkInRound == 0 && round <= len(playedByRound); round++ {
+			if playedByRound[round-1][int(player.ID)] {
+				checkInRound = round
+			}
+		}
+		if checkInRound > 0 {
+			checkInRounds[int(player.ID)] = checkInRound
+		}
+	}
+
+	for _, arrangerPlayer := range arrangerPlayers {
+		var checkInRound int
+		for _, player := range players {
+			if int(player.ID) == arrangerPlayer.ID {
+				checkInRound = player.CheckInRound
+			}
+		}
+		if checkInRound <= 1 {
 			continue
 		}
 
-		playerMap[side.Pid1].Opponents[playerMap[*side.Pid2]] =
-			playerMap[side.Pid1].Opponents[playerMap[*side.Pid2]] + 1
-		playerMap[*side.Pid2].Opponents[playerMap[side.Pid1]] =
-			playerMap[*side.Pid2].Opponents[playerMap[side.Pid1]] + 1
+		var fieldGames []float32
+		for pid, fieldCheckInRound := range checkInRounds {
+			if pid == arrangerPlayer.ID || fieldCheckInRound >= checkInRound {
+				continue
+			}
+			var games float32
+			for round := 1; round < checkInRound && round <= len(playedByRound); round++ {
+				if playedByRound[round-1][pid] {
+					games++
+				}
+			}
+			fieldGames = append(fieldGames, games)
+		}
+
+		var playShares []float32
+		for round := 1; round < checkInRound && round <= len(playedByRound); round++ {
+			checkedIn := 0
+			for _, fieldCheckInRound := range checkInRounds {
+				if fieldCheckInRound <= round {
+					checkedIn++
+				}
+			}
+			if checkedIn > 0 {
+				playShares = append(playShares, float32(len(playedByRound[round-1]))/float32(checkedIn))
+			}
+		}
+
+		arrangerPlayer.Matches += policy.Credit(fieldGames, playShares)
 	}
 }
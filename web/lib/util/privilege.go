@@ -8,17 +8,28 @@ import (
 const (
 	// AdminCookieKey is the name of the cookie field that stores the admin key
 	AdminCookieKey = "admin"
+	// AdminHeaderKey is the name of the HTTP header that carries the admin key for API clients,
+	// which takes precedence over the cookie.
+	AdminHeaderKey = "X-Admin-Key"
 )
 
 // HasAdminPrivilege returns if the current visitor has admin privilege to the given event.
 func HasAdminPrivilege(ctx *gin.Context, event gormmodel.Event) bool {
-	currentCookie, err := ctx.Request.Cookie(AdminCookieKey)
-	if currentCookie == nil || err != nil {
-		// No admin cookie set, no admin privilege.
+	adminKey := GetAdminKey(ctx)
+	if adminKey == "" {
+		// No admin key given, no admin privilege.
 		return false
 	}
 
-	return event.AdminKey != "" && currentCookie.Value == event.AdminKey
+	return event.AdminKey != "" && adminKey == event.AdminKey
+}
+
+// GetAdminKey returns the admin key given in the admin header, or else in the admin cookie.
+func GetAdminKey(ctx *gin.Context) string {
+	if adminKey := ctx.GetHeader(AdminHeaderKey); adminKey != "" {
+		return adminKey
+	}
+	return GetAdminCookie(ctx)
 }
 
 // GetAdminCookie returns the value of the admin cookie
@@ -31,3 +42,9 @@ func GetAdminCookie(ctx *gin.Context) string {
 
 	return adminCookie
 }
+
+// HasLadderAdminPrivilege returns if the current visitor has admin privilege to the given ladder.
+func HasLadderAdminPrivilege(ctx *gin.Context, record gormmodel.Ladder) bool {
+	adminKey := GetAdminKey(ctx)
+	return record.AdminKey != "" && adminKey == record.AdminKey
+}
@@ -0,0 +1,129 @@
+package util
+
+import (
+	"log"
+	"sort"
+
+	"github.com/yushenli/badminton_match_table/pkg/rotation"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"gorm.io/gorm"
+)
+
+// PopulateCourtStates fetches the states of the courts under an event, keyed by court.
+func PopulateCourtStates(eid int) (map[int]*gormmodel.CourtState, error) {
+	var states []gormmodel.CourtState
+	ret := config.DB.Where("eid = ?", eid).Find(&states)
+	if ret.Error != nil {
+		log.Printf("Failed to list court states under event %d: %v", eid, ret.Error)
+		return nil, ret.Error
+	}
+	stateMap := make(map[int]*gormmodel.CourtState)
+	for idx := range states {
+		stateMap[states[idx].Court] = &states[idx]
+	}
+	return stateMap, nil
+}
+
+// PopulateQueue fetches the IDs of the players waiting for a court under an event, in the
+// order of the queue.
+func PopulateQueue(eid int) ([]int, error) {
+	return PopulateQueueIn(config.DB, eid)
+}
+
+// PopulateQueueIn is PopulateQueue reading through the given database handle, e.g. a transaction.
+func PopulateQueueIn(db *gorm.DB, eid int) ([]int, error) {
+	var queued []gormmodel.QueuedPlayer
+	ret := db.Where("eid = ?", eid).Order("position").Find(&queued)
+	if ret.Error != nil {
+		log.Printf("Failed to list the waiting queue under event %d: %v", eid, ret.Error)
+		return nil, ret.Error
+	}
+	var queue []int
+	for _, entry := range queued {
+		queue = append(queue, entry.Pid)
+	}
+	return queue, nil
+}
+
+// SaveQueue replaces the waiting queue of an event with the given player IDs.
+func SaveQueue(tx *gorm.DB, eid int, queue []int) error {
+	ret := tx.Unscoped().Where("eid = ?", eid).Delete(&gormmodel.QueuedPlayer{})
+	if ret.Error != nil {
+		return ret.Error
+	}
+	if len(queue) == 0 {
+		return nil
+	}
+	entries := make([]gormmodel.QueuedPlayer, len(queue))
+	for idx, pid := range queue {
+		entries[idx] = gormmodel.QueuedPlayer{Eid: eid, Pid: pid, Position: idx}
+	}
+	return tx.Create(&entries).Error
+}
+
+// SyncQueue returns the waiting queue without the players who are in break, unknown or playing,
+// and with the available players who are neither waiting nor playing joined at the back, those
+// with higher priority first.
+func SyncQueue(queue []int, playerMap map[int]*PlayerWithCounter, playing map[int]bool) []int {
+	var ret []int
+	queued := make(map[int]bool)
+	for _, pid := range queue {
+		player, ok := playerMap[pid]
+		if !ok || player.InBreak || playing[pid] || queued[pid] {
+			continue
+		}
+		ret = append(ret, pid)
+		queued[pid] = true
+	}
+
+	var joining []*PlayerWithCounter
+	for pid, player := range playerMap {
+		if !player.InBreak && !playing[pid] && !queued[pid] {
+			joining = append(joining, player)
+		}
+	}
+	sort.Slice(joining, func(i, j int) bool {
+		if joining[i].Priority != joining[j].Priority {
+			return joining[i].Priority > joining[j].Priority
+		}
+		return joining[i].ID < joining[j].ID
+	})
+	for _, player := range joining {
+		ret = append(ret, int(player.ID))
+	}
+	return ret
+}
+
+// ToRotationMatch converts a match, whose sides are expected to have been populated, into a
+// rotation.Match.
+func ToRotationMatch(match *gormmodel.Match) rotation.Match {
+	ret := rotation.Match{}
+	for idx, side := range []*gormmodel.Side{match.Side1, match.Side2} {
+		var ids []int
+		if side != nil {
+			ids = append(ids, side.Pid1)
+			if side.Pid2 != nil {
+				ids = append(ids, *side.Pid2)
+			}
+		}
+		if idx == 0 {
+			ret.Side1 = ids
+		} else {
+			ret.Side2 = ids
+		}
+	}
+	switch match.Status {
+	case gormmodel.SIDE1WON:
+		ret.Winner = 1
+	case gormmodel.SIDE2WON:
+		ret.Winner = 2
+	}
+	return ret
+}
+
+// FromRotationMatch converts a rotation.Match into a Match object under gormmodel in the current
+// round of the event, on the given court. The Sides in the Match object will be filled.
+func FromRotationMatch(match rotation.Match, event gormmodel.Event, court int) gormmodel.Match {
+	return entrantsMatch(match.Side1, match.Side2, event, court)
+}
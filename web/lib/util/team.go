@@ -0,0 +1,116 @@
+package util
+
+import (
+	"log"
+	"sort"
+
+	"github.com/yushenli/badminton_match_table/pkg/model"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"gorm.io/gorm"
+)
+
+// TeamWithCounter is a gormmodel.Team embedded with its players, games counters and a score.
+// Only the matches the two players played together as a side are counted.
+type TeamWithCounter struct {
+	gormmodel.Team
+	Player1 *PlayerWithCounter
+	Player2 *PlayerWithCounter
+	Games   int
+	Win     int
+	Loss    int
+	Score   float32
+}
+
+// PopulateTeams fetches all teams under an event.
+func PopulateTeams(eid int) ([]gormmodel.Team, error) {
+	return PopulateTeamsIn(config.DB, eid)
+}
+
+// PopulateTeamsIn is PopulateTeams reading through the given database handle, e.g. a transaction.
+func PopulateTeamsIn(db *gorm.DB, eid int) ([]gormmodel.Team, error) {
+	var teams []gormmodel.Team
+	ret := db.Where("eid = ?", eid).Find(&teams)
+	if ret.Error != nil {
+		log.Printf("Failed to list teams under event %d: %v", eid, ret.Error)
+		return nil, ret.Error
+	}
+	return teams, nil
+}
+
+// TeammateIDs returns a map from the ID of each player in the teams to the ID of their teammate.
+func TeammateIDs(teams []gormmodel.Team) map[int]int {
+	ret := make(map[int]int)
+	for _, team := range teams {
+		ret[team.Pid1] = team.Pid2
+		ret[team.Pid2] = team.Pid1
+	}
+	return ret
+}
+
+// FillArrangerPlayersTeams links the model.Player objects into model.Team objects. Teams whose
+// players are not both in players are skipped, so the present player is arranged on their own.
+func FillArrangerPlayersTeams(players model.PlayerSlice, teams []gormmodel.Team) {
+	playerMap := make(map[int]*model.Player)
+	for idx := range players {
+		playerMap[players[idx].ID] = players[idx]
+	}
+
+	for _, team := range teams {
+		player1, ok1 := playerMap[team.Pid1]
+		player2, ok2 := playerMap[team.Pid2]
+		if !ok1 || !ok2 {
+			continue
+		}
+		model.NewTeam(int(team.ID), player1, player2)
+	}
+}
+
+// FillTeamCounter calculates the game counter and scores for given teams using sides data.
+// A team starts with the average initial score of its players. The returned teams are sorted
+// by score.
+func FillTeamCounter(teams []gormmodel.Team, playerMap map[int]*PlayerWithCounter, sides []gormmodel.Side) []*TeamWithCounter {
+	var ret []*TeamWithCounter
+	for _, team := range teams {
+		player1, ok1 := playerMap[team.Pid1]
+		player2, ok2 := playerMap[team.Pid2]
+		if !ok1 || !ok2 {
+			continue
+		}
+
+		teamWithCounter := &TeamWithCounter{
+			Team:    team,
+			Player1: player1,
+			Player2: player2,
+			Score:   (player1.InitialScore + player2.InitialScore) / 2,
+		}
+		for _, side := range sides {
+			if side.Pid2 == nil {
+				continue
+			}
+			if !(side.Pid1 == team.Pid1 && *side.Pid2 == team.Pid2) &&
+				!(side.Pid1 == team.Pid2 && *side.Pid2 == team.Pid1) {
+				continue
+			}
+			teamWithCounter.Score += side.Score
+			if side.Score > 0 {
+				teamWithCounter.Games++
+				teamWithCounter.Win++
+			}
+			if side.Score < 0 {
+				teamWithCounter.Games++
+				teamWithCounter.Loss++
+			}
+		}
+		if player1.Rating != nil && player2.Rating != nil {
+			// Under a rating system the scores of the players are their ratings.
+			teamWithCounter.Score = (player1.Score + player2.Score) / 2
+		}
+		ret = append(ret, teamWithCounter)
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Score > ret[j].Score
+	})
+	return ret
+}
@@ -0,0 +1,152 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/yushenli/badminton_match_table/pkg/tournament"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"gorm.io/gorm"
+)
+
+// PopulateTournament fetches the tournament under an event, or nil if the event does not have one.
+func PopulateTournament(eid int) (*gormmodel.Tournament, error) {
+	var record gormmodel.Tournament
+	ret := config.DB.Where("eid = ?", eid).First(&record)
+	if errors.Is(ret.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if ret.Error != nil {
+		log.Printf("Failed to fetch the tournament under event %d: %v", eid, ret.Error)
+		return nil, ret.Error
+	}
+	return &record, nil
+}
+
+// DecodeTournament decodes the bracket and the player IDs of the entrants of a tournament record.
+func DecodeTournament(record gormmodel.Tournament) (*tournament.Bracket, [][]int, error) {
+	var bracket tournament.Bracket
+	err := json.Unmarshal([]byte(record.Bracket), &bracket)
+	if err != nil {
+		return nil, nil, fmt.Errorf("malformed bracket of tournament %d: %v", record.ID, err)
+	}
+	var entrants [][]int
+	err = json.Unmarshal([]byte(record.Entrants), &entrants)
+	if err != nil {
+		return nil, nil, fmt.Errorf("malformed entrants of tournament %d: %v", record.ID, err)
+	}
+	if len(entrants) != bracket.Entrants {
+		return nil, nil, fmt.Errorf("tournament %d has %d entrants but a bracket of %d", record.ID, len(entrants), bracket.Entrants)
+	}
+	return &bracket, entrants, nil
+}
+
+// EncodeTournament encodes the bracket and the entrants into a tournament record.
+func EncodeTournament(record *gormmodel.Tournament, bracket *tournament.Bracket, entrants [][]int) error {
+	encodedBracket, err := json.Marshal(bracket)
+	if err != nil {
+		return err
+	}
+	encodedEntrants, err := json.Marshal(entrants)
+	if err != nil {
+		return err
+	}
+	record.Kind = string(bracket.Kind)
+	record.Bracket = string(encodedBracket)
+	record.Entrants = string(encodedEntrants)
+	return nil
+}
+
+// EntrantNames returns the names of the entrants in the order of their seeds, the names of
+// a pair joined by " / ".
+func EntrantNames(entrants [][]int, playerMap map[int]*PlayerWithCounter) []string {
+	names := make([]string, len(entrants))
+	for idx, ids := range entrants {
+		var parts []string
+		for _, id := range ids {
+			if player, ok := playerMap[id]; ok {
+				parts = append(parts, player.Name)
+			} else {
+				parts = append(parts, fmt.Sprintf("#%d", id))
+			}
+		}
+		names[idx] = strings.Join(parts, " / ")
+	}
+	return names
+}
+
+// ReportBracketResults reports the results of the bracket matches which have been scheduled as
+// the given matches. A scheduled bracket match whose match no longer exists, e.g. the round has
+// been re-scheduled, is unlinked so it can be scheduled again. Returns the number of reported matches.
+func ReportBracketResults(bracket *tournament.Bracket, matches []gormmodel.Match) (int, error) {
+	matchMap := make(map[int]*gormmodel.Match)
+	for idx := range matches {
+		matchMap[int(matches[idx].ID)] = &matches[idx]
+	}
+
+	reported := 0
+	for _, bracketMatch := range bracket.Matches {
+		if bracketMatch.Ref == 0 || !bracketMatch.Ready() {
+			continue
+		}
+		match, ok := matchMap[bracketMatch.Ref]
+		if !ok {
+			bracketMatch.Ref = 0
+			continue
+		}
+
+		winner := 0
+		switch match.Status {
+		case gormmodel.SIDE1WON:
+			winner = 1
+		case gormmodel.SIDE2WON:
+			winner = 2
+		}
+		if winner == 0 {
+			continue
+		}
+		err := bracket.Report(bracketMatch.ID, winner)
+		if err != nil {
+			return reported, err
+		}
+		reported++
+	}
+	return reported, nil
+}
+
+// FromBracketMatch converts a bracket match ready to be played into a Match object under gormmodel
+// in the current round of the event, on the given court. The Sides in the Match object will be filled.
+func FromBracketMatch(bracketMatch *tournament.Match, entrants [][]int, event gormmodel.Event, court int) gormmodel.Match {
+	return entrantsMatch(entrants[bracketMatch.Entrant1-1], entrants[bracketMatch.Entrant2-1], event, court)
+}
+
+// entrantsMatch returns a Match object under gormmodel between the given entrants, who are one
+// or two players each, in the current round of the event on the given court.
+func entrantsMatch(ids1, ids2 []int, event gormmodel.Event, court int) gormmodel.Match {
+	match := gormmodel.Match{
+		Eid:    int(event.ID),
+		Round:  event.CurrentRound,
+		Court:  court,
+		Status: gormmodel.PLAYING,
+	}
+	for idx, ids := range [][]int{ids1, ids2} {
+		side := &gormmodel.Side{
+			Eid:  int(event.ID),
+			Pid1: ids[0],
+		}
+		if len(ids) > 1 {
+			pid2 := ids[1]
+			side.Pid2 = &pid2
+		}
+		if idx == 0 {
+			match.Side1 = side
+		} else {
+			match.Side2 = side
+		}
+	}
+	return match
+}
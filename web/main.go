@@ -15,6 +15,7 @@ import (
 
 var staticDirFlag = flag.String("static_dir", "./static", "The root directory which contains all the static files")
 var templatesDirFlag = flag.String("templates_dir", "./templates", "The root directory which contains all the template files")
+var apiKeyFlag = flag.String("api_key", "", "The key API clients must give in the X-Admin-Key header to create events, which is disabled if empty")
 
 func setupRouter() *gin.Engine {
 	r := gin.Default()
@@ -27,13 +28,53 @@ func setupRouter() *gin.Engine {
 	r.GET("/rules.html", controller.RenderRules)
 	r.GET("/event/today", controller.RedirctToToday)
 	r.GET("/event/:key", controller.RenderEvent)
+	r.GET("/event/:key/stats", controller.RenderEventStats)
+	r.GET("/event/:key/bracket", controller.RenderEventBracket)
+	r.GET("/event/:key/groups", controller.RenderEventGroups)
+	r.GET("/ladder/:key", controller.RenderLadder)
 	r.GET("/admin/change_match_status", controller.ChangeMatchStatus)
 	r.GET("/admin/change_break_status", controller.ChangeBreakStatus)
 	r.GET("/admin/complete_round", controller.CompleteRound)
 	r.GET("/admin/schedule", controller.ScheduleCurrentRound)
+	r.GET("/admin/plan", controller.PlanRounds)
 	r.GET("/admin/cookie", controller.SetAdminCookie)
 	r.GET("/admin/players/:eid", controller.PlayersForm)
 	r.POST("/admin/players/:eid", controller.PlayersSubmit)
+	r.GET("/admin/event/:eid", controller.EventSettingsForm)
+	r.POST("/admin/event/:eid", controller.EventSettingsSubmit)
+	r.GET("/admin/constraints/:eid", controller.ConstraintsForm)
+	r.POST("/admin/constraints/:eid", controller.ConstraintsSubmit)
+	r.GET("/admin/scores/:eid", controller.ScoresForm)
+	r.POST("/admin/scores/:eid", controller.ScoresSubmit)
+	r.GET("/admin/tournament/:eid", controller.TournamentForm)
+	r.POST("/admin/tournament/:eid", controller.TournamentSubmit)
+	r.GET("/admin/tournament/:eid/advance", controller.AdvanceTournament)
+	r.GET("/admin/groups/:eid", controller.GroupsForm)
+	r.POST("/admin/groups/:eid", controller.GroupsSubmit)
+	r.GET("/admin/groups/:eid/advance", controller.AdvanceGroups)
+	r.GET("/admin/stay_on/:eid", controller.StayOnCourts)
+	r.GET("/admin/continuous/:eid", controller.ContinuousCourts)
+	r.GET("/admin/ladder/:lid", controller.LadderForm)
+	r.POST("/admin/ladder/:lid", controller.LadderSubmit)
+	r.POST("/admin/ladder/:lid/challenge", controller.ChallengeSubmit)
+	r.GET("/admin/ladder/:lid/challenge/:cid/schedule", controller.ScheduleChallenge)
+	r.GET("/admin/ladder/:lid/settle", controller.SettleLadder)
+
+	r.NoRoute(controller.RenderNotFound)
+
+	api := r.Group("/api/v1")
+	api.GET("/events", controller.APIListEvents)
+	api.POST("/events", controller.APICreateEvent)
+	api.GET("/events/:eid", controller.APIGetEvent)
+	api.GET("/events/:eid/players", controller.APIListPlayers)
+	api.POST("/events/:eid/players", controller.APICreatePlayer)
+	api.PATCH("/events/:eid/players/:pid", controller.APIUpdatePlayer)
+	api.GET("/events/:eid/rounds", controller.APIListRounds)
+	api.GET("/events/:eid/rounds/:round", controller.APIGetRound)
+	api.POST("/events/:eid/rounds/:round/schedule", controller.APIScheduleRound)
+	api.POST("/events/:eid/rounds/:round/complete", controller.APICompleteRound)
+	api.GET("/events/:eid/standings", controller.APIStandings)
+	api.PUT("/events/:eid/matches/:mid/result", controller.APIReportResult)
 
 	staticFiles := []string{}
 	for _, staticFile := range staticFiles {
@@ -54,6 +95,7 @@ func setupRouter() *gin.Engine {
 func main() {
 	var err error
 	flag.Parse()
+	config.APIKey = *apiKeyFlag
 
 	config.DB, err = gorm.Open(
 		mysql.Open("badminton:badminton@tcp(127.0.0.1:3306)/badminton?charset=utf8&parseTime=True&loc=Local"),
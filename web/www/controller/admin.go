@@ -10,6 +10,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/yushenli/badminton_match_table/pkg/arranger"
+	"github.com/yushenli/badminton_match_table/pkg/rotation"
 	"github.com/yushenli/badminton_match_table/web/lib/config"
 	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
 	"github.com/yushenli/badminton_match_table/web/lib/util"
@@ -52,6 +53,21 @@ func ChangeMatchStatus(ctx *gin.Context) {
 			fmt.Sprintf("Failed to locate the match by mid %d: %v", mid, ret.Error))
 	}
 
+	var event gormmodel.Event
+	ret = config.DB.First(&event, match.Eid)
+	if ret.Error != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to locate the event by eid %d: %v", match.Eid, ret.Error))
+		return
+	}
+	// Reporting a result advances the court under winners stay on and continuous scheduling,
+	// which only the admin may do.
+	if rotation.Mode(event.Mode) != rotation.Rounds && !util.HasAdminPrivilege(ctx, event) {
+		RenderError(ctx, http.StatusForbidden,
+			fmt.Sprintf("You do not have admin privilege to event %d", event.ID))
+		return
+	}
+
 	switch sideStr {
 	case "1":
 		match.Status = gormmodel.SIDE1WON
@@ -64,7 +80,34 @@ func ChangeMatchStatus(ctx *gin.Context) {
 	if ret.Error != nil {
 		RenderError(ctx, http.StatusBadRequest,
 			fmt.Sprintf("Failed to update the match by mid %d to status %s: %v", mid, sideStr, ret.Error))
+		return
 	}
+
+	note, ok := advanceCourt(ctx, event, match)
+	if !ok {
+		return
+	}
+	ctx.Writer.WriteString(note)
+}
+
+// advanceCourt produces the next match on the court of a match whose status has just been changed,
+// when its event runs winners stay on or continuous scheduling. The caller must have checked the
+// visitor has admin privilege to the event. Returns a note of what has been done, and false if an
+// error page has been rendered.
+func advanceCourt(ctx *gin.Context, event gormmodel.Event, match gormmodel.Match) (string, bool) {
+	switch rotation.Mode(event.Mode) {
+	case rotation.WinnersStayOn:
+		note, err := advanceStayOn(event, match)
+		if err != nil {
+			log.Printf("Failed when advancing court %d of event %d: %v", match.Court, event.ID, err)
+			RenderError(ctx, http.StatusInternalServerError, "Failed when advancing the court")
+			return "", false
+		}
+		return note, true
+	case rotation.Continuous:
+		return advanceContinuous(ctx, &event, match)
+	}
+	return "", true
 }
 
 // ChangeBreakStatus handles the reuqest to change whether a player is in a break.
@@ -101,18 +144,58 @@ func ChangeBreakStatus(ctx *gin.Context) {
 	if ret.Error != nil {
 		RenderError(ctx, http.StatusBadRequest,
 			fmt.Sprintf("Failed to locate the player by pid %d: %v", pid, ret.Error))
+		return
 	}
 
-	if inBreakStr == "1" {
-		player.InBreak = true
-	} else {
-		player.InBreak = false
+	setBreakStatus(ctx, &player, inBreakStr == "1")
+}
+
+// setBreakStatus changes whether a player is in a break, along with their teammate if they are in
+// a fixed partnership. Returns false if an error page has been rendered.
+func setBreakStatus(ctx *gin.Context, player *gormmodel.Player, inBreak bool) bool {
+	player.InBreak = inBreak
+	if !player.InBreak && player.CheckInRound == 0 {
+		var event gormmodel.Event
+		ret := config.DB.First(&event, player.Eid)
+		if ret.Error != nil {
+			RenderError(ctx, http.StatusInternalServerError,
+				fmt.Sprintf("Failed to locate the event by eid %d: %v", player.Eid, ret.Error))
+			return false
+		}
+		player.CheckInRound = event.CurrentRound
 	}
-	ret = config.DB.Save(&player)
+	ret := config.DB.Save(player)
 	if ret.Error != nil {
 		RenderError(ctx, http.StatusBadRequest,
-			fmt.Sprintf("Failed to update the player by pid %d: %v", pid, ret.Error))
+			fmt.Sprintf("Failed to update the player by pid %d: %v", player.ID, ret.Error))
+		return false
+	}
+
+	// A fixed partnership takes breaks together.
+	teams, err := util.PopulateTeams(player.Eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list teams under event %d", player.Eid))
+		return false
+	}
+	if teammateID, ok := util.TeammateIDs(teams)[int(player.ID)]; ok {
+		ret = config.DB.Model(&gormmodel.Player{}).Where("id = ?", teammateID).Update("in_break", player.InBreak)
+		if ret.Error != nil {
+			RenderError(ctx, http.StatusBadRequest,
+				fmt.Sprintf("Failed to update the teammate by pid %d: %v", teammateID, ret.Error))
+			return false
+		}
+		if !player.InBreak {
+			ret = config.DB.Model(&gormmodel.Player{}).Where("id = ? AND check_in_round = 0", teammateID).
+				Update("check_in_round", player.CheckInRound)
+			if ret.Error != nil {
+				RenderError(ctx, http.StatusBadRequest,
+					fmt.Sprintf("Failed to update the teammate by pid %d: %v", teammateID, ret.Error))
+				return false
+			}
+		}
 	}
+	return true
 }
 
 // CompleteRound submits the scroes for each side involved in matches in the given round,
@@ -160,16 +243,43 @@ func CompleteRound(ctx *gin.Context) {
 		return
 	}
 
+	matches, ok := loadCompletableRound(ctx, event, round)
+	if !ok {
+		return
+	}
+	output, err := completeRound(&event, round, matches)
+	if err != nil {
+		log.Printf("Failed when modifying sides and/or event %d in round %d: %v", eid, round, err)
+		RenderError(ctx, http.StatusInternalServerError, "Failed when modifying sides and/or event")
+		return
+	}
+
+	// The output must wait until no error will be thrown, since errors are thrown
+	// under different HTTP status codes.
+	ctx.Writer.WriteString(output)
+}
+
+// loadCompletableRound lists the matches of a round which is ready to be completed, i.e. all of
+// its matches have been decided. Completing a round again requires override to be the time its
+// scores were last reported. An error page is rendered if anything goes wrong.
+func loadCompletableRound(ctx *gin.Context, event gormmodel.Event, round int) ([]gormmodel.Match, bool) {
+	eid := int(event.ID)
+	if rotation.Mode(event.Mode) != rotation.Rounds {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Event %d runs %s, where the sides are credited as soon as each result is reported", eid, event.Mode))
+		return nil, false
+	}
+
 	var matches []gormmodel.Match
-	ret = config.DB.Where("eid = ?", eid).Where("round = ?", round).Find(&matches)
+	ret := config.DB.Where("eid = ?", eid).Where("round = ?", round).Find(&matches)
 	if ret.Error != nil {
 		log.Printf("Failed to list matches under event %d in round %d: %v", eid, round, ret.Error)
 		RenderError(ctx, http.StatusInternalServerError, "Failed to list matches under event")
-		return
+		return nil, false
 	}
 	if len(matches) == 0 {
 		RenderError(ctx, http.StatusBadRequest, fmt.Sprintf("Round %d does not have any matches", round))
-		return
+		return nil, false
 	}
 
 	var latestUpdatedScore *time.Time
@@ -183,7 +293,7 @@ func CompleteRound(ctx *gin.Context) {
 			RenderError(ctx, http.StatusBadRequest,
 				fmt.Sprintf("The scores for round %d have been already reported, to override pass override=%d",
 					round, latestUpdatedScore.Unix()))
-			return
+			return nil, false
 		}
 	}
 
@@ -191,12 +301,17 @@ func CompleteRound(ctx *gin.Context) {
 		if match.Status == gormmodel.PLAYING {
 			RenderError(ctx, http.StatusBadRequest,
 				fmt.Sprintf("There are still match(es) with PLAYING status: %+v", match))
-			return
+			return nil, false
 		}
 	}
+	return matches, true
+}
 
+// completeRound sets the scores of the sides of the matches in a round, and moves the current round
+// of the event on if it is the given round. Returns the log of what has been done.
+func completeRound(event *gormmodel.Event, round int, matches []gormmodel.Match) (string, error) {
 	var output strings.Builder
-	err = config.DB.Transaction(func(tx *gorm.DB) error {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
 		for _, match := range matches {
 			sidWon := match.Sid1
 			sidLost := match.Sid2
@@ -207,7 +322,7 @@ func CompleteRound(ctx *gin.Context) {
 
 			var sideWon gormmodel.Side
 			var sideLost gormmodel.Side
-			ret = tx.First(&sideWon, sidWon)
+			ret := tx.First(&sideWon, sidWon)
 			if ret.Error != nil {
 				return ret.Error
 			}
@@ -234,7 +349,67 @@ func CompleteRound(ctx *gin.Context) {
 		if event.CurrentRound == round {
 			event.CurrentRound++
 			output.WriteString(fmt.Sprintf("Increased event %d current round to %d<br>\n", event.ID, event.CurrentRound))
-			ret = tx.Save(&event)
+			ret := tx.Save(event)
+			if ret.Error != nil {
+				return ret.Error
+			}
+		}
+
+		return nil
+	})
+	return output.String(), err
+}
+
+// checkSchedulableRound checks the matches of the given round of the event may be scheduled, i.e.
+// it is the current round of an event played in lockstep rounds. Scheduling a round again requires
+// override to be the time its matches were last updated. An error page is rendered if not.
+func checkSchedulableRound(ctx *gin.Context, event gormmodel.Event, round int) bool {
+	eid := int(event.ID)
+	if event.CurrentRound != round {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("You may only generate schedule for the current round %d", event.CurrentRound))
+		return false
+	}
+	if rotation.Mode(event.Mode) != rotation.Rounds {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Event %d runs %s, where the next match on a court follows each reported result", eid, event.Mode))
+		return false
+	}
+
+	var latestUpdatedScore *time.Time
+	config.DB.Raw("SELECT MAX(`updated_at`)	FROM `match` m 	WHERE m.eid=? AND m.round=?", eid, round).Scan(&latestUpdatedScore)
+	if latestUpdatedScore != nil {
+		if ctx.Query("override") != fmt.Sprintf("%d", latestUpdatedScore.Unix()) {
+			RenderError(ctx, http.StatusBadRequest,
+				fmt.Sprintf("The matches for round %d have been already scheduled, to override pass override=%d",
+					round, latestUpdatedScore.Unix()))
+			return false
+		}
+	}
+	return true
+}
+
+// saveCurrentRound replaces the matches of the current round of the event with the given ones, and
+// saves the re-planned rounds if any.
+func saveCurrentRound(event gormmodel.Event, matches []gormmodel.Match, replannedRounds []gormmodel.PlannedRound) error {
+	// Clean up existing arrangements
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var oldMatches []gormmodel.Match
+		ret := tx.Where("eid = ?", event.ID).Where("round = ?", event.CurrentRound).Find(&oldMatches)
+		if ret.Error != nil {
+			return ret.Error
+		}
+
+		for idx := range oldMatches {
+			ret = tx.Delete(&gormmodel.Side{}, oldMatches[idx].Sid1)
+			if ret.Error != nil {
+				return ret.Error
+			}
+			ret = tx.Delete(&gormmodel.Side{}, oldMatches[idx].Sid2)
+			if ret.Error != nil {
+				return ret.Error
+			}
+			ret = tx.Delete(&oldMatches[idx])
 			if ret.Error != nil {
 				return ret.Error
 			}
@@ -243,14 +418,16 @@ func CompleteRound(ctx *gin.Context) {
 		return nil
 	})
 	if err != nil {
-		log.Printf("Failed when modifying sides and/or event %d in round %d: %v", eid, round, ret.Error)
-		RenderError(ctx, http.StatusInternalServerError, "Failed when modifying sides and/or event")
-		return
+		return fmt.Errorf("failed when cleaning up old matches/sides: %v", err)
 	}
 
-	// The output must wait until no error will be thrown, since errors are thrown
-	// under different HTTP status codes.
-	ctx.Writer.WriteString(output.String())
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		err := replacePlannedRounds(tx, int(event.ID), replannedRounds)
+		if err != nil {
+			return err
+		}
+		return createMatches(tx, matches)
+	})
 }
 
 // ScheduleCurrentRound generates a new match table for the current round.
@@ -294,159 +471,62 @@ func ScheduleCurrentRound(ctx *gin.Context) {
 		return
 	}
 
-	if event.CurrentRound != round {
-		RenderError(ctx, http.StatusBadRequest,
-			fmt.Sprintf("You may only generate schedule for the current round %d", event.CurrentRound))
-		return
-	}
-
-	var latestUpdatedScore *time.Time
-	config.DB.Raw("SELECT MAX(`updated_at`)	FROM `match` m 	WHERE m.eid=? AND m.round=?", eid, round).Scan(&latestUpdatedScore)
-	if latestUpdatedScore != nil {
-		if ctx.Query("override") != fmt.Sprintf("%d", latestUpdatedScore.Unix()) {
-			RenderError(ctx, http.StatusBadRequest,
-				fmt.Sprintf("The matches for round %d have been already scheduled, to override pass override=%d",
-					round, latestUpdatedScore.Unix()))
-			return
-		}
-	}
-
-	players, playerMap, err := util.PopulatePlayers(eid)
-	if err != nil {
-		RenderError(ctx, http.StatusInternalServerError,
-			fmt.Sprintf("Failed to list players under event %d", eid))
-		return
-	}
-
-	sides, _, err := util.PopulateSides(int(event.ID), playerMap, &event.CurrentRound)
-	if ret.Error != nil {
-		RenderError(ctx, http.StatusInternalServerError,
-			fmt.Sprintf("Failed to list sides under event %d", eid))
+	if !checkSchedulableRound(ctx, event, round) {
 		return
 	}
 
-	util.FillPlayerCounter(playerMap, sides)
-
-	activePlayers := util.FilterActivePlayers(players)
-	allArrangerPlayers := util.ToArrangerPlayersP(players)
-	activeArrangerPlayers := util.ToArrangerPlayers(activePlayers)
-	util.FillArrangerPlayersOpponents(activeArrangerPlayers, sides)
-
-	ctx.Writer.WriteString("<html><head><style> body { font-family: Courier New; font-weight: bold; } </style> </head><body>\n")
-
-	if ctx.Query("proceed") != "1" {
-		ctx.Writer.WriteString(fmt.Sprintf(
-			"<br>\n<a href=\"%s&proceed=1\">Proceed</a><br>\n", ctx.Request.URL))
-	}
-
-	ctx.Writer.WriteString("Active players with opponents filled:<br>\n")
-	for idx := range activeArrangerPlayers {
-		ctx.Writer.WriteString(fmt.Sprintf("%p %+v", activeArrangerPlayers[idx], activeArrangerPlayers[idx]))
-		ctx.Writer.WriteString("<br>\n")
-	}
-
-	playingPlayers, err := arranger.PickPlayersForCourts(activeArrangerPlayers, event.Courts)
-	if err != nil {
-		RenderError(ctx, http.StatusInternalServerError,
-			fmt.Sprintf("Error when picking players based on number of courts %d: %v", event.Courts, err))
+	input, eventArranger, ok := loadArrangerInput(ctx, config.DB, event)
+	if !ok {
 		return
 	}
 
-	arranger.SortPlayerSliceByScorePriority(playingPlayers)
-	err = arranger.SeparateCompetedPlayersWithinBands(allArrangerPlayers, playingPlayers)
+	var explanation arranger.Explanation
+	input.Explanation = &explanation
+	arrangerMatches, replannedRounds, note, err := arrangeCurrentRound(event, input, eventArranger)
 	if err != nil {
 		RenderError(ctx, http.StatusInternalServerError,
-			fmt.Sprintf("Error when separating competed players within bands %v", err))
+			fmt.Sprintf("Error when making match arrangement based on active players: %v", err))
 		return
 	}
 
-	ctx.Writer.WriteString("<br>\n<br>\nPlayers clustered by score and separated between competed:<br>\n")
-	for idx := range playingPlayers {
-		ctx.Writer.WriteString(fmt.Sprintf("%p %+v", playingPlayers[idx], playingPlayers[idx]))
-		ctx.Writer.WriteString("<br>\n")
-	}
-
-	arrangerMatches, err := arranger.MakeMatchArrangements(playingPlayers, event.Courts, event.CurrentRound)
-	if err != nil {
-		RenderError(ctx, http.StatusInternalServerError,
-			fmt.Sprintf("Error when making match arrangement based on playing players: %v", err))
-		return
-	}
 	matches := util.FromArrangerMatchArrangement(arrangerMatches, event)
-	ctx.Writer.WriteString("<br>\n<br>\nMatch arrangement:<br>\n")
-	for idx := range matches {
-		ctx.Writer.WriteString(fmt.Sprintf("%+v<br>\n", matches[idx]))
-		ctx.Writer.WriteString(fmt.Sprintf("---- %+v<br>\n", matches[idx].Side1))
-		ctx.Writer.WriteString(fmt.Sprintf("---- %+v", matches[idx].Side2))
-		ctx.Writer.WriteString("<br>\n<br>\n")
+	jsonOutput := ctx.Query("format") == "json"
+	if !jsonOutput {
+		renderExplanation(ctx, event, input.CostModel(), note, &explanation)
+		if event.Handicap {
+			renderHandicaps(ctx, matches)
+		}
 	}
 
 	if ctx.Query("proceed") != "1" {
-		return
-	}
-
-	// Clean up existing arrangements
-	err = config.DB.Transaction(func(tx *gorm.DB) error {
-		var oldMatches []gormmodel.Match
-		ret := tx.Where("eid = ?", event.ID).Where("round = ?", event.CurrentRound).Find(&oldMatches)
-		if ret.Error != nil {
-			return ret.Error
+		if jsonOutput {
+			ctx.JSON(http.StatusOK, gin.H{
+				"round":       event.CurrentRound,
+				"note":        note,
+				"explanation": explanation,
+				"persisted":   false,
+			})
+		} else {
+			ctx.Writer.WriteString("</body></html>\n")
 		}
-
-		for idx := range oldMatches {
-			ret = tx.Delete(&gormmodel.Side{}, oldMatches[idx].Sid1)
-			if ret.Error != nil {
-				return ret.Error
-			}
-			ret = tx.Delete(&gormmodel.Side{}, oldMatches[idx].Sid2)
-			if ret.Error != nil {
-				return ret.Error
-			}
-			ret = tx.Delete(&oldMatches[idx])
-			if ret.Error != nil {
-				return ret.Error
-			}
-		}
-
-		return nil
-	})
-	if err != nil {
-		log.Printf("Failed when cleaning up old matches/sides %d in round %d: %v", eid, event.CurrentRound, err)
-		RenderError(ctx, http.StatusInternalServerError, "Failed when creating new matches/sides")
 		return
 	}
 
-	err = config.DB.Transaction(func(tx *gorm.DB) error {
-		for idx := range matches {
-			ret = tx.Create(&matches[idx])
-			if ret.Error != nil {
-				return ret.Error
-			}
-
-			matches[idx].Side1.Mid = int(matches[idx].ID)
-			ret = tx.Save(matches[idx].Side1)
-			if ret.Error != nil {
-				return ret.Error
-			}
-			matches[idx].Side2.Mid = int(matches[idx].ID)
-			ret = tx.Save(matches[idx].Side2)
-			if ret.Error != nil {
-				return ret.Error
-			}
-		}
-		return nil
-	})
+	err = saveCurrentRound(event, matches, replannedRounds)
 	if err != nil {
 		log.Printf("Failed when creating new matches/sides %d in round %d: %v", eid, event.CurrentRound, err)
 		RenderError(ctx, http.StatusInternalServerError, "Failed when creating new matches/sides")
 		return
 	}
 
-	ctx.Writer.WriteString("<br>\n<br>\nMatch arrangement persisted:<br>\n")
-	for idx := range matches {
-		ctx.Writer.WriteString(fmt.Sprintf("%+v<br>\n", matches[idx]))
-		ctx.Writer.WriteString(fmt.Sprintf("---- %+v<br>\n", matches[idx].Side1))
-		ctx.Writer.WriteString(fmt.Sprintf("---- %+v", matches[idx].Side2))
-		ctx.Writer.WriteString("<br>\n<br>\n")
+	if jsonOutput {
+		ctx.JSON(http.StatusOK, gin.H{
+			"round":       event.CurrentRound,
+			"note":        note,
+			"explanation": explanation,
+			"persisted":   true,
+		})
+		return
 	}
+	ctx.Writer.WriteString("<p>Match arrangement persisted.\n</body></html>\n")
 }
@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yushenli/badminton_match_table/pkg/arranger"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"github.com/yushenli/badminton_match_table/web/lib/util"
+	"gorm.io/gorm"
+)
+
+// ConstraintsForm returns the form for editing the player constraints of a given event.
+// The text form will be pre-filled with existing constraints in csv format, if any exists.
+func ConstraintsForm(ctx *gin.Context) {
+	event, ok := loadAdminEvent(ctx)
+	if !ok {
+		return
+	}
+
+	players, playerMap, err := util.PopulatePlayers(int(event.ID))
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list players under event %d", event.ID))
+		return
+	}
+	constraints, err := util.PopulateConstraints(int(event.ID))
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list constraints under event %d", event.ID))
+		return
+	}
+
+	var constraintEntries [][]string
+	for _, constraint := range constraints {
+		player1, ok1 := playerMap[constraint.Pid1]
+		player2, ok2 := playerMap[constraint.Pid2]
+		if !ok1 || !ok2 {
+			continue
+		}
+		constraintEntries = append(constraintEntries, []string{player1.Name, constraint.Kind, player2.Name})
+	}
+
+	buffer := new(bytes.Buffer)
+	writer := csv.NewWriter(buffer)
+	writer.WriteAll(constraintEntries)
+
+	var kinds []string
+	for _, kind := range arranger.ConstraintKinds {
+		kinds = append(kinds, string(kind))
+	}
+	var names []string
+	for _, player := range players {
+		names = append(names, player.Name)
+	}
+
+	ctx.Writer.WriteString(`
+<html>
+<head>
+	<style>
+		body {
+			font-family: Courier New;
+			font-weight: bold;
+		}
+	</style>
+</head>
+<body>
+	<p>One constraint per line: player name, kind, player name.
+	<p>Kinds: ` + html.EscapeString(strings.Join(kinds, ", ")) + `
+	<p>Players: ` + html.EscapeString(strings.Join(names, ", ")) + `
+	<form id="constraintsform" method="post">
+		<textarea rows="24" cols="50" name="constraints">` +
+		html.EscapeString(buffer.String()) +
+		`</textarea>
+		<p>
+		<input type="submit">
+	</form>
+</body>
+</html>
+	`)
+}
+
+// ConstraintsSubmit takes the form for editing the player constraints of a given event.
+// The input is expected to be a CSV where each line contains a player's name, the constraint kind
+// and another player's name. The constraints of the event are replaced by the ones in the input.
+func ConstraintsSubmit(ctx *gin.Context) {
+	event, ok := loadAdminEvent(ctx)
+	if !ok {
+		return
+	}
+	eid := int(event.ID)
+
+	players, _, err := util.PopulatePlayers(eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list players under event %d", eid))
+		return
+	}
+	playerMap := make(map[string]*util.PlayerWithCounter)
+	for idx := range players {
+		playerMap[players[idx].Name] = &players[idx]
+	}
+
+	reader := csv.NewReader(strings.NewReader(ctx.PostForm("constraints")))
+	reader.FieldsPerRecord = -1
+	constraintEntries, err := reader.ReadAll()
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Unable to parse the constraints in CSV: %v", err))
+		return
+	}
+
+	var constraints []gormmodel.PlayerConstraint
+	for idx, entry := range constraintEntries {
+		if len(entry) == 0 {
+			continue
+		}
+		if len(entry) != 3 {
+			RenderError(ctx, http.StatusBadRequest,
+				fmt.Sprintf("Invalid entry on row %d , 3 fields are expected: %+v", idx+1, entry))
+			return
+		}
+
+		player1, ok := playerMap[strings.TrimSpace(entry[0])]
+		if !ok {
+			RenderError(ctx, http.StatusBadRequest,
+				fmt.Sprintf("Invalid entry on row %d , unknown player %q: %+v", idx+1, entry[0], entry))
+			return
+		}
+		kind, err := arranger.ParseConstraintKind(strings.TrimSpace(entry[1]))
+		if err != nil {
+			RenderError(ctx, http.StatusBadRequest,
+				fmt.Sprintf("Invalid entry on row %d , %v: %+v", idx+1, err, entry))
+			return
+		}
+		player2, ok := playerMap[strings.TrimSpace(entry[2])]
+		if !ok {
+			RenderError(ctx, http.StatusBadRequest,
+				fmt.Sprintf("Invalid entry on row %d , unknown player %q: %+v", idx+1, entry[2], entry))
+			return
+		}
+		if player1.ID == player2.ID {
+			RenderError(ctx, http.StatusBadRequest,
+				fmt.Sprintf("Invalid entry on row %d , a constraint needs two different players: %+v", idx+1, entry))
+			return
+		}
+
+		constraints = append(constraints, gormmodel.PlayerConstraint{
+			Eid:  eid,
+			Kind: string(kind),
+			Pid1: int(player1.ID),
+			Pid2: int(player2.ID),
+		})
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		ret := tx.Where("eid = ?", eid).Delete(&gormmodel.PlayerConstraint{})
+		if ret.Error != nil {
+			return ret.Error
+		}
+		for _, constraint := range constraints {
+			ctx.Writer.WriteString(fmt.Sprintf("To be created: %+v\n", constraint))
+		}
+		if len(constraints) > 0 {
+			ret = tx.Create(&constraints)
+			if ret.Error != nil {
+				return ret.Error
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to replace constraints: %v", err))
+		return
+	}
+}
@@ -0,0 +1,238 @@
+package controller
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yushenli/badminton_match_table/pkg/arranger"
+	"github.com/yushenli/badminton_match_table/pkg/rating"
+	"github.com/yushenli/badminton_match_table/pkg/rotation"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"github.com/yushenli/badminton_match_table/web/lib/util"
+)
+
+// loadAdminEvent loads the event whose eid is given in the URL path, and checks the visitor
+// has admin privilege to it. An error page is rendered if anything goes wrong.
+func loadAdminEvent(ctx *gin.Context) (*gormmodel.Event, bool) {
+	if config.DB == nil {
+		RenderError(ctx, http.StatusInternalServerError, "Unable to connect to database. Please contact the admin.")
+		return nil, false
+	}
+
+	eidStr := ctx.Param("eid")
+	eid, err := strconv.Atoi(eidStr)
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Invalid eid provided: %q", eidStr))
+		return nil, false
+	}
+
+	var event gormmodel.Event
+	ret := config.DB.First(&event, eid)
+	if ret.Error != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to locate the event by eid %d: %v", eid, ret.Error))
+		return nil, false
+	}
+
+	if !util.HasAdminPrivilege(ctx, event) {
+		RenderError(ctx, http.StatusForbidden,
+			fmt.Sprintf("You do not have admin privilege to event %d", eid))
+		return nil, false
+	}
+
+	return &event, true
+}
+
+// selectOptions renders the <option> tags of a <select>, with the current value selected.
+func selectOptions(values []string, current string) string {
+	var sb strings.Builder
+	for _, value := range values {
+		selected := ""
+		if value == current {
+			selected = " selected"
+		}
+		sb.WriteString(fmt.Sprintf("<option value=\"%s\"%s>%s</option>\n",
+			html.EscapeString(value), selected, html.EscapeString(value)))
+	}
+	return sb.String()
+}
+
+// checked returns the checked attribute of a checkbox input if the value is true.
+func checked(value bool) string {
+	if value {
+		return " checked"
+	}
+	return ""
+}
+
+// EventSettingsForm returns the form for changing how the rounds of a given event are arranged.
+func EventSettingsForm(ctx *gin.Context) {
+	event, ok := loadAdminEvent(ctx)
+	if !ok {
+		return
+	}
+
+	currentArranger := event.Arranger
+	if currentArranger == "" {
+		currentArranger = arranger.DefaultArrangerName
+	}
+	costModel, err := arranger.ParseCostModel(event.CostModel)
+	if err != nil {
+		costModel = arranger.DefaultCostModel
+	}
+	banding, err := arranger.ParseBanding(event.Banding)
+	if err != nil {
+		banding = arranger.HierarchicalBanding{}
+	}
+	var formats []string
+	for _, format := range arranger.Formats {
+		formats = append(formats, string(format))
+	}
+	currentRating := event.Rating
+	if currentRating == "" {
+		currentRating = rating.DefaultSystemName
+	}
+	var catchUpPolicies []string
+	for _, policy := range arranger.CatchUpPolicies {
+		catchUpPolicies = append(catchUpPolicies, string(policy))
+	}
+
+	var modes []string
+	for _, mode := range rotation.Modes {
+		modes = append(modes, string(mode))
+	}
+
+	ctx.Writer.WriteString(`
+<html>
+<head>
+	<style>
+		body {
+			font-family: Courier New;
+			font-weight: bold;
+		}
+	</style>
+</head>
+<body>
+	<form id="eventform" method="post">
+		<p>Arranger:
+		<select name="arranger">
+` + selectOptions(arranger.Names(), currentArranger) + `		</select>
+		<p>Banding (` + html.EscapeString(strings.Join(arranger.BandingNames, ", ")) + `, e.g. quantile:4):
+		<input type="text" size="30" name="banding" value="` + html.EscapeString(banding.String()) + `">
+		<p>Format:
+		<select name="format">
+` + selectOptions(formats, event.Format) + `		</select>
+		<p>Balance the combined scores of doubles sides:
+		<input type="checkbox" name="balance_sides" value="1"` + checked(event.BalanceSides) + `>
+		<p>Catch-up policy for late arrivals:
+		<select name="catch_up">
+` + selectOptions(catchUpPolicies, event.CatchUp) + `		</select>
+		<p>Rating system:
+		<select name="rating">
+` + selectOptions(rating.Names(), currentRating) + `		</select>
+		<p>Handicap for uneven matches:
+		<input type="checkbox" name="handicap" value="1"` + checked(event.Handicap) + `>
+		<p>Scheduling mode:
+		<select name="mode">
+` + selectOptions(modes, event.Mode) + `		</select>
+		<p>Most matches in a row a side can win under winners stay on (0 for no limit):
+		<input type="text" size="4" name="max_stays" value="` + strconv.Itoa(event.MaxStays) + `">
+		<p>Cost model:
+		<input type="text" size="80" name="cost_model" value="` + html.EscapeString(costModel.String()) + `">
+		<p>
+		<input type="submit">
+	</form>
+</body>
+</html>
+	`)
+}
+
+// EventSettingsSubmit takes the form for changing how the rounds of a given event are arranged.
+func EventSettingsSubmit(ctx *gin.Context) {
+	event, ok := loadAdminEvent(ctx)
+	if !ok {
+		return
+	}
+
+	arrangerName := strings.TrimSpace(ctx.PostForm("arranger"))
+	if _, err := arranger.Lookup(arrangerName); err != nil {
+		RenderError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	banding, err := arranger.ParseBanding(ctx.PostForm("banding"))
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	format, err := arranger.ParseFormat(strings.TrimSpace(ctx.PostForm("format")))
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	catchUp, err := arranger.ParseCatchUpPolicy(strings.TrimSpace(ctx.PostForm("catch_up")))
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ratingName := strings.TrimSpace(ctx.PostForm("rating"))
+	if _, err := rating.Lookup(ratingName); err != nil {
+		RenderError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	mode, err := rotation.ParseMode(strings.TrimSpace(ctx.PostForm("mode")))
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	maxStays, err := strconv.Atoi(strings.TrimSpace(ctx.PostForm("max_stays")))
+	if err != nil || maxStays < 0 {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Invalid max stays provided: %q", ctx.PostForm("max_stays")))
+		return
+	}
+
+	costModelSpec := strings.TrimSpace(ctx.PostForm("cost_model"))
+	costModel, err := arranger.ParseCostModel(costModelSpec)
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Invalid cost model: %v", err))
+		return
+	}
+
+	event.Arranger = arrangerName
+	event.Banding = ""
+	if _, ok := banding.(arranger.HierarchicalBanding); !ok {
+		event.Banding = banding.String()
+	}
+	event.Format = string(format)
+	event.BalanceSides = ctx.PostForm("balance_sides") == "1"
+	event.CatchUp = string(catchUp)
+	event.Rating = ratingName
+	event.Handicap = ctx.PostForm("handicap") == "1"
+	event.Mode = string(mode)
+	event.MaxStays = maxStays
+	event.CostModel = ""
+	if costModel != arranger.DefaultCostModel {
+		event.CostModel = costModel.String()
+	}
+	ret := config.DB.Save(event)
+	if ret.Error != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to update event %d: %v", event.ID, ret.Error))
+		return
+	}
+
+	ctx.Writer.WriteString(fmt.Sprintf("Updated event %d: arranger=%s, banding=%s, format=%q, balance sides=%v, catch-up=%q, rating=%s, handicap=%v, mode=%q, max stays=%d, cost model=%s\n",
+		event.ID, arrangerName, banding, format, event.BalanceSides, catchUp, ratingName, event.Handicap, mode, maxStays, costModel))
+}
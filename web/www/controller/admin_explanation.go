@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yushenli/badminton_match_table/pkg/arranger"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+)
+
+// formatCost renders the terms of a cost breakdown in a single line.
+func formatCost(b arranger.CostBreakdown) string {
+	return fmt.Sprintf("partner %.2f, opponent %.2f, side gap %.2f, match gap %.2f, waited %.2f",
+		b.RepeatPartner, b.RepeatOpponent, b.ScoreGapInSide, b.ScoreGapBetweenSides, b.RoundsWaited)
+}
+
+// renderExplanation writes the preview page of an arrangement, opening the html body for
+// the caller to append to and close.
+func renderExplanation(ctx *gin.Context, event gormmodel.Event, costModel arranger.CostModel, note string,
+	explanation *arranger.Explanation) {
+	var sb strings.Builder
+	sb.WriteString(`
+<html>
+<head>
+	<style>
+		body {
+			font-family: Courier New;
+			font-weight: bold;
+		}
+		td, th {
+			padding: 2px 8px;
+			text-align: left;
+		}
+	</style>
+</head>
+<body>
+`)
+	sb.WriteString(fmt.Sprintf("<h3>Round %d of event %d</h3>\n", event.CurrentRound, event.ID))
+	sb.WriteString(fmt.Sprintf("<p>%s. Format: %q, cost model: %s\n",
+		html.EscapeString(note), html.EscapeString(event.Format), html.EscapeString(costModel.String())))
+	if ctx.Query("proceed") != "1" {
+		url := html.EscapeString(ctx.Request.URL.String())
+		sb.WriteString(fmt.Sprintf("<p><a href=\"%s&proceed=1\">Proceed</a> | <a href=\"%s&format=json\">JSON</a>\n", url, url))
+	}
+
+	sb.WriteString("<h4>Matches</h4>\n<table>\n<tr><th>Court</th><th>Side 1</th><th>Side 2</th><th>Cost</th><th>Breakdown</th></tr>\n")
+	for _, match := range explanation.Matches {
+		sb.WriteString(fmt.Sprintf("<tr><td>%d</td><td>%s</td><td>%s</td><td>%.2f</td><td>%s</td></tr>\n",
+			match.Court,
+			html.EscapeString(strings.Join(match.Side1, " / ")),
+			html.EscapeString(strings.Join(match.Side2, " / ")),
+			match.TotalCost,
+			formatCost(match.Cost)))
+	}
+	sb.WriteString(fmt.Sprintf("<tr><td>Total</td><td></td><td></td><td>%.2f</td><td>%s</td></tr>\n</table>\n",
+		explanation.TotalCost, formatCost(explanation.Total)))
+	for _, team := range explanation.SplitTeams {
+		sb.WriteString(fmt.Sprintf("<p>Teammates %s and %s are split up to satisfy the constraints.\n",
+			html.EscapeString(team[0]), html.EscapeString(team[1])))
+	}
+
+	sb.WriteString("<h4>Players</h4>\n<table>\n")
+	sb.WriteString("<tr><th>Name</th><th>Score</th><th>Matches</th><th>Sit-out streak</th><th>Rounds sat out</th><th>Picked</th><th>Reason</th></tr>\n")
+	for _, player := range explanation.Players {
+		picked := "no"
+		if player.Picked {
+			picked = "yes"
+		}
+		sb.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%g</td><td>%g</td><td>%d</td><td>%d</td><td>%s</td><td>%s</td></tr>\n",
+			html.EscapeString(player.Name), player.Score, player.Matches, player.RoundsWaited, player.RoundsSatOut,
+			picked, html.EscapeString(player.Reason)))
+	}
+	sb.WriteString("</table>\n")
+
+	if len(explanation.Bands) > 0 {
+		var bands []string
+		for _, band := range explanation.Bands {
+			bands = append(bands, fmt.Sprintf("%g", band))
+		}
+		sb.WriteString(fmt.Sprintf("<h4>Score bands</h4>\n<p>Lower bounds: %s\n", strings.Join(bands, ", ")))
+	}
+	if len(explanation.Ranges) > 0 {
+		sb.WriteString("<h4>Ranges separated by match history</h4>\n<table>\n<tr><th>From</th><th>To</th><th>Last fixed</th><th>Players</th></tr>\n")
+		for _, r := range explanation.Ranges {
+			sb.WriteString(fmt.Sprintf("<tr><td>%d</td><td>%d</td><td>%v</td><td>%s</td></tr>\n",
+				r.Left, r.Right, r.EndFixed, html.EscapeString(strings.Join(r.Players, ", "))))
+		}
+		sb.WriteString("</table>\n")
+	}
+
+	ctx.Writer.WriteString(sb.String())
+}
+
+// renderHandicaps writes the handicaps given to the matches about to be created.
+func renderHandicaps(ctx *gin.Context, matches []gormmodel.Match) {
+	var sb strings.Builder
+	sb.WriteString("<h4>Handicaps</h4>\n<table>\n<tr><th>Court</th><th>Side</th><th>Starting points</th></tr>\n")
+	for _, match := range matches {
+		if match.HandicapSide == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("<tr><td>%d</td><td>%d</td><td>%d</td></tr>\n",
+			match.Court, match.HandicapSide, match.HandicapPoints))
+	}
+	sb.WriteString("</table>\n")
+	ctx.Writer.WriteString(sb.String())
+}
@@ -0,0 +1,319 @@
+package controller
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yushenli/badminton_match_table/pkg/tournament"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"github.com/yushenli/badminton_match_table/web/lib/util"
+	"gorm.io/gorm"
+)
+
+// GroupsForm returns the form for creating the round-robin group stage of a given event.
+// The existing groups are shown above the form, which is pre-filled with their entrants.
+func GroupsForm(ctx *gin.Context) {
+	event, ok := loadAdminEvent(ctx)
+	if !ok {
+		return
+	}
+	_, playerMap, err := util.PopulatePlayers(int(event.ID))
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list players under event %d", event.ID))
+		return
+	}
+	record, stage, _, names, ok := loadGroupStage(ctx, *event, playerMap)
+	if !ok {
+		return
+	}
+
+	var kinds []string
+	for _, kind := range tournament.Kinds {
+		kinds = append(kinds, string(kind))
+	}
+	groups, advance, knockout := 2, 2, string(tournament.SingleElimination)
+	existing := ""
+	if stage != nil {
+		groups, advance, knockout = len(stage.Groups), stage.Advance, record.Knockout
+		existing = renderGroups(stage, names) +
+			fmt.Sprintf("\t<p><a href=\"/admin/groups/%d/advance\">Advance the group stage</a>\n", event.ID) +
+			"\t<p>Submitting the form replaces the group stage and all of its results.\n"
+	}
+
+	ctx.Writer.WriteString(`
+<html>
+<head>
+	<style>
+		body {
+			font-family: Courier New;
+			font-weight: bold;
+		}
+		td, th {
+			padding: 2px 8px;
+		}
+	</style>
+</head>
+<body>
+` + existing + `	<form id="groupsform" method="post">
+		<p>Groups:
+		<input type="text" size="4" name="groups" value="` + strconv.Itoa(groups) + `">
+		<p>Entrants advancing from every group:
+		<input type="text" size="4" name="advance" value="` + strconv.Itoa(advance) + `">
+		<p>Knockout:
+		<select name="knockout">
+` + selectOptions(kinds, knockout) + `		</select>
+		<p>Entrants in the order of their seeds, one per line, either "name" or "name,teammate":<br>
+		<textarea name="entrants" rows="20" cols="60">` + html.EscapeString(strings.Join(names, "\n")) + `</textarea>
+		<p>
+		<input type="submit">
+	</form>
+</body>
+</html>
+	`)
+}
+
+// GroupsSubmit takes the form for creating the round-robin group stage of a given event. The seeded
+// entrants are split into balanced groups, whose fixtures are scheduled across the courts of the
+// event. The existing group stage is replaced if any.
+func GroupsSubmit(ctx *gin.Context) {
+	event, ok := loadAdminEvent(ctx)
+	if !ok {
+		return
+	}
+	eid := int(event.ID)
+
+	groups, err := strconv.Atoi(strings.TrimSpace(ctx.PostForm("groups")))
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Invalid groups provided: %q", ctx.PostForm("groups")))
+		return
+	}
+	advance, err := strconv.Atoi(strings.TrimSpace(ctx.PostForm("advance")))
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Invalid advance provided: %q", ctx.PostForm("advance")))
+		return
+	}
+	knockout, err := tournament.ParseKind(ctx.PostForm("knockout"))
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	entrants, ok := parseEntrants(ctx, eid)
+	if !ok {
+		return
+	}
+
+	stage, err := tournament.NewGroupStage(len(entrants), groups, advance, event.Courts)
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	record, err := util.PopulateGroupStage(eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to fetch the group stage under event %d", eid))
+		return
+	}
+	if record == nil {
+		record = &gormmodel.GroupStage{Eid: eid}
+	}
+	record.Knockout = string(knockout)
+	err = util.EncodeGroupStage(record, stage, entrants)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to encode the group stage: %v", err))
+		return
+	}
+	ret := config.DB.Save(record)
+	if ret.Error != nil {
+		log.Printf("Failed when saving the group stage of event %d: %v", eid, ret.Error)
+		RenderError(ctx, http.StatusInternalServerError, "Failed when saving the group stage")
+		return
+	}
+
+	ctx.Writer.WriteString(fmt.Sprintf("Created %d groups of %d entrants, %d fixtures in all.<br>\n",
+		groups, len(entrants), len(stage.Fixtures)))
+}
+
+// AdvanceGroups reports the results of the scheduled group fixtures and assigns the fixtures of the
+// earliest pending slot to the free courts of the current round, skipping those whose entrants have
+// played a fixture of an earlier slot in the current round or are still playing one. Once every
+// fixture has been played, the knockout tournament is created from the qualifiers, unless the
+// event already has a tournament. The changes are only saved when proceed=1 is given.
+func AdvanceGroups(ctx *gin.Context) {
+	event, ok := loadAdminEvent(ctx)
+	if !ok {
+		return
+	}
+	eid := int(event.ID)
+
+	_, playerMap, err := util.PopulatePlayers(eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list players under event %d", eid))
+		return
+	}
+	record, stage, entrants, names, ok := loadGroupStage(ctx, *event, playerMap)
+	if !ok {
+		return
+	}
+	if stage == nil {
+		RenderError(ctx, http.StatusBadRequest, fmt.Sprintf("Event %d does not have a group stage", eid))
+		return
+	}
+	_, sideMap, err := util.PopulateSides(eid, playerMap, nil)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list sides under event %d", eid))
+		return
+	}
+	matches, matchesByRound, err := util.PopulateMatches(eid, event.CurrentRound, sideMap)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list matches under event %d", eid))
+		return
+	}
+
+	reported, err := util.ReportGroupResults(stage, matches)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to report the results of the group stage: %v", err))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<html><head><style> body { font-family: Courier New; font-weight: bold; } td, th { padding: 2px 8px; } </style></head><body>\n")
+	sb.WriteString(fmt.Sprintf("<p>Reported %d result(s).\n", reported))
+	if ctx.Query("proceed") != "1" {
+		sb.WriteString(fmt.Sprintf("<p><a href=\"%s?proceed=1\">Proceed</a>\n", html.EscapeString(ctx.Request.URL.Path)))
+	}
+
+	var knockout *gormmodel.Tournament
+	var scheduled []*tournament.Fixture
+	var newMatches []gormmodel.Match
+	if stage.Done() {
+		knockout, ok = knockoutOf(ctx, record, stage, entrants)
+		if !ok {
+			return
+		}
+		if knockout == nil {
+			sb.WriteString("<p>The group stage is over, and the event already has a tournament.\n")
+		} else {
+			sb.WriteString(fmt.Sprintf("<p>The group stage is over, a %s elimination knockout of the qualifiers will be created.\n",
+				knockout.Kind))
+		}
+	} else {
+		currentMatches := matchesByRound[event.CurrentRound-1]
+		recent := make(map[int]bool)
+		for _, match := range currentMatches {
+			recent[int(match.ID)] = true
+		}
+		courts := newCourtAssigner(*event, currentMatches)
+		for _, fixture := range stage.Ready(recent) {
+			match, ok := courts.assign(util.FromFixture(fixture, entrants, *event, 0))
+			if !ok {
+				continue
+			}
+			scheduled = append(scheduled, fixture)
+			newMatches = append(newMatches, match)
+		}
+
+		sb.WriteString(fmt.Sprintf("<h4>Group matches for round %d</h4>\n<table>\n<tr><th>Court</th><th>Group</th><th>Side 1</th><th>Side 2</th></tr>\n",
+			event.CurrentRound))
+		for idx, fixture := range scheduled {
+			sb.WriteString(fmt.Sprintf("<tr><td>%d</td><td>%c</td><td>%s</td><td>%s</td></tr>\n", newMatches[idx].Court, 'A'+fixture.Group,
+				html.EscapeString(entrantName(fixture.Entrant1, names)),
+				html.EscapeString(entrantName(fixture.Entrant2, names))))
+		}
+		sb.WriteString("</table>\n")
+	}
+
+	if ctx.Query("proceed") == "1" {
+		err = config.DB.Transaction(func(tx *gorm.DB) error {
+			err := createMatches(tx, newMatches)
+			if err != nil {
+				return err
+			}
+			for idx := range newMatches {
+				scheduled[idx].Ref = int(newMatches[idx].ID)
+			}
+
+			if knockout != nil {
+				ret := tx.Create(knockout)
+				if ret.Error != nil {
+					return ret.Error
+				}
+			}
+			err = util.EncodeGroupStage(record, stage, entrants)
+			if err != nil {
+				return err
+			}
+			return tx.Save(record).Error
+		})
+		if err != nil {
+			log.Printf("Failed when advancing the group stage of event %d: %v", eid, err)
+			RenderError(ctx, http.StatusInternalServerError, "Failed when advancing the group stage")
+			return
+		}
+		sb.WriteString("<p>Group stage advanced.\n")
+		if knockout != nil {
+			sb.WriteString(fmt.Sprintf("<p><a href=\"/admin/tournament/%d\">Go to the knockout</a>\n", eid))
+		}
+	}
+	sb.WriteString(renderGroups(stage, names))
+	sb.WriteString("</body></html>\n")
+	ctx.Writer.WriteString(sb.String())
+}
+
+// knockoutOf returns the knockout tournament of the qualifiers of a group stage which is over,
+// or nil if the event already has a tournament. An error page is rendered if anything goes wrong.
+func knockoutOf(ctx *gin.Context, record *gormmodel.GroupStage, stage *tournament.GroupStage, entrants [][]int) (
+	*gormmodel.Tournament, bool) {
+	existing, err := util.PopulateTournament(record.Eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to fetch the tournament under event %d", record.Eid))
+		return nil, false
+	}
+	if existing != nil {
+		return nil, true
+	}
+
+	qualifiers, err := stage.Qualifiers()
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	kind, err := tournament.ParseKind(record.Knockout)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	bracket, err := tournament.New(kind, len(qualifiers))
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	var knockoutEntrants [][]int
+	for _, seed := range qualifiers {
+		knockoutEntrants = append(knockoutEntrants, entrants[seed-1])
+	}
+
+	knockout := &gormmodel.Tournament{Eid: record.Eid}
+	err = util.EncodeTournament(knockout, bracket, knockoutEntrants)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to encode the knockout: %v", err))
+		return nil, false
+	}
+	return knockout, true
+}
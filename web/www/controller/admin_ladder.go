@@ -0,0 +1,436 @@
+package controller
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yushenli/badminton_match_table/pkg/ladder"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"github.com/yushenli/badminton_match_table/web/lib/util"
+	"gorm.io/gorm"
+)
+
+// loadAdminLadder loads the ladder whose lid is given in the URL path, and checks the visitor
+// has admin privilege to it. An error page is rendered if anything goes wrong.
+func loadAdminLadder(ctx *gin.Context) (*gormmodel.Ladder, bool) {
+	if config.DB == nil {
+		RenderError(ctx, http.StatusInternalServerError, "Unable to connect to database. Please contact the admin.")
+		return nil, false
+	}
+
+	lidStr := ctx.Param("lid")
+	lid, err := strconv.Atoi(lidStr)
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Invalid lid provided: %q", lidStr))
+		return nil, false
+	}
+
+	var record gormmodel.Ladder
+	ret := config.DB.First(&record, lid)
+	if ret.Error != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to locate the ladder by lid %d: %v", lid, ret.Error))
+		return nil, false
+	}
+
+	if !util.HasLadderAdminPrivilege(ctx, record) {
+		RenderError(ctx, http.StatusForbidden,
+			fmt.Sprintf("You do not have admin privilege to ladder %d", lid))
+		return nil, false
+	}
+
+	return &record, true
+}
+
+// pendingChallenges returns the open challenges which have not lapsed, as ladder.Challenge.
+func pendingChallenges(rules ladder.Rules, open []gormmodel.Challenge, now time.Time) []ladder.Challenge {
+	var ret []ladder.Challenge
+	for _, challenge := range open {
+		c := util.ToLadderChallenge(challenge)
+		if !rules.Expired(c, now) {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
+
+// LadderForm returns the forms for changing the ranking and the rules of a ladder, and for creating
+// and scheduling challenges. The current ladder is shown above the forms.
+func LadderForm(ctx *gin.Context) {
+	record, ok := loadAdminLadder(ctx)
+	if !ok {
+		return
+	}
+	lid := int(record.ID)
+	ranking, open, played, ok := loadLadder(ctx, lid)
+	if !ok {
+		return
+	}
+
+	var schedule strings.Builder
+	for _, challenge := range open {
+		if challenge.Mid != 0 {
+			continue
+		}
+		schedule.WriteString(fmt.Sprintf(`	<form method="get" action="/admin/ladder/%d/challenge/%d/schedule">
+		%s against %s in event <input type="text" size="6" name="eid"> <input type="submit" value="Schedule">
+	</form>
+`, lid, challenge.ID, html.EscapeString(challenge.Challenger), html.EscapeString(challenge.Defender)))
+	}
+
+	ctx.Writer.WriteString(`
+<html>
+<head>
+	<style>
+		body {
+			font-family: Courier New;
+			font-weight: bold;
+		}
+		td, th {
+			padding: 2px 8px;
+		}
+	</style>
+</head>
+<body>
+` + renderLadder(*record, ranking, open, played) + fmt.Sprintf("\t<p><a href=\"/admin/ladder/%d/settle\">Settle the challenges</a>\n", lid) +
+		schedule.String() + fmt.Sprintf(`	<h4>New challenge</h4>
+	<form id="challengeform" method="post" action="/admin/ladder/%d/challenge">
+		<select name="challenger">
+`, lid) + selectOptions(ranking, "") + `		</select>
+		challenges
+		<select name="defender">
+` + selectOptions(ranking, "") + `		</select>
+		<input type="submit">
+	</form>
+	<h4>Ladder</h4>
+	<form id="ladderform" method="post">
+		<p>Places above their own a player may challenge:
+		<input type="text" size="4" name="reach" value="` + strconv.Itoa(record.Reach) + `">
+		<p>Days a challenge may wait to be scheduled (0 for no limit):
+		<input type="text" size="4" name="expiry_days" value="` + strconv.Itoa(record.ExpiryDays) + `">
+		<p>Players from the top down, one per line. New players join at the bottom:<br>
+		<textarea name="ranking" rows="20" cols="40">` + html.EscapeString(strings.Join(ranking, "\n")) + `</textarea>
+		<p>
+		<input type="submit">
+	</form>
+</body>
+</html>
+	`)
+}
+
+// LadderSubmit takes the form for changing the ranking and the rules of a ladder.
+func LadderSubmit(ctx *gin.Context) {
+	record, ok := loadAdminLadder(ctx)
+	if !ok {
+		return
+	}
+	lid := int(record.ID)
+
+	reach, err := strconv.Atoi(strings.TrimSpace(ctx.PostForm("reach")))
+	if err != nil || reach < 1 {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Invalid reach provided: %q", ctx.PostForm("reach")))
+		return
+	}
+	expiryDays, err := strconv.Atoi(strings.TrimSpace(ctx.PostForm("expiry_days")))
+	if err != nil || expiryDays < 0 {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Invalid expiry days provided: %q", ctx.PostForm("expiry_days")))
+		return
+	}
+
+	var ranking ladder.Ladder
+	for idx, line := range strings.Split(ctx.PostForm("ranking"), "\n") {
+		name := strings.TrimSpace(line)
+		if name == "" {
+			continue
+		}
+		if ranking.Position(name) != 0 {
+			RenderError(ctx, http.StatusBadRequest,
+				fmt.Sprintf("Invalid entry on row %d , %q is already on the ladder", idx+1, name))
+			return
+		}
+		ranking = ranking.Join(name)
+	}
+
+	open, err := util.PopulateChallenges(lid, gormmodel.CHALLENGEOPEN)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list the open challenges of ladder %d", lid))
+		return
+	}
+	for _, challenge := range open {
+		for _, name := range []string{challenge.Challenger, challenge.Defender} {
+			if ranking.Position(name) == 0 {
+				RenderError(ctx, http.StatusBadRequest,
+					fmt.Sprintf("%q may not leave the ladder while in an open challenge", name))
+				return
+			}
+		}
+	}
+
+	record.Reach = reach
+	record.ExpiryDays = expiryDays
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		ret := tx.Save(record)
+		if ret.Error != nil {
+			return ret.Error
+		}
+		return util.SaveLadderRungs(tx, lid, ranking)
+	})
+	if err != nil {
+		log.Printf("Failed when saving ladder %d: %v", lid, err)
+		RenderError(ctx, http.StatusInternalServerError, "Failed when saving the ladder")
+		return
+	}
+
+	ctx.Writer.WriteString(fmt.Sprintf("Updated ladder %d: %d player(s), reach=%d, expiry=%d day(s)\n",
+		lid, len(ranking), reach, expiryDays))
+}
+
+// ChallengeSubmit takes the form for creating a challenge on a ladder.
+func ChallengeSubmit(ctx *gin.Context) {
+	record, ok := loadAdminLadder(ctx)
+	if !ok {
+		return
+	}
+	lid := int(record.ID)
+
+	ranking, open, _, ok := loadLadder(ctx, lid)
+	if !ok {
+		return
+	}
+	challenger := strings.TrimSpace(ctx.PostForm("challenger"))
+	defender := strings.TrimSpace(ctx.PostForm("defender"))
+	rules := util.LadderRules(*record)
+	err := rules.Check(ranking, pendingChallenges(rules, open, time.Now()), challenger, defender)
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	challenge := gormmodel.Challenge{
+		Lid:        lid,
+		Challenger: challenger,
+		Defender:   defender,
+		Status:     gormmodel.CHALLENGEOPEN,
+	}
+	ret := config.DB.Create(&challenge)
+	if ret.Error != nil {
+		log.Printf("Failed when creating a challenge on ladder %d: %v", lid, ret.Error)
+		RenderError(ctx, http.StatusInternalServerError, "Failed when creating the challenge")
+		return
+	}
+
+	ctx.Writer.WriteString(fmt.Sprintf("Created challenge %d: %q challenges %q\n", challenge.ID, challenger, defender))
+}
+
+// ScheduleChallenge schedules an open challenge of a ladder as a singles match on a free court in
+// the current round of the event given by eid, whose players include both sides of the challenge.
+// The visitor must have admin privilege to both. The match is only created when proceed=1 is given.
+func ScheduleChallenge(ctx *gin.Context) {
+	record, ok := loadAdminLadder(ctx)
+	if !ok {
+		return
+	}
+	lid := int(record.ID)
+
+	cidStr := ctx.Param("cid")
+	cid, err := strconv.Atoi(cidStr)
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Invalid cid provided: %q", cidStr))
+		return
+	}
+	var challenge gormmodel.Challenge
+	ret := config.DB.Where("lid = ?", lid).First(&challenge, cid)
+	if ret.Error != nil {
+		RenderError(ctx, http.StatusNotFound,
+			fmt.Sprintf("Unable to find challenge %d on ladder %d", cid, lid))
+		return
+	}
+	if challenge.Status != gormmodel.CHALLENGEOPEN || challenge.Mid != 0 {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Challenge %d is not waiting to be scheduled", cid))
+		return
+	}
+	if util.LadderRules(*record).Expired(util.ToLadderChallenge(challenge), time.Now()) {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Challenge %d has lapsed", cid))
+		return
+	}
+
+	eidStr := ctx.Query("eid")
+	eid, err := strconv.Atoi(eidStr)
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Invalid eid provided: %q", eidStr))
+		return
+	}
+	var event gormmodel.Event
+	ret = config.DB.First(&event, eid)
+	if ret.Error != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to locate the event by eid %d: %v", eid, ret.Error))
+		return
+	}
+	if !util.HasAdminPrivilege(ctx, event) {
+		RenderError(ctx, http.StatusForbidden,
+			fmt.Sprintf("You do not have admin privilege to event %d", eid))
+		return
+	}
+
+	players, playerMap, err := util.PopulatePlayers(eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list players under event %d", eid))
+		return
+	}
+	playerIDs := make(map[string]int)
+	for _, player := range players {
+		playerIDs[player.Name] = int(player.ID)
+	}
+	for _, name := range []string{challenge.Challenger, challenge.Defender} {
+		if _, ok := playerIDs[name]; !ok {
+			RenderError(ctx, http.StatusBadRequest,
+				fmt.Sprintf("%q is not a player of event %d", name, eid))
+			return
+		}
+	}
+
+	_, sideMap, err := util.PopulateSides(eid, playerMap, nil)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list sides under event %d", eid))
+		return
+	}
+	_, matchesByRound, err := util.PopulateMatches(eid, event.CurrentRound, sideMap)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list matches under event %d", eid))
+		return
+	}
+	courts := newCourtAssigner(event, matchesByRound[event.CurrentRound-1])
+	match, ok := courts.assign(util.ChallengeMatch(playerIDs[challenge.Challenger], playerIDs[challenge.Defender], event, 0))
+	if !ok {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("No court is free in round %d of event %d, or a player is still playing", event.CurrentRound, eid))
+		return
+	}
+
+	if ctx.Query("proceed") != "1" {
+		ctx.Writer.WriteString(fmt.Sprintf("<p>%s against %s on court %d in round %d of event %d.\n",
+			html.EscapeString(challenge.Challenger), html.EscapeString(challenge.Defender), match.Court, event.CurrentRound, eid))
+		ctx.Writer.WriteString(fmt.Sprintf("<p><a href=\"%s?eid=%d&proceed=1\">Proceed</a>\n", html.EscapeString(ctx.Request.URL.Path), eid))
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		matches := []gormmodel.Match{match}
+		err := createMatches(tx, matches)
+		if err != nil {
+			return err
+		}
+		challenge.Mid = int(matches[0].ID)
+		return tx.Save(&challenge).Error
+	})
+	if err != nil {
+		log.Printf("Failed when scheduling challenge %d in event %d: %v", cid, eid, err)
+		RenderError(ctx, http.StatusInternalServerError, "Failed when creating new matches/sides")
+		return
+	}
+
+	ctx.Writer.WriteString(fmt.Sprintf("Scheduled challenge %d as match %d on court %d.\n", cid, challenge.Mid, match.Court))
+}
+
+// SettleLadder applies the results of the challenges whose matches have been decided, in the order
+// the challenges were created, and lapses the challenges which have waited too long to be scheduled.
+// A challenge whose match has been deleted, e.g. by rescheduling the round, waits to be scheduled
+// again. The changes are only saved when proceed=1 is given.
+func SettleLadder(ctx *gin.Context) {
+	record, ok := loadAdminLadder(ctx)
+	if !ok {
+		return
+	}
+	lid := int(record.ID)
+
+	ranking, open, _, ok := loadLadder(ctx, lid)
+	if !ok {
+		return
+	}
+
+	rules := util.LadderRules(*record)
+	now := time.Now()
+	var sb strings.Builder
+	var changed []gormmodel.Challenge
+	for _, challenge := range open {
+		if challenge.Mid != 0 {
+			var found []gormmodel.Match
+			ret := config.DB.Where("id = ?", challenge.Mid).Find(&found)
+			if ret.Error != nil {
+				log.Printf("Failed to locate match %d of challenge %d: %v", challenge.Mid, challenge.ID, ret.Error)
+				RenderError(ctx, http.StatusInternalServerError, "Failed to locate the match of a challenge")
+				return
+			}
+			switch {
+			case len(found) == 0:
+				challenge.Mid = 0
+				sb.WriteString(fmt.Sprintf("<p>The match of %s against %s is gone, waiting to be scheduled again.\n",
+					html.EscapeString(challenge.Challenger), html.EscapeString(challenge.Defender)))
+			case found[0].Status == gormmodel.PLAYING:
+				continue
+			default:
+				challenge.Status = gormmodel.CHALLENGEPLAYED
+				challenge.ChallengerWon = found[0].Status == gormmodel.SIDE1WON
+				ranking = ranking.Apply(challenge.Challenger, challenge.Defender, challenge.ChallengerWon)
+				winner := challenge.Defender
+				if challenge.ChallengerWon {
+					winner = challenge.Challenger
+				}
+				sb.WriteString(fmt.Sprintf("<p>%s won the challenge of %s against %s.\n", html.EscapeString(winner),
+					html.EscapeString(challenge.Challenger), html.EscapeString(challenge.Defender)))
+			}
+			changed = append(changed, challenge)
+			continue
+		}
+		if rules.Expired(util.ToLadderChallenge(challenge), now) {
+			challenge.Status = gormmodel.CHALLENGEEXPIRED
+			sb.WriteString(fmt.Sprintf("<p>The challenge of %s against %s has lapsed.\n",
+				html.EscapeString(challenge.Challenger), html.EscapeString(challenge.Defender)))
+			changed = append(changed, challenge)
+		}
+	}
+
+	if ctx.Query("proceed") != "1" {
+		ctx.Writer.WriteString(fmt.Sprintf("<p>Settling %d challenge(s).\n", len(changed)))
+		ctx.Writer.WriteString(sb.String())
+		ctx.Writer.WriteString(fmt.Sprintf("<p><a href=\"%s?proceed=1\">Proceed</a>\n", html.EscapeString(ctx.Request.URL.Path)))
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for idx := range changed {
+			ret := tx.Save(&changed[idx])
+			if ret.Error != nil {
+				return ret.Error
+			}
+		}
+		return util.SaveLadderRungs(tx, lid, ranking)
+	})
+	if err != nil {
+		log.Printf("Failed when settling ladder %d: %v", lid, err)
+		RenderError(ctx, http.StatusInternalServerError, "Failed when settling the ladder")
+		return
+	}
+
+	ctx.Writer.WriteString(fmt.Sprintf("<p>Settled %d challenge(s).\n", len(changed)))
+	ctx.Writer.WriteString(sb.String())
+}
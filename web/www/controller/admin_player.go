@@ -5,10 +5,12 @@ import (
 	"encoding/csv"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/yushenli/badminton_match_table/pkg/model"
 	"github.com/yushenli/badminton_match_table/web/lib/config"
 	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
 	"github.com/yushenli/badminton_match_table/web/lib/util"
@@ -53,12 +55,30 @@ func PlayersForm(ctx *gin.Context) {
 		return
 	}
 
+	teams, err := util.PopulateTeams(eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list teams by eid %d: %v", eid, err))
+		return
+	}
+	teammateIDs := util.TeammateIDs(teams)
+	playerNames := make(map[int]string)
+	for _, player := range players {
+		playerNames[int(player.ID)] = player.Name
+	}
+
 	var playerEntries [][]string
 	for _, player := range players {
+		teammate := ""
+		if teammateID, ok := teammateIDs[int(player.ID)]; ok {
+			teammate = playerNames[teammateID]
+		}
 		playerEntries = append(playerEntries, []string{
 			player.Name,
 			fmt.Sprintf("%0.1f", player.Priority),
 			fmt.Sprintf("%0.1f", player.InitialScore),
+			player.Category,
+			teammate,
 		})
 	}
 
@@ -90,8 +110,11 @@ func PlayersForm(ctx *gin.Context) {
 }
 
 // PlayersSubmit takes the form for adding/updating players for a given event.
-// The input is expected to be a CSV where each line contains the player's name, priority and initial score.
+// The input is expected to be a CSV where each line contains the player's name, priority and initial score,
+// optionally followed by the player's category (M or F) and the name of their fixed partner.
 // Whether is player is an existing one or to be added is determined by their name.
+// A row giving the fixed partner field replaces the player's fixed partnership, or removes it if
+// the field is empty. The fixed partnerships of the players on the other rows are kept.
 func PlayersSubmit(ctx *gin.Context) {
 	if config.DB == nil {
 		RenderError(ctx, http.StatusInternalServerError, "Unable to connect to database. Please contact the admin.")
@@ -132,67 +155,57 @@ func PlayersSubmit(ctx *gin.Context) {
 		playerMap[players[idx].Name] = &players[idx]
 	}
 
-	reader := csv.NewReader(strings.NewReader(ctx.PostForm("players")))
-	playerEntries, err := reader.ReadAll()
+	entries, err := parsePlayerEntries(ctx.PostForm("players"))
 	if err != nil {
-		RenderError(ctx, http.StatusBadRequest,
-			fmt.Sprintf("Unable to parse the players in CSV: %v", err))
+		RenderError(ctx, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	var playersToUpdate []*gormmodel.Player
 	var playersToCreate []gormmodel.Player
-	for idx, entry := range playerEntries {
-		if len(entry) == 0 {
-			continue
-		}
-		if len(entry) != 3 {
-			RenderError(ctx, http.StatusBadRequest,
-				fmt.Sprintf("Invalid entry on row %d , 3 fields are expected: %+v", idx+1, entry))
-			return
-		}
-
-		name := strings.TrimSpace(entry[0])
-		if name == "" {
-			RenderError(ctx, http.StatusBadRequest,
-				fmt.Sprintf("Invalid entry on row %d , name cannot be empty: %+v", idx+1, entry))
-			return
-		}
-
-		priority, err := strconv.ParseFloat(strings.TrimSpace(entry[1]), 32)
-		if err != nil {
-			RenderError(ctx, http.StatusBadRequest,
-				fmt.Sprintf("Invalid entry on row %d , priority needs to be a valid float: %+v", idx+1, entry))
-			return
-		}
-
-		initialScore, err := strconv.ParseFloat(strings.TrimSpace(entry[2]), 32)
-		if err != nil {
-			RenderError(ctx, http.StatusBadRequest,
-				fmt.Sprintf("Invalid entry on row %d , initial score needs to be a valid float: %+v", idx+1, entry))
-			return
+	teammates := make(map[string]string)
+	knownNames := make(map[string]bool)
+	for name := range playerMap {
+		knownNames[name] = true
+	}
+	for _, entry := range entries {
+		knownNames[entry.Name] = true
+		if entry.Teammate != "" {
+			teammates[entry.Name] = entry.Teammate
 		}
 
-		player, ok := playerMap[name]
+		player, ok := playerMap[entry.Name]
 		if ok {
-			player.Priority = float32(priority)
-			player.InitialScore = float32(initialScore)
+			player.Priority = entry.Priority
+			player.InitialScore = entry.InitialScore
+			player.Category = entry.Category
 			playersToUpdate = append(playersToUpdate, player)
 		} else {
 			playersToCreate = append(playersToCreate, gormmodel.Player{
-				Name:         name,
+				Name:         entry.Name,
 				Eid:          eid,
-				Priority:     float32(priority),
-				InitialScore: float32(initialScore),
+				Priority:     entry.Priority,
+				InitialScore: entry.InitialScore,
 				InBreak:      true,
+				Category:     entry.Category,
 			})
 		}
+	}
 
-		if len(playersToUpdate) == 0 && len(playersToCreate) == 0 {
-			RenderError(ctx, http.StatusBadRequest,
-				fmt.Sprintf("No player entries is provided."))
-			return
-		}
+	teamPairs, err := teamPairsFromNames(teammates, knownNames)
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+	existingTeams, err := util.PopulateTeams(eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list teams by eid %d: %v", eid, err))
+		return
+	}
+	playerNames := make(map[int]string)
+	for _, player := range players {
+		playerNames[int(player.ID)] = player.Name
 	}
 
 	err = config.DB.Transaction(func(tx *gorm.DB) error {
@@ -210,7 +223,37 @@ func PlayersSubmit(ctx *gin.Context) {
 			ctx.Writer.WriteString(fmt.Sprintf("To be created: %+v\n", playersToCreate[idx]))
 		}
 		if len(playersToCreate) > 0 {
-			tx.Create(&playersToCreate)
+			ret := tx.Create(&playersToCreate)
+			if ret.Error != nil {
+				return ret.Error
+			}
+		}
+
+		for idx := range playersToCreate {
+			playerMap[playersToCreate[idx].Name] = &playersToCreate[idx]
+		}
+		teamsToDelete, pairsToCreate := diffTeams(existingTeams, playerNames, entries, teamPairs)
+		for _, team := range teamsToDelete {
+			ctx.Writer.WriteString(fmt.Sprintf("Team to be deleted: %+v\n", team))
+			ret := tx.Delete(&team)
+			if ret.Error != nil {
+				return ret.Error
+			}
+		}
+		var teams []gormmodel.Team
+		for _, pair := range pairsToCreate {
+			player1, ok1 := playerMap[pair[0]]
+			player2, ok2 := playerMap[pair[1]]
+			if !ok1 || !ok2 {
+				return fmt.Errorf("unknown player in team %s / %s", pair[0], pair[1])
+			}
+			teams = append(teams, gormmodel.Team{Eid: eid, Pid1: int(player1.ID), Pid2: int(player2.ID)})
+		}
+		for _, team := range teams {
+			ctx.Writer.WriteString(fmt.Sprintf("Team to be created: %+v\n", team))
+		}
+		if len(teams) > 0 {
+			ret := tx.Create(&teams)
 			if ret.Error != nil {
 				return ret.Error
 			}
@@ -224,3 +267,148 @@ func PlayersSubmit(ctx *gin.Context) {
 		return
 	}
 }
+
+// playerEntry is a player given on a row of the players form.
+type playerEntry struct {
+	Name         string
+	Priority     float32
+	InitialScore float32
+	Category     string
+	// Teammate is the name of the player's fixed partner, empty if they have none.
+	Teammate string
+	// HasTeammate is set if the row gives the teammate field, even an empty one.
+	HasTeammate bool
+}
+
+// parsePlayerEntries parses the players form. Each row contains the player's name, priority and
+// initial score, optionally followed by the player's category (M or F) and the name of their fixed
+// partner. Rows may have different numbers of fields, and empty rows are skipped.
+func parsePlayerEntries(text string) ([]playerEntry, error) {
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse the players in CSV: %v", err)
+	}
+
+	var entries []playerEntry
+	for idx, row := range rows {
+		if len(row) == 0 || (len(row) == 1 && strings.TrimSpace(row[0]) == "") {
+			continue
+		}
+		if len(row) < 3 || len(row) > 5 {
+			return nil, fmt.Errorf("Invalid entry on row %d , 3 to 5 fields are expected: %+v", idx+1, row)
+		}
+
+		entry := playerEntry{Name: strings.TrimSpace(row[0]), Category: model.CategoryUnknown}
+		if entry.Name == "" {
+			return nil, fmt.Errorf("Invalid entry on row %d , name cannot be empty: %+v", idx+1, row)
+		}
+
+		priority, err := strconv.ParseFloat(strings.TrimSpace(row[1]), 32)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid entry on row %d , priority needs to be a valid float: %+v", idx+1, row)
+		}
+		entry.Priority = float32(priority)
+
+		initialScore, err := strconv.ParseFloat(strings.TrimSpace(row[2]), 32)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid entry on row %d , initial score needs to be a valid float: %+v", idx+1, row)
+		}
+		entry.InitialScore = float32(initialScore)
+
+		if len(row) >= 4 {
+			entry.Category = strings.ToUpper(strings.TrimSpace(row[3]))
+			if !model.ValidCategory(entry.Category) {
+				return nil, fmt.Errorf("Invalid entry on row %d , category needs to be M, F or empty: %+v", idx+1, row)
+			}
+		}
+
+		if len(row) == 5 {
+			entry.Teammate = strings.TrimSpace(row[4])
+			entry.HasTeammate = true
+			if entry.Teammate == entry.Name {
+				return nil, fmt.Errorf("Invalid entry on row %d , a player cannot team up with themselves: %+v", idx+1, row)
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("No player entries is provided.")
+	}
+	return entries, nil
+}
+
+// diffTeams works out how the existing teams change by the players form: the teams to be deleted,
+// and the pairs of names to be created as teams. A team is kept if neither of its players gives
+// the fixed partner field on their row nor is named in any of the pairs, or if it's one of the
+// pairs itself. playerNames maps the IDs of the existing players to their names.
+func diffTeams(existing []gormmodel.Team, playerNames map[int]string, entries []playerEntry, pairs [][2]string) ([]gormmodel.Team, [][2]string) {
+	changed := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.HasTeammate {
+			changed[entry.Name] = true
+		}
+	}
+	pairOf := make(map[string]string)
+	for _, pair := range pairs {
+		changed[pair[0]], changed[pair[1]] = true, true
+		pairOf[pair[0]], pairOf[pair[1]] = pair[1], pair[0]
+	}
+
+	var toDelete []gormmodel.Team
+	kept := make(map[string]bool)
+	for _, team := range existing {
+		name1, name2 := playerNames[team.Pid1], playerNames[team.Pid2]
+		if pairOf[name1] == name2 && name2 != "" {
+			kept[name1] = true
+			continue
+		}
+		if changed[name1] || changed[name2] {
+			toDelete = append(toDelete, team)
+		}
+	}
+
+	var toCreate [][2]string
+	for _, pair := range pairs {
+		if !kept[pair[0]] {
+			toCreate = append(toCreate, pair)
+		}
+	}
+	return toDelete, toCreate
+}
+
+// teamPairsFromNames turns the fixed partners given by each player's name into pairs of names.
+// A pair only needs to be given by one of its players, but a player cannot be in two pairs.
+// Returns error if a fixed partner is not among the known names, i.e. the players of the event
+// and the ones being added.
+func teamPairsFromNames(teammates map[string]string, known map[string]bool) ([][2]string, error) {
+	var names []string
+	for name := range teammates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var pairs [][2]string
+	paired := make(map[string]string)
+	for _, name := range names {
+		teammate := teammates[name]
+		if !known[teammate] {
+			return nil, fmt.Errorf("%s cannot team up with %s, who is not a player of the event", name, teammate)
+		}
+		if paired[name] == teammate {
+			continue
+		}
+		if other, ok := paired[name]; ok {
+			return nil, fmt.Errorf("%s cannot team up with both %s and %s", name, other, teammate)
+		}
+		if other, ok := paired[teammate]; ok {
+			return nil, fmt.Errorf("%s cannot team up with both %s and %s", teammate, other, name)
+		}
+		paired[name] = teammate
+		paired[teammate] = name
+		pairs = append(pairs, [2]string{name, teammate})
+	}
+	return pairs, nil
+}
@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yushenli/badminton_match_table/pkg/model"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+)
+
+func TestParsePlayerEntries(t *testing.T) {
+	cases := []struct {
+		title       string
+		text        string
+		expected    []playerEntry
+		expectedErr bool
+	}{
+		{
+			"MixedWidths",
+			"Alice,1,2\nBob,0,1.5,M\n\nCarol,2,3,f,Dave\nDave, 0, 0, F, Carol\n",
+			[]playerEntry{
+				{Name: "Alice", Priority: 1, InitialScore: 2, Category: model.CategoryUnknown},
+				{Name: "Bob", Priority: 0, InitialScore: 1.5, Category: model.CategoryMale},
+				{Name: "Carol", Priority: 2, InitialScore: 3, Category: model.CategoryFemale, Teammate: "Dave", HasTeammate: true},
+				{Name: "Dave", Priority: 0, InitialScore: 0, Category: model.CategoryFemale, Teammate: "Carol", HasTeammate: true},
+			},
+			false,
+		},
+		{
+			"EmptyTeammate",
+			"Alice,1,2,,\n",
+			[]playerEntry{
+				{Name: "Alice", Priority: 1, InitialScore: 2, Category: model.CategoryUnknown, HasTeammate: true},
+			},
+			false,
+		},
+		{
+			"TooFewFields",
+			"Alice,1,2\nBob,0\n",
+			nil,
+			true,
+		},
+		{
+			"TooManyFields",
+			"Alice,1,2,M,Bob,Carol\n",
+			nil,
+			true,
+		},
+		{
+			"InvalidPriority",
+			"Alice,high,2\n",
+			nil,
+			true,
+		},
+		{
+			"InvalidCategory",
+			"Alice,1,2,X\n",
+			nil,
+			true,
+		},
+		{
+			"SelfTeammate",
+			"Alice,1,2,F,Alice\n",
+			nil,
+			true,
+		},
+		{
+			"Empty",
+			"\n",
+			nil,
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			entries, err := parsePlayerEntries(tc.text)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("Expected error but got nil.")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			if !reflect.DeepEqual(entries, tc.expected) {
+				t.Errorf("Unexpected entries, expected %+v got %+v", tc.expected, entries)
+			}
+		})
+	}
+}
+
+func TestDiffTeams(t *testing.T) {
+	playerNames := map[int]string{1: "Alice", 2: "Bob", 3: "Carol", 4: "Dave", 5: "Erin", 6: "Frank"}
+	aliceBob := gormmodel.Team{Eid: 1, Pid1: 1, Pid2: 2}
+	carolDave := gormmodel.Team{Eid: 1, Pid1: 3, Pid2: 4}
+	existing := []gormmodel.Team{aliceBob, carolDave}
+
+	cases := []struct {
+		title            string
+		entries          []playerEntry
+		pairs            [][2]string
+		expectedToDelete []gormmodel.Team
+		expectedToCreate [][2]string
+	}{
+		{
+			"NoTeammateFields",
+			[]playerEntry{{Name: "Alice"}, {Name: "Carol"}, {Name: "Erin"}},
+			nil,
+			nil,
+			nil,
+		},
+		{
+			"SameTeam",
+			[]playerEntry{{Name: "Alice", Teammate: "Bob", HasTeammate: true}},
+			[][2]string{{"Alice", "Bob"}},
+			nil,
+			nil,
+		},
+		{
+			"EmptyTeammateField",
+			[]playerEntry{{Name: "Bob", HasTeammate: true}, {Name: "Carol"}},
+			nil,
+			[]gormmodel.Team{aliceBob},
+			nil,
+		},
+		{
+			"NewTeamTakesAnExistingPlayer",
+			[]playerEntry{{Name: "Erin", Teammate: "Dave", HasTeammate: true}},
+			[][2]string{{"Erin", "Dave"}},
+			[]gormmodel.Team{carolDave},
+			[][2]string{{"Erin", "Dave"}},
+		},
+		{
+			"NewPlayers",
+			[]playerEntry{{Name: "Gina", Teammate: "Frank", HasTeammate: true}},
+			[][2]string{{"Gina", "Frank"}},
+			nil,
+			[][2]string{{"Gina", "Frank"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			toDelete, toCreate := diffTeams(existing, playerNames, tc.entries, tc.pairs)
+			if !reflect.DeepEqual(toDelete, tc.expectedToDelete) {
+				t.Errorf("Unexpected teams to delete, expected %+v got %+v", tc.expectedToDelete, toDelete)
+			}
+			if !reflect.DeepEqual(toCreate, tc.expectedToCreate) {
+				t.Errorf("Unexpected teams to create, expected %+v got %+v", tc.expectedToCreate, toCreate)
+			}
+		})
+	}
+}
+
+func TestTeamPairsFromNames(t *testing.T) {
+	known := map[string]bool{"Alice": true, "Bob": true, "Carol": true, "Dave": true}
+	cases := []struct {
+		title         string
+		teammates     map[string]string
+		expected      [][2]string
+		expectedError bool
+	}{
+		{"GivenByBoth", map[string]string{"Alice": "Bob", "Bob": "Alice"}, [][2]string{{"Alice", "Bob"}}, false},
+		{"TwoTeams", map[string]string{"Alice": "Bob", "Dave": "Carol"},
+			[][2]string{{"Alice", "Bob"}, {"Dave", "Carol"}}, false},
+		{"TwoTeammates", map[string]string{"Alice": "Bob", "Carol": "Bob"}, nil, true},
+		{"UnknownTeammate", map[string]string{"Alice": "Bobby"}, nil, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.title, func(t *testing.T) {
+			pairs, err := teamPairsFromNames(tc.teammates, known)
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("Expected error but got nil.")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Not expecting error but got %v.", err)
+				return
+			}
+			if !reflect.DeepEqual(pairs, tc.expected) {
+				t.Errorf("Unexpected pairs, expected %+v got %+v", tc.expected, pairs)
+			}
+		})
+	}
+}
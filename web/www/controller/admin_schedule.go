@@ -0,0 +1,295 @@
+package controller
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yushenli/badminton_match_table/pkg/arranger"
+	"github.com/yushenli/badminton_match_table/pkg/model"
+	"github.com/yushenli/badminton_match_table/pkg/rotation"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"github.com/yushenli/badminton_match_table/web/lib/util"
+	"gorm.io/gorm"
+)
+
+// loadArrangerInput builds the input to arrange the current round of the event with, from
+// the players and their history read through db, and looks up the arranger of the event. An
+// error page is rendered if anything goes wrong.
+func loadArrangerInput(ctx *gin.Context, db *gorm.DB, event gormmodel.Event) (arranger.Input, arranger.Arranger, bool) {
+	eid := int(event.ID)
+
+	players, playerMap, err := util.PopulatePlayersIn(db, eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list players under event %d", eid))
+		return arranger.Input{}, nil, false
+	}
+
+	sides, sideMap, err := util.PopulateSidesIn(db, int(event.ID), playerMap, &event.CurrentRound)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list sides under event %d", eid))
+		return arranger.Input{}, nil, false
+	}
+
+	util.FillPlayerCounter(playerMap, sides)
+
+	_, matchesByRound, err := util.PopulateMatchesIn(db, int(event.ID), event.CurrentRound, sideMap)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list matches under event %d", eid))
+		return arranger.Input{}, nil, false
+	}
+	if !fillPlayerRatings(ctx, event, playerMap, matchesByRound) {
+		return arranger.Input{}, nil, false
+	}
+
+	activePlayers := util.FilterActivePlayers(players)
+	allArrangerPlayers := util.ToArrangerPlayersP(players)
+	activeArrangerPlayers := util.ToArrangerPlayers(activePlayers)
+	util.FillArrangerPlayersHistory(activeArrangerPlayers, sides)
+
+	teams, err := util.PopulateTeamsIn(db, eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list teams under event %d", eid))
+		return arranger.Input{}, nil, false
+	}
+	util.FillArrangerPlayersTeams(activeArrangerPlayers, teams)
+
+	playerConstraints, err := util.PopulateConstraintsIn(db, eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list constraints under event %d", eid))
+		return arranger.Input{}, nil, false
+	}
+	constraints := util.ToArrangerConstraints(playerConstraints, activeArrangerPlayers)
+
+	util.FillArrangerPlayersRoundsWaited(activeArrangerPlayers, matchesByRound, event.CurrentRound)
+
+	catchUp, err := arranger.ParseCatchUpPolicy(event.CatchUp)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Error when parsing the catch-up policy of event %d: %v", eid, err))
+		return arranger.Input{}, nil, false
+	}
+	util.FillArrangerPlayersCatchUp(catchUp, activeArrangerPlayers, players, matchesByRound)
+
+	eventArranger, err := arranger.Lookup(event.Arranger)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Error when looking up the arranger of event %d: %v", eid, err))
+		return arranger.Input{}, nil, false
+	}
+
+	costModel, err := arranger.ParseCostModel(event.CostModel)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Error when parsing the cost model of event %d: %v", eid, err))
+		return arranger.Input{}, nil, false
+	}
+
+	banding, err := arranger.ParseBanding(event.Banding)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Error when parsing the banding of event %d: %v", eid, err))
+		return arranger.Input{}, nil, false
+	}
+
+	format, err := arranger.ParseFormat(event.Format)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Error when parsing the format of event %d: %v", eid, err))
+		return arranger.Input{}, nil, false
+	}
+
+	return arranger.Input{
+		AllPlayers:   allArrangerPlayers,
+		Players:      activeArrangerPlayers,
+		CourtCount:   event.Courts,
+		Seed:         event.CurrentRound,
+		Cost:         &costModel,
+		Banding:      banding,
+		Format:       format,
+		BalanceSides: event.BalanceSides,
+		Constraints:  constraints,
+	}, eventArranger, true
+}
+
+// arrangeCurrentRound arranges the current round of the event. If rounds have been planned ahead,
+// the planned current round is used as long as the same players are available as when it was
+// planned. Otherwise, e.g. someone has taken a break, the remaining planned rounds are re-planned
+// for the available players. Returns the arrangement, the re-planned rounds to be saved if any,
+// and a note on how the arrangement was made. The explanation of input is filled.
+func arrangeCurrentRound(event gormmodel.Event, input arranger.Input, eventArranger arranger.Arranger) (
+	model.MatchArrangement, []gormmodel.PlannedRound, string, error) {
+	plannedRounds, err := util.PopulatePlannedRounds(int(event.ID), event.CurrentRound)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to list planned rounds under event %d", event.ID)
+	}
+	if len(plannedRounds) == 0 {
+		arrangement, err := eventArranger.Arrange(input)
+		return arrangement, nil, "Arranged by " + arrangerName(event), err
+	}
+
+	activeKey := util.ActivePlayersKey(input.Players)
+	if plannedRounds[0].Round == event.CurrentRound && plannedRounds[0].Active == activeKey {
+		arrangement, err := util.FromPlannedRound(plannedRounds[0], input.Players)
+		if err == nil {
+			*input.Explanation = arranger.Explain(input, arrangement)
+			return arrangement, nil, fmt.Sprintf("Planned ahead, %d planned round(s) left", len(plannedRounds)), nil
+		}
+		log.Printf("Re-planning event %d: %v", event.ID, err)
+	}
+
+	rounds := plannedRounds[len(plannedRounds)-1].Round - event.CurrentRound + 1
+	plan, err := arranger.PlanRounds(eventArranger, input, rounds)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	replanned, err := util.ToPlannedRounds(plan, event, activeKey)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	*input.Explanation = arranger.Explain(input, plan[0])
+	return plan[0], replanned, fmt.Sprintf("The available players changed since planning, re-planned %d round(s)", rounds), nil
+}
+
+// arrangerName returns the name of the arranger of the event.
+func arrangerName(event gormmodel.Event) string {
+	if event.Arranger == "" {
+		return arranger.DefaultArrangerName
+	}
+	return event.Arranger
+}
+
+// replacePlannedRounds replaces the planned rounds of the event from the first given round on.
+func replacePlannedRounds(tx *gorm.DB, eid int, rounds []gormmodel.PlannedRound) error {
+	if len(rounds) == 0 {
+		return nil
+	}
+	ret := tx.Where("eid = ?", eid).Where("round >= ?", rounds[0].Round).Delete(&gormmodel.PlannedRound{})
+	if ret.Error != nil {
+		return ret.Error
+	}
+	ret = tx.Create(&rounds)
+	return ret.Error
+}
+
+// PlanRounds generates the arrangements of a number of upcoming rounds of an event at once,
+// starting from the current round. The plan is only saved when proceed=1 is given. Planned rounds
+// are then used by ScheduleCurrentRound, which re-plans them when the available players change.
+func PlanRounds(ctx *gin.Context) {
+	eidStr := ctx.Query("eid")
+	roundsStr := ctx.Query("rounds")
+	if eidStr == "" || roundsStr == "" {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("You must provide eid and rounds parameters: %s", ctx.Request.URL.String()))
+		return
+	}
+
+	eid, err := strconv.Atoi(eidStr)
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Invalid eid provided: %q", eidStr))
+		return
+	}
+	rounds, err := strconv.Atoi(roundsStr)
+	if err != nil || rounds <= 0 {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Invalid rounds provided: %q", roundsStr))
+		return
+	}
+
+	if config.DB == nil {
+		RenderError(ctx, http.StatusInternalServerError, "Unable to connect to database. Please contact the admin.")
+		return
+	}
+
+	var event gormmodel.Event
+	ret := config.DB.First(&event, eid)
+	if ret.Error != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to locate the event by eid %d: %v", eid, ret.Error))
+		return
+	}
+
+	if !util.HasAdminPrivilege(ctx, event) {
+		RenderError(ctx, http.StatusForbidden,
+			fmt.Sprintf("You do not have admin privilege to event %d", eid))
+		return
+	}
+	// Planned rounds are only used when all the courts are scheduled at once.
+	if rotation.Mode(event.Mode) != rotation.Rounds {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Event %d runs %s, where rounds cannot be planned ahead", eid, event.Mode))
+		return
+	}
+
+	input, eventArranger, ok := loadArrangerInput(ctx, config.DB, event)
+	if !ok {
+		return
+	}
+
+	plan, err := arranger.PlanRounds(eventArranger, input, rounds)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Error when planning %d rounds based on active players: %v", rounds, err))
+		return
+	}
+	plannedRounds, err := util.ToPlannedRounds(plan, event, util.ActivePlayersKey(input.Players))
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to encode the planned rounds: %v", err))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<html><head><style> body { font-family: Courier New; font-weight: bold; } td, th { padding: 2px 8px; } </style></head><body>\n")
+	sb.WriteString(fmt.Sprintf("<h3>Plan of %d round(s) of event %d by %s</h3>\n", rounds, eid, html.EscapeString(arrangerName(event))))
+	if ctx.Query("proceed") != "1" {
+		sb.WriteString(fmt.Sprintf("<p><a href=\"%s&proceed=1\">Proceed</a>\n", html.EscapeString(ctx.Request.URL.String())))
+	}
+	for idx, arrangement := range plan {
+		sb.WriteString(fmt.Sprintf("<h4>Round %d</h4>\n<table>\n<tr><th>Court</th><th>Side 1</th><th>Side 2</th></tr>\n",
+			event.CurrentRound+idx))
+		for court, match := range arrangement {
+			sb.WriteString(fmt.Sprintf("<tr><td>%d</td><td>%s</td><td>%s</td></tr>\n", court+1,
+				html.EscapeString(sideNames(match.Side1)), html.EscapeString(sideNames(match.Side2))))
+		}
+		sb.WriteString("</table>\n")
+		var benched []string
+		for _, player := range arranger.BenchedPlayers(input.Players, arrangement) {
+			benched = append(benched, player.Name)
+		}
+		sb.WriteString(fmt.Sprintf("<p>Sitting out: %s\n", html.EscapeString(strings.Join(benched, ", "))))
+	}
+
+	if ctx.Query("proceed") == "1" {
+		err = config.DB.Transaction(func(tx *gorm.DB) error {
+			return replacePlannedRounds(tx, eid, plannedRounds)
+		})
+		if err != nil {
+			log.Printf("Failed when saving the planned rounds of event %d: %v", eid, err)
+			RenderError(ctx, http.StatusInternalServerError, "Failed when saving the planned rounds")
+			return
+		}
+		sb.WriteString("<p>Plan saved.\n")
+	}
+	sb.WriteString("</body></html>\n")
+	ctx.Writer.WriteString(sb.String())
+}
+
+// sideNames returns the names of the players in a side, separated by slashes.
+func sideNames(side model.Side) string {
+	if side.Player2 == nil {
+		return side.Player1.Name
+	}
+	return side.Player1.Name + " / " + side.Player2.Name
+}
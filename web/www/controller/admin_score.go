@@ -0,0 +1,200 @@
+package controller
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yushenli/badminton_match_table/pkg/scoring"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"github.com/yushenli/badminton_match_table/web/lib/util"
+	"gorm.io/gorm"
+)
+
+// loadRoundMatches loads the matches of the round given in the query, or of the current round
+// if no round is given, with their sides and games. An error page is rendered if anything goes wrong.
+func loadRoundMatches(ctx *gin.Context, event *gormmodel.Event) (int, []*gormmodel.Match, bool) {
+	eid := int(event.ID)
+	round := event.CurrentRound
+	if roundStr := ctx.Query("round"); roundStr != "" {
+		var err error
+		round, err = strconv.Atoi(roundStr)
+		if err != nil || round < 1 || round > event.CurrentRound {
+			RenderError(ctx, http.StatusBadRequest,
+				fmt.Sprintf("Invalid round provided: %q", roundStr))
+			return 0, nil, false
+		}
+	}
+
+	_, playerMap, err := util.PopulatePlayers(eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list players under event %d", eid))
+		return 0, nil, false
+	}
+	_, sideMap, err := util.PopulateSides(eid, playerMap, nil)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list sides under event %d", eid))
+		return 0, nil, false
+	}
+	_, matchesByRound, err := util.PopulateMatches(eid, event.CurrentRound, sideMap)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list matches under event %d", eid))
+		return 0, nil, false
+	}
+	if len(matchesByRound[round-1]) == 0 {
+		RenderError(ctx, http.StatusBadRequest, fmt.Sprintf("Round %d does not have any matches", round))
+		return 0, nil, false
+	}
+
+	return round, matchesByRound[round-1], true
+}
+
+// sideNamesOf returns the names of the players of a side joined by " / ".
+func sideNamesOf(side *gormmodel.Side) string {
+	if side == nil || side.Player1 == nil {
+		return "?"
+	}
+	names := side.Player1.Name
+	if side.Player2 != nil {
+		names += " / " + side.Player2.Name
+	}
+	return names
+}
+
+// ScoresForm returns the form for recording the game scores of the matches in a round of a given event.
+func ScoresForm(ctx *gin.Context) {
+	event, ok := loadAdminEvent(ctx)
+	if !ok {
+		return
+	}
+	round, matches, ok := loadRoundMatches(ctx, event)
+	if !ok {
+		return
+	}
+
+	var sb strings.Builder
+	for _, match := range matches {
+		sb.WriteString(fmt.Sprintf("\t\t<p>Court %d: %s vs %s", match.Court,
+			html.EscapeString(sideNamesOf(match.Side1)), html.EscapeString(sideNamesOf(match.Side2))))
+		if match.HandicapSide != 0 {
+			sb.WriteString(fmt.Sprintf(" (side %d starts every game with %d points, which are included in the scores)",
+				match.HandicapSide, match.HandicapPoints))
+		}
+		sb.WriteString("<br>\n")
+		sb.WriteString(fmt.Sprintf("\t\t<input type=\"text\" size=\"30\" name=\"score_%d\" value=\"%s\">\n",
+			match.ID, html.EscapeString(util.MatchGames(match).String())))
+	}
+
+	ctx.Writer.WriteString(`
+<html>
+<head>
+	<style>
+		body {
+			font-family: Courier New;
+			font-weight: bold;
+		}
+	</style>
+</head>
+<body>
+	<p>Round ` + strconv.Itoa(round) + `. Enter the points of every game, e.g. "21-17, 19-21, 21-15".
+	<p>A game goes to ` + strconv.Itoa(scoring.PointsToWin) + ` points with a lead of ` + strconv.Itoa(scoring.MinLead) +
+		`, capped at ` + strconv.Itoa(scoring.PointsCap) + `. Leave a match empty to clear its scores.
+	<form id="scoresform" method="post">
+` + sb.String() + `		<p>
+		<input type="submit">
+	</form>
+</body>
+</html>
+	`)
+}
+
+// ScoresSubmit takes the form for recording the game scores of the matches in a round of a given event.
+// The games of every match in the round are replaced by the submitted ones, which must make a completed
+// match under the rally point system, and the status of the match is set to the side who won.
+// Clearing the scores of a match sets it back to PLAYING.
+func ScoresSubmit(ctx *gin.Context) {
+	event, ok := loadAdminEvent(ctx)
+	if !ok {
+		return
+	}
+	round, matches, ok := loadRoundMatches(ctx, event)
+	if !ok {
+		return
+	}
+
+	gamesByMatch := make(map[uint]scoring.Games)
+	for _, match := range matches {
+		spec := strings.TrimSpace(ctx.PostForm(fmt.Sprintf("score_%d", match.ID)))
+		if spec == "" {
+			gamesByMatch[match.ID] = nil
+			continue
+		}
+		games, err := scoring.ParseGames(spec)
+		if err == nil {
+			err = games.Validate()
+		}
+		if err == nil {
+			err = games.ValidateHandicap(match.HandicapSide, match.HandicapPoints)
+		}
+		if err != nil {
+			RenderError(ctx, http.StatusBadRequest,
+				fmt.Sprintf("Invalid scores for the match on court %d: %v", match.Court, err))
+			return
+		}
+		gamesByMatch[match.ID] = games
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, match := range matches {
+			ret := tx.Where("mid = ?", match.ID).Delete(&gormmodel.Game{})
+			if ret.Error != nil {
+				return ret.Error
+			}
+			games := gamesByMatch[match.ID]
+			if len(games) == 0 {
+				// A match decided by its cleared scores is back to being played, while one whose
+				// winner was reported without scores keeps its status.
+				if len(match.Games) == 0 || match.Status == gormmodel.PLAYING {
+					continue
+				}
+				ret = tx.Model(&gormmodel.Match{}).Where("id = ?", match.ID).Update("status", gormmodel.PLAYING)
+				if ret.Error != nil {
+					return ret.Error
+				}
+				ctx.Writer.WriteString(fmt.Sprintf("Court %d: scores cleared, %s<br>\n", match.Court, gormmodel.PLAYING))
+				continue
+			}
+
+			records := util.ToGames(games, *match)
+			ret = tx.Create(&records)
+			if ret.Error != nil {
+				return ret.Error
+			}
+			status := gormmodel.SIDE1WON
+			if games.Winner() == 2 {
+				status = gormmodel.SIDE2WON
+			}
+			ret = tx.Model(&gormmodel.Match{}).Where("id = ?", match.ID).Update("status", status)
+			if ret.Error != nil {
+				return ret.Error
+			}
+			ctx.Writer.WriteString(fmt.Sprintf("Court %d: %s, %s<br>\n", match.Court, games, status))
+		}
+		return nil
+	})
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to record the scores of round %d: %v", round, err))
+		return
+	}
+	if round < event.CurrentRound {
+		ctx.Writer.WriteString(fmt.Sprintf("Round %d was completed before, complete it again to update the standings.<br>\n", round))
+	}
+}
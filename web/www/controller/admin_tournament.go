@@ -0,0 +1,363 @@
+package controller
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yushenli/badminton_match_table/pkg/tournament"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"github.com/yushenli/badminton_match_table/web/lib/util"
+	"gorm.io/gorm"
+)
+
+// TournamentForm returns the form for creating the elimination tournament of a given event.
+// The existing bracket is shown above the form, which is pre-filled with its entrants.
+func TournamentForm(ctx *gin.Context) {
+	event, ok := loadAdminEvent(ctx)
+	if !ok {
+		return
+	}
+	_, playerMap, err := util.PopulatePlayers(int(event.ID))
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list players under event %d", event.ID))
+		return
+	}
+	_, bracket, _, names, ok := loadBracket(ctx, *event, playerMap)
+	if !ok {
+		return
+	}
+
+	var kinds []string
+	for _, kind := range tournament.Kinds {
+		kinds = append(kinds, string(kind))
+	}
+	current := ""
+	existing := ""
+	if bracket != nil {
+		current = string(bracket.Kind)
+		existing = renderBracket(bracket, names) +
+			fmt.Sprintf("\t<p><a href=\"/admin/tournament/%d/advance\">Advance the tournament</a>\n", event.ID) +
+			"\t<p>Submitting the form replaces the tournament and all of its results.\n"
+	}
+
+	ctx.Writer.WriteString(`
+<html>
+<head>
+	<style>
+		body {
+			font-family: Courier New;
+			font-weight: bold;
+		}
+		td, th {
+			padding: 2px 8px;
+		}
+	</style>
+</head>
+<body>
+` + existing + `	<form id="tournamentform" method="post">
+		<p>Kind:
+		<select name="kind">
+` + selectOptions(kinds, current) + `		</select>
+		<p>Entrants in the order of their seeds, one per line, either "name" or "name,teammate":<br>
+		<textarea name="entrants" rows="20" cols="60">` + html.EscapeString(strings.Join(names, "\n")) + `</textarea>
+		<p>
+		<input type="submit">
+	</form>
+</body>
+</html>
+	`)
+}
+
+// parseEntrants parses the entrants posted in the form, one per line in the order of their seeds,
+// either "name" or "name,teammate". Every name must be a player of the event who enters only once,
+// and the entrants must be all players or all pairs. An error page is rendered if anything goes wrong.
+func parseEntrants(ctx *gin.Context, eid int) ([][]int, bool) {
+	players, _, err := util.PopulatePlayers(eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list players under event %d", eid))
+		return nil, false
+	}
+	playerIDs := make(map[string]int)
+	for _, player := range players {
+		playerIDs[player.Name] = int(player.ID)
+	}
+
+	// Names are joined by " / " when the form is pre-filled, and by commas when typed in.
+	spec := strings.ReplaceAll(ctx.PostForm("entrants"), " / ", ",")
+	reader := csv.NewReader(strings.NewReader(spec))
+	reader.FieldsPerRecord = -1
+	entries, err := reader.ReadAll()
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Unable to parse the entrants in CSV: %v", err))
+		return nil, false
+	}
+
+	var entrants [][]int
+	seen := make(map[int]bool)
+	for idx, entry := range entries {
+		if len(entry) > 2 {
+			RenderError(ctx, http.StatusBadRequest,
+				fmt.Sprintf("Invalid entry on row %d , 1 or 2 names are expected: %+v", idx+1, entry))
+			return nil, false
+		}
+		var ids []int
+		for _, name := range entry {
+			name = strings.TrimSpace(name)
+			id, ok := playerIDs[name]
+			if !ok {
+				RenderError(ctx, http.StatusBadRequest,
+					fmt.Sprintf("Invalid entry on row %d , %q is not a player of the event", idx+1, name))
+				return nil, false
+			}
+			if seen[id] {
+				RenderError(ctx, http.StatusBadRequest,
+					fmt.Sprintf("Invalid entry on row %d , %q has already entered", idx+1, name))
+				return nil, false
+			}
+			seen[id] = true
+			ids = append(ids, id)
+		}
+		if len(entrants) > 0 && len(ids) != len(entrants[0]) {
+			RenderError(ctx, http.StatusBadRequest,
+				fmt.Sprintf("Invalid entry on row %d , the entrants must be all players or all pairs: %+v", idx+1, entry))
+			return nil, false
+		}
+		entrants = append(entrants, ids)
+	}
+	return entrants, true
+}
+
+// TournamentSubmit takes the form for creating the elimination tournament of a given event.
+// The bracket is built from the seeded entrants, replacing the existing tournament if any.
+func TournamentSubmit(ctx *gin.Context) {
+	event, ok := loadAdminEvent(ctx)
+	if !ok {
+		return
+	}
+	eid := int(event.ID)
+
+	kind, err := tournament.ParseKind(ctx.PostForm("kind"))
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entrants, ok := parseEntrants(ctx, eid)
+	if !ok {
+		return
+	}
+
+	bracket, err := tournament.New(kind, len(entrants))
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	record, err := util.PopulateTournament(eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to fetch the tournament under event %d", eid))
+		return
+	}
+	if record == nil {
+		record = &gormmodel.Tournament{Eid: eid}
+	}
+	err = util.EncodeTournament(record, bracket, entrants)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to encode the tournament: %v", err))
+		return
+	}
+	ret := config.DB.Save(record)
+	if ret.Error != nil {
+		log.Printf("Failed when saving the tournament of event %d: %v", eid, ret.Error)
+		RenderError(ctx, http.StatusInternalServerError, "Failed when saving the tournament")
+		return
+	}
+
+	ctx.Writer.WriteString(fmt.Sprintf("Created a %s elimination tournament of %d entrants.<br>\n", kind, len(entrants)))
+}
+
+// courtAssigner assigns matches to the free courts of the current round of an event, i.e. those
+// without a match, while keeping a player from playing two matches at the same time.
+type courtAssigner struct {
+	courts      int
+	usedCourts  map[int]bool
+	busyPlayers map[int]bool
+}
+
+// newCourtAssigner returns a courtAssigner for the current round of the event, which already has
+// the given matches.
+func newCourtAssigner(event gormmodel.Event, currentMatches []*gormmodel.Match) *courtAssigner {
+	a := &courtAssigner{
+		courts:      event.Courts,
+		usedCourts:  make(map[int]bool),
+		busyPlayers: make(map[int]bool),
+	}
+	for _, match := range currentMatches {
+		a.usedCourts[match.Court] = true
+		if match.Status == gormmodel.PLAYING {
+			for _, id := range util.MatchPlayerIDs(match) {
+				a.busyPlayers[id] = true
+			}
+		}
+	}
+	return a
+}
+
+// assign puts the match on the first free court. Returns false if no court is free or any of
+// its players is still playing.
+func (a *courtAssigner) assign(match gormmodel.Match) (gormmodel.Match, bool) {
+	ids := util.MatchPlayerIDs(&match)
+	for _, id := range ids {
+		if a.busyPlayers[id] {
+			return match, false
+		}
+	}
+	for court := 1; court <= a.courts; court++ {
+		if a.usedCourts[court] {
+			continue
+		}
+		a.usedCourts[court] = true
+		for _, id := range ids {
+			a.busyPlayers[id] = true
+		}
+		match.Court = court
+		return match, true
+	}
+	return match, false
+}
+
+// createMatches creates the matches with their sides.
+func createMatches(tx *gorm.DB, matches []gormmodel.Match) error {
+	for idx := range matches {
+		ret := tx.Create(&matches[idx])
+		if ret.Error != nil {
+			return ret.Error
+		}
+
+		matches[idx].Side1.Mid = int(matches[idx].ID)
+		ret = tx.Save(matches[idx].Side1)
+		if ret.Error != nil {
+			return ret.Error
+		}
+		matches[idx].Side2.Mid = int(matches[idx].ID)
+		ret = tx.Save(matches[idx].Side2)
+		if ret.Error != nil {
+			return ret.Error
+		}
+	}
+	return nil
+}
+
+// AdvanceTournament reports the results of the scheduled bracket matches and assigns the bracket
+// matches ready to be played to the free courts of the current round, i.e. those without a match.
+// A bracket match is held back while any of its players is still playing. The changes are only
+// saved when proceed=1 is given.
+func AdvanceTournament(ctx *gin.Context) {
+	event, ok := loadAdminEvent(ctx)
+	if !ok {
+		return
+	}
+	eid := int(event.ID)
+
+	_, playerMap, err := util.PopulatePlayers(eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list players under event %d", eid))
+		return
+	}
+	record, bracket, entrants, names, ok := loadBracket(ctx, *event, playerMap)
+	if !ok {
+		return
+	}
+	if bracket == nil {
+		RenderError(ctx, http.StatusBadRequest, fmt.Sprintf("Event %d does not have a tournament", eid))
+		return
+	}
+	_, sideMap, err := util.PopulateSides(eid, playerMap, nil)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list sides under event %d", eid))
+		return
+	}
+	matches, matchesByRound, err := util.PopulateMatches(eid, event.CurrentRound, sideMap)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list matches under event %d", eid))
+		return
+	}
+
+	reported, err := util.ReportBracketResults(bracket, matches)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to report the results of the tournament: %v", err))
+		return
+	}
+
+	courts := newCourtAssigner(*event, matchesByRound[event.CurrentRound-1])
+	var scheduled []*tournament.Match
+	var newMatches []gormmodel.Match
+	for _, bracketMatch := range bracket.Ready() {
+		if bracketMatch.Ref != 0 {
+			continue
+		}
+		match, ok := courts.assign(util.FromBracketMatch(bracketMatch, entrants, *event, 0))
+		if !ok {
+			continue
+		}
+		scheduled = append(scheduled, bracketMatch)
+		newMatches = append(newMatches, match)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<html><head><style> body { font-family: Courier New; font-weight: bold; } td, th { padding: 2px 8px; } </style></head><body>\n")
+	sb.WriteString(fmt.Sprintf("<p>Reported %d result(s).\n", reported))
+	if ctx.Query("proceed") != "1" {
+		sb.WriteString(fmt.Sprintf("<p><a href=\"%s?proceed=1\">Proceed</a>\n", html.EscapeString(ctx.Request.URL.Path)))
+	}
+	sb.WriteString(fmt.Sprintf("<h4>Bracket matches for round %d</h4>\n<table>\n<tr><th>Court</th><th>Side 1</th><th>Side 2</th></tr>\n",
+		event.CurrentRound))
+	for idx, bracketMatch := range scheduled {
+		sb.WriteString(fmt.Sprintf("<tr><td>%d</td><td>%s</td><td>%s</td></tr>\n", newMatches[idx].Court,
+			html.EscapeString(entrantName(bracketMatch.Entrant1, names)),
+			html.EscapeString(entrantName(bracketMatch.Entrant2, names))))
+	}
+	sb.WriteString("</table>\n")
+
+	if ctx.Query("proceed") == "1" {
+		err = config.DB.Transaction(func(tx *gorm.DB) error {
+			err := createMatches(tx, newMatches)
+			if err != nil {
+				return err
+			}
+			for idx := range newMatches {
+				scheduled[idx].Ref = int(newMatches[idx].ID)
+			}
+
+			err = util.EncodeTournament(record, bracket, entrants)
+			if err != nil {
+				return err
+			}
+			return tx.Save(record).Error
+		})
+		if err != nil {
+			log.Printf("Failed when advancing the tournament of event %d: %v", eid, err)
+			RenderError(ctx, http.StatusInternalServerError, "Failed when advancing the tournament")
+			return
+		}
+		sb.WriteString("<p>Tournament advanced.\n")
+	}
+	sb.WriteString(renderBracket(bracket, names))
+	sb.WriteString("</body></html>\n")
+	ctx.Writer.WriteString(sb.String())
+}
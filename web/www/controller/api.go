@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"github.com/yushenli/badminton_match_table/web/lib/util"
+	"gorm.io/gorm"
+)
+
+// apiPrefix is the path prefix of the JSON API. Errors of requests under it are rendered as JSON.
+const apiPrefix = "/api/"
+
+// apiError is the body of every error response of the JSON API.
+type apiError struct {
+	Error apiErrorDetail `json:"error"`
+}
+
+type apiErrorDetail struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// isAPIRequest returns whether the request is to the JSON API.
+func isAPIRequest(ctx *gin.Context) bool {
+	return ctx.Request != nil && ctx.Request.URL != nil && strings.HasPrefix(ctx.Request.URL.Path, apiPrefix)
+}
+
+// renderAPIError renders an error of the JSON API.
+func renderAPIError(ctx *gin.Context, errorCode int, message string) {
+	ctx.AbortWithStatusJSON(errorCode, apiError{Error: apiErrorDetail{Code: errorCode, Message: message}})
+}
+
+type apiEvent struct {
+	ID           uint      `json:"id"`
+	Key          string    `json:"key"`
+	Date         time.Time `json:"date"`
+	Location     string    `json:"location"`
+	Courts       int       `json:"courts"`
+	CurrentRound int       `json:"current_round"`
+	Arranger     string    `json:"arranger"`
+	Format       string    `json:"format"`
+	Rating       string    `json:"rating"`
+	Mode         string    `json:"mode"`
+}
+
+func toAPIEvent(event gormmodel.Event) apiEvent {
+	return apiEvent{
+		ID:           event.ID,
+		Key:          event.Key,
+		Date:         event.Date,
+		Location:     event.Location,
+		Courts:       event.Courts,
+		CurrentRound: event.CurrentRound,
+		Arranger:     arrangerName(event),
+		Format:       event.Format,
+		Rating:       event.Rating,
+		Mode:         event.Mode,
+	}
+}
+
+type apiPlayer struct {
+	ID           uint    `json:"id"`
+	Name         string  `json:"name"`
+	Priority     float32 `json:"priority"`
+	InitialScore float32 `json:"initial_score"`
+	InBreak      bool    `json:"in_break"`
+	Category     string  `json:"category"`
+	CheckInRound int     `json:"check_in_round"`
+}
+
+func toAPIPlayer(player gormmodel.Player) apiPlayer {
+	return apiPlayer{
+		ID:           player.ID,
+		Name:         player.Name,
+		Priority:     player.Priority,
+		InitialScore: player.InitialScore,
+		InBreak:      player.InBreak,
+		Category:     player.Category,
+		CheckInRound: player.CheckInRound,
+	}
+}
+
+type apiSidePlayer struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type apiSide struct {
+	Players []apiSidePlayer `json:"players"`
+	Score   float32         `json:"score"`
+}
+
+type apiGame struct {
+	Side1Points int `json:"side1_points"`
+	Side2Points int `json:"side2_points"`
+}
+
+type apiMatch struct {
+	ID             uint      `json:"id"`
+	Round          int       `json:"round"`
+	Court          int       `json:"court"`
+	Status         string    `json:"status"`
+	Side1          apiSide   `json:"side1"`
+	Side2          apiSide   `json:"side2"`
+	HandicapSide   int       `json:"handicap_side,omitempty"`
+	HandicapPoints int       `json:"handicap_points,omitempty"`
+	Games          []apiGame `json:"games"`
+}
+
+// toAPIMatch converts a match whose sides have been populated, naming the players from playerMap.
+func toAPIMatch(match gormmodel.Match, playerMap map[int]*util.PlayerWithCounter) apiMatch {
+	ret := apiMatch{
+		ID:             match.ID,
+		Round:          match.Round,
+		Court:          match.Court,
+		Status:         match.Status,
+		HandicapSide:   match.HandicapSide,
+		HandicapPoints: match.HandicapPoints,
+		Games:          []apiGame{},
+	}
+	for idx, side := range []*gormmodel.Side{match.Side1, match.Side2} {
+		converted := apiSide{Players: []apiSidePlayer{}}
+		if side != nil {
+			converted.Score = side.Score
+			ids := []int{side.Pid1}
+			if side.Pid2 != nil {
+				ids = append(ids, *side.Pid2)
+			}
+			for _, id := range ids {
+				name := ""
+				if player, ok := playerMap[id]; ok {
+					name = player.Name
+				}
+				converted.Players = append(converted.Players, apiSidePlayer{ID: id, Name: name})
+			}
+		}
+		if idx == 0 {
+			ret.Side1 = converted
+		} else {
+			ret.Side2 = converted
+		}
+	}
+	for _, game := range match.Games {
+		ret.Games = append(ret.Games, apiGame{Side1Points: game.Side1Points, Side2Points: game.Side2Points})
+	}
+	return ret
+}
+
+// apiIntParam parses an integer parameter of the URL path. An error is rendered if it is invalid.
+func apiIntParam(ctx *gin.Context, name string) (int, bool) {
+	value, err := strconv.Atoi(ctx.Param(name))
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Invalid %s provided: %q", name, ctx.Param(name)))
+		return 0, false
+	}
+	return value, true
+}
+
+// loadAPIEvent loads the event whose eid is given in the URL path. An error is rendered if
+// anything goes wrong.
+func loadAPIEvent(ctx *gin.Context) (*gormmodel.Event, bool) {
+	if config.DB == nil {
+		RenderError(ctx, http.StatusInternalServerError, "Unable to connect to database. Please contact the admin.")
+		return nil, false
+	}
+
+	eid, ok := apiIntParam(ctx, "eid")
+	if !ok {
+		return nil, false
+	}
+	var event gormmodel.Event
+	ret := config.DB.First(&event, eid)
+	if errors.Is(ret.Error, gorm.ErrRecordNotFound) {
+		RenderError(ctx, http.StatusNotFound, fmt.Sprintf("Unable to find an event by eid %d", eid))
+		return nil, false
+	}
+	if ret.Error != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to locate the event by eid %d: %v", eid, ret.Error))
+		return nil, false
+	}
+	return &event, true
+}
+
+// loadAPIAdminEvent loads the event whose eid is given in the URL path, and checks the client has
+// admin privilege to it, by the admin key given in the X-Admin-Key header or the admin cookie.
+// An error is rendered if anything goes wrong.
+func loadAPIAdminEvent(ctx *gin.Context) (*gormmodel.Event, bool) {
+	event, ok := loadAPIEvent(ctx)
+	if !ok {
+		return nil, false
+	}
+	if util.GetAdminKey(ctx) == "" {
+		RenderError(ctx, http.StatusUnauthorized,
+			fmt.Sprintf("An admin key is required in the %s header", util.AdminHeaderKey))
+		return nil, false
+	}
+	if !util.HasAdminPrivilege(ctx, *event) {
+		RenderError(ctx, http.StatusForbidden,
+			fmt.Sprintf("You do not have admin privilege to event %d", event.ID))
+		return nil, false
+	}
+	return event, true
+}
+
+// bindAPIBody parses the JSON body of the request. An error is rendered if it is invalid.
+func bindAPIBody(ctx *gin.Context, body interface{}) bool {
+	err := ctx.ShouldBindJSON(body)
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest, fmt.Sprintf("Invalid JSON body: %v", err))
+		return false
+	}
+	return true
+}
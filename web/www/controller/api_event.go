@@ -0,0 +1,264 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yushenli/badminton_match_table/pkg/model"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"github.com/yushenli/badminton_match_table/web/lib/util"
+	"gorm.io/gorm"
+)
+
+// The number of events listed by APIListEvents by default, and at most.
+const (
+	apiDefaultEventLimit = 20
+	apiMaxEventLimit     = 100
+)
+
+// APIListEvents lists the latest events, as many as limit.
+func APIListEvents(ctx *gin.Context) {
+	if config.DB == nil {
+		RenderError(ctx, http.StatusInternalServerError, "Unable to connect to database. Please contact the admin.")
+		return
+	}
+
+	limit := apiDefaultEventLimit
+	if limitStr := ctx.Query("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > apiMaxEventLimit {
+			RenderError(ctx, http.StatusBadRequest,
+				fmt.Sprintf("Invalid limit provided, 1 to %d is expected: %q", apiMaxEventLimit, limitStr))
+			return
+		}
+	}
+
+	var events []gormmodel.Event
+	ret := config.DB.Order("date desc").Limit(limit).Find(&events)
+	if ret.Error != nil {
+		log.Printf("Failed to list events: %v", ret.Error)
+		RenderError(ctx, http.StatusInternalServerError, "Failed to list events")
+		return
+	}
+	converted := make([]apiEvent, len(events))
+	for idx, event := range events {
+		converted[idx] = toAPIEvent(event)
+	}
+	ctx.JSON(http.StatusOK, gin.H{"events": converted})
+}
+
+// APICreateEvent creates an event. The API key the server is started with must be given in the
+// admin header, and the admin key of the new event in the body, so that the event can be managed.
+func APICreateEvent(ctx *gin.Context) {
+	if config.APIKey == "" {
+		RenderError(ctx, http.StatusForbidden, "Creating events through the API is disabled")
+		return
+	}
+	if ctx.GetHeader(util.AdminHeaderKey) != config.APIKey {
+		RenderError(ctx, http.StatusForbidden, "You do not have privilege to create events")
+		return
+	}
+	if config.DB == nil {
+		RenderError(ctx, http.StatusInternalServerError, "Unable to connect to database. Please contact the admin.")
+		return
+	}
+
+	var body struct {
+		Key      string `json:"key"`
+		Date     string `json:"date"`
+		Location string `json:"location"`
+		Courts   int    `json:"courts"`
+		AdminKey string `json:"admin_key"`
+	}
+	if !bindAPIBody(ctx, &body) {
+		return
+	}
+	body.Key = strings.TrimSpace(body.Key)
+	if body.Key == "" || body.AdminKey == "" {
+		RenderError(ctx, http.StatusBadRequest, "Both key and admin_key are required")
+		return
+	}
+	date, err := time.ParseInLocation("2006-01-02", body.Date, time.Local)
+	if err != nil {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Invalid date provided, YYYY-MM-DD is expected: %q", body.Date))
+		return
+	}
+	if body.Courts < 1 {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Invalid courts provided, at least 1 is expected: %d", body.Courts))
+		return
+	}
+
+	var existing []gormmodel.Event
+	ret := config.DB.Where("`key` = ?", body.Key).Limit(1).Find(&existing)
+	if ret.Error != nil {
+		log.Printf("Failed to look up the event by key %q: %v", body.Key, ret.Error)
+		RenderError(ctx, http.StatusInternalServerError, "Failed to look up the event by key")
+		return
+	}
+	if len(existing) > 0 {
+		RenderError(ctx, http.StatusConflict, fmt.Sprintf("An event with key %q already exists", body.Key))
+		return
+	}
+
+	event := gormmodel.Event{
+		Key:          body.Key,
+		Date:         date,
+		Location:     body.Location,
+		Courts:       body.Courts,
+		CurrentRound: 1,
+		AdminKey:     body.AdminKey,
+	}
+	ret = config.DB.Create(&event)
+	if ret.Error != nil {
+		log.Printf("Failed when creating event %q: %v", body.Key, ret.Error)
+		RenderError(ctx, http.StatusInternalServerError, "Failed when creating the event")
+		return
+	}
+	ctx.JSON(http.StatusCreated, toAPIEvent(event))
+}
+
+// APIGetEvent returns an event.
+func APIGetEvent(ctx *gin.Context) {
+	event, ok := loadAPIEvent(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, toAPIEvent(*event))
+}
+
+// APIListPlayers lists the players of an event.
+func APIListPlayers(ctx *gin.Context) {
+	event, ok := loadAPIEvent(ctx)
+	if !ok {
+		return
+	}
+	players, _, err := util.PopulatePlayers(int(event.ID))
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list players under event %d", event.ID))
+		return
+	}
+	ret := make([]apiPlayer, len(players))
+	for idx, player := range players {
+		ret[idx] = toAPIPlayer(player.Player)
+	}
+	ctx.JSON(http.StatusOK, gin.H{"players": ret})
+}
+
+// APICreatePlayer adds a player to an event.
+func APICreatePlayer(ctx *gin.Context) {
+	event, ok := loadAPIAdminEvent(ctx)
+	if !ok {
+		return
+	}
+	eid := int(event.ID)
+
+	var body struct {
+		Name         string  `json:"name"`
+		Priority     float32 `json:"priority"`
+		InitialScore float32 `json:"initial_score"`
+		Category     string  `json:"category"`
+		InBreak      bool    `json:"in_break"`
+	}
+	if !bindAPIBody(ctx, &body) {
+		return
+	}
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		RenderError(ctx, http.StatusBadRequest, "A name is required")
+		return
+	}
+	if !model.ValidCategory(body.Category) {
+		RenderError(ctx, http.StatusBadRequest, fmt.Sprintf("Invalid category provided: %q", body.Category))
+		return
+	}
+
+	var existing []gormmodel.Player
+	ret := config.DB.Where("eid = ?", eid).Where("name = ?", body.Name).Limit(1).Find(&existing)
+	if ret.Error != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list players by eid %d: %v", eid, ret.Error))
+		return
+	}
+	if len(existing) > 0 {
+		RenderError(ctx, http.StatusConflict,
+			fmt.Sprintf("Event %d already has a player named %q", eid, body.Name))
+		return
+	}
+
+	player := gormmodel.Player{
+		Eid:          eid,
+		Name:         body.Name,
+		Priority:     body.Priority,
+		InitialScore: body.InitialScore,
+		Category:     body.Category,
+		InBreak:      body.InBreak,
+	}
+	if !player.InBreak {
+		player.CheckInRound = event.CurrentRound
+	}
+	ret = config.DB.Create(&player)
+	if ret.Error != nil {
+		log.Printf("Failed when creating player %q under event %d: %v", body.Name, eid, ret.Error)
+		RenderError(ctx, http.StatusInternalServerError, "Failed when creating the player")
+		return
+	}
+	ctx.JSON(http.StatusCreated, toAPIPlayer(player))
+}
+
+// APIUpdatePlayer changes whether a player of an event is in a break, and their priority. Fields
+// which are not given are kept.
+func APIUpdatePlayer(ctx *gin.Context) {
+	event, ok := loadAPIAdminEvent(ctx)
+	if !ok {
+		return
+	}
+	pid, ok := apiIntParam(ctx, "pid")
+	if !ok {
+		return
+	}
+
+	var body struct {
+		InBreak  *bool    `json:"in_break"`
+		Priority *float32 `json:"priority"`
+	}
+	if !bindAPIBody(ctx, &body) {
+		return
+	}
+
+	var player gormmodel.Player
+	ret := config.DB.Where("eid = ?", event.ID).First(&player, pid)
+	if errors.Is(ret.Error, gorm.ErrRecordNotFound) {
+		RenderError(ctx, http.StatusNotFound, fmt.Sprintf("Unable to find player %d under event %d", pid, event.ID))
+		return
+	}
+	if ret.Error != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to locate the player by pid %d: %v", pid, ret.Error))
+		return
+	}
+
+	if body.Priority != nil {
+		player.Priority = *body.Priority
+		ret = config.DB.Save(&player)
+		if ret.Error != nil {
+			RenderError(ctx, http.StatusInternalServerError,
+				fmt.Sprintf("Failed to update the player by pid %d: %v", pid, ret.Error))
+			return
+		}
+	}
+	if body.InBreak != nil && !setBreakStatus(ctx, &player, *body.InBreak) {
+		return
+	}
+	ctx.JSON(http.StatusOK, toAPIPlayer(player))
+}
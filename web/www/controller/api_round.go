@@ -0,0 +1,312 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yushenli/badminton_match_table/pkg/arranger"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"github.com/yushenli/badminton_match_table/web/lib/util"
+	"gorm.io/gorm"
+)
+
+type apiRound struct {
+	Round int `json:"round"`
+	// Completed is set once the round is before the current round of the event.
+	Completed bool       `json:"completed"`
+	Matches   []apiMatch `json:"matches"`
+}
+
+type apiStanding struct {
+	Rank            int       `json:"rank"`
+	Player          apiPlayer `json:"player"`
+	Games           int       `json:"games"`
+	Win             int       `json:"win"`
+	Loss            int       `json:"loss"`
+	Score           float32   `json:"score"`
+	PointDifference int       `json:"point_difference"`
+	Rating          *float64  `json:"rating,omitempty"`
+	Buchholz        float64   `json:"buchholz"`
+	MedianBuchholz  float64   `json:"median_buchholz"`
+	SonnebornBerger float64   `json:"sonneborn_berger"`
+}
+
+// loadAPIRounds loads the rounds of an event up to the current one, with their matches.
+// An error is rendered if anything goes wrong.
+func loadAPIRounds(ctx *gin.Context, event gormmodel.Event) ([]apiRound, bool) {
+	eid := int(event.ID)
+	_, playerMap, err := util.PopulatePlayers(eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list players under event %d", eid))
+		return nil, false
+	}
+	_, sideMap, err := util.PopulateSides(eid, playerMap, nil)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list sides under event %d", eid))
+		return nil, false
+	}
+	_, matchesByRound, err := util.PopulateMatches(eid, event.CurrentRound, sideMap)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list matches under event %d", eid))
+		return nil, false
+	}
+
+	rounds := make([]apiRound, len(matchesByRound))
+	for idx, matches := range matchesByRound {
+		rounds[idx] = apiRound{Round: idx + 1, Completed: idx+1 < event.CurrentRound, Matches: []apiMatch{}}
+		for _, match := range matches {
+			rounds[idx].Matches = append(rounds[idx].Matches, toAPIMatch(*match, playerMap))
+		}
+	}
+	return rounds, true
+}
+
+// APIListRounds lists the rounds of an event up to the current one, with their matches.
+func APIListRounds(ctx *gin.Context) {
+	event, ok := loadAPIEvent(ctx)
+	if !ok {
+		return
+	}
+	rounds, ok := loadAPIRounds(ctx, *event)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"current_round": event.CurrentRound, "rounds": rounds})
+}
+
+// APIGetRound returns a round of an event with its matches.
+func APIGetRound(ctx *gin.Context) {
+	event, ok := loadAPIEvent(ctx)
+	if !ok {
+		return
+	}
+	round, ok := apiIntParam(ctx, "round")
+	if !ok {
+		return
+	}
+	if round < 1 || round > event.CurrentRound {
+		RenderError(ctx, http.StatusNotFound,
+			fmt.Sprintf("Round %d not found, event %d is in round %d", round, event.ID, event.CurrentRound))
+		return
+	}
+	rounds, ok := loadAPIRounds(ctx, *event)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, rounds[round-1])
+}
+
+// APIStandings returns the standings of an event, ranked the same way as on the event page.
+func APIStandings(ctx *gin.Context) {
+	event, ok := loadAPIEvent(ctx)
+	if !ok {
+		return
+	}
+	eid := int(event.ID)
+
+	players, playerMap, err := util.PopulatePlayers(eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list players under event %d", eid))
+		return
+	}
+	sides, sideMap, err := util.PopulateSides(eid, playerMap, nil)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list sides under event %d", eid))
+		return
+	}
+	util.FillPlayerCounter(playerMap, sides)
+	matches, matchesByRound, err := util.PopulateMatches(eid, event.CurrentRound, sideMap)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list matches under event %d", eid))
+		return
+	}
+	util.FillPlayerPoints(playerMap, matches)
+	if !fillPlayerRatings(ctx, *event, playerMap, matchesByRound) {
+		return
+	}
+	var completedMatches []gormmodel.Match
+	for _, match := range matches {
+		if match.Round < event.CurrentRound {
+			completedMatches = append(completedMatches, match)
+		}
+	}
+	util.FillPlayerTiebreaks(playerMap, completedMatches)
+
+	sortedPlayers := make([]*util.PlayerWithCounter, len(players))
+	for idx := range players {
+		sortedPlayers[idx] = &players[idx]
+	}
+	sortPlayerSlice(sortedPlayers)
+
+	standings := make([]apiStanding, len(sortedPlayers))
+	for idx, player := range sortedPlayers {
+		standings[idx] = apiStanding{
+			Rank:            idx + 1,
+			Player:          toAPIPlayer(player.Player),
+			Games:           player.Games,
+			Win:             player.Win,
+			Loss:            player.Loss,
+			Score:           player.Score,
+			PointDifference: player.PointDifference(),
+			Buchholz:        player.Tiebreaks.Buchholz,
+			MedianBuchholz:  player.Tiebreaks.MedianBuchholz,
+			SonnebornBerger: player.Tiebreaks.SonnebornBerger,
+		}
+		if player.Rating != nil {
+			value := player.Rating.Value
+			standings[idx].Rating = &value
+		}
+	}
+	ctx.JSON(http.StatusOK, gin.H{"standings": standings})
+}
+
+// APIReportResult reports the result of a match: winner is the side who won, 1 or 2, or 0 if the
+// match is being played again. Under winners stay on and continuous scheduling, the next match on
+// the court is produced right away.
+func APIReportResult(ctx *gin.Context) {
+	event, ok := loadAPIAdminEvent(ctx)
+	if !ok {
+		return
+	}
+	mid, ok := apiIntParam(ctx, "mid")
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Winner int `json:"winner"`
+	}
+	if !bindAPIBody(ctx, &body) {
+		return
+	}
+	statuses := map[int]string{0: gormmodel.PLAYING, 1: gormmodel.SIDE1WON, 2: gormmodel.SIDE2WON}
+	status, ok := statuses[body.Winner]
+	if !ok {
+		RenderError(ctx, http.StatusBadRequest,
+			fmt.Sprintf("Invalid winner provided, 0, 1 or 2 is expected: %d", body.Winner))
+		return
+	}
+
+	var match gormmodel.Match
+	ret := config.DB.Where("eid = ?", event.ID).First(&match, mid)
+	if errors.Is(ret.Error, gorm.ErrRecordNotFound) {
+		RenderError(ctx, http.StatusNotFound, fmt.Sprintf("Unable to find match %d under event %d", mid, event.ID))
+		return
+	}
+	if ret.Error != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to locate the match by mid %d: %v", mid, ret.Error))
+		return
+	}
+
+	match.Status = status
+	ret = config.DB.Save(&match)
+	if ret.Error != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to update the match by mid %d to status %s: %v", mid, status, ret.Error))
+		return
+	}
+	note, ok := advanceCourt(ctx, *event, match)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"id": match.ID, "status": match.Status, "note": note})
+}
+
+// APIScheduleRound arranges the matches of the current round of an event, and saves them unless
+// dry_run=1 is given. Scheduling a round again requires override, as on the admin page.
+func APIScheduleRound(ctx *gin.Context) {
+	event, ok := loadAPIAdminEvent(ctx)
+	if !ok {
+		return
+	}
+	round, ok := apiIntParam(ctx, "round")
+	if !ok {
+		return
+	}
+	if !checkSchedulableRound(ctx, *event, round) {
+		return
+	}
+
+	input, eventArranger, ok := loadArrangerInput(ctx, config.DB, *event)
+	if !ok {
+		return
+	}
+	var explanation arranger.Explanation
+	input.Explanation = &explanation
+	arrangerMatches, replannedRounds, note, err := arrangeCurrentRound(*event, input, eventArranger)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Error when making match arrangement based on active players: %v", err))
+		return
+	}
+	matches := util.FromArrangerMatchArrangement(arrangerMatches, *event)
+
+	persisted := ctx.Query("dry_run") != "1"
+	if persisted {
+		err = saveCurrentRound(*event, matches, replannedRounds)
+		if err != nil {
+			log.Printf("Failed when creating new matches/sides %d in round %d: %v", event.ID, event.CurrentRound, err)
+			RenderError(ctx, http.StatusInternalServerError, "Failed when creating new matches/sides")
+			return
+		}
+	}
+
+	_, playerMap, err := util.PopulatePlayers(int(event.ID))
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list players under event %d", event.ID))
+		return
+	}
+	converted := make([]apiMatch, len(matches))
+	for idx, match := range matches {
+		converted[idx] = toAPIMatch(match, playerMap)
+	}
+	status := http.StatusOK
+	if persisted {
+		status = http.StatusCreated
+	}
+	ctx.JSON(status, gin.H{
+		"round":       event.CurrentRound,
+		"note":        note,
+		"explanation": explanation,
+		"persisted":   persisted,
+		"matches":     converted,
+	})
+}
+
+// APICompleteRound credits the sides of the matches in a round of an event once all of them have
+// been decided, and moves the event on to the next round. Completing a round again requires
+// override, as on the admin page.
+func APICompleteRound(ctx *gin.Context) {
+	event, ok := loadAPIAdminEvent(ctx)
+	if !ok {
+		return
+	}
+	round, ok := apiIntParam(ctx, "round")
+	if !ok {
+		return
+	}
+
+	matches, ok := loadCompletableRound(ctx, *event, round)
+	if !ok {
+		return
+	}
+	_, err := completeRound(event, round, matches)
+	if err != nil {
+		log.Printf("Failed when modifying sides and/or event %d in round %d: %v", event.ID, round, err)
+		RenderError(ctx, http.StatusInternalServerError, "Failed when modifying sides and/or event")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"round": round, "current_round": event.CurrentRound})
+}
@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yushenli/badminton_match_table/pkg/tournament"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"github.com/yushenli/badminton_match_table/web/lib/util"
+)
+
+// bracketTitles are the headings of the brackets of a tournament, in the order they are rendered.
+var bracketTitles = []struct {
+	bracket string
+	title   string
+}{
+	{tournament.WinnersBracket, "Winners bracket"},
+	{tournament.LosersBracket, "Losers bracket"},
+	{tournament.GrandFinal, "Grand final"},
+}
+
+// entrantName returns the name of an entrant in a bracket match.
+func entrantName(entrant int, names []string) string {
+	switch {
+	case entrant == tournament.Bye:
+		return "(bye)"
+	case entrant == tournament.Unknown || entrant > len(names):
+		return "TBD"
+	}
+	return fmt.Sprintf("[%d] %s", entrant, names[entrant-1])
+}
+
+// renderBracket renders the brackets of a tournament as HTML tables, one column per round,
+// where names are the names of the entrants in the order of their seeds. The winners are in bold.
+func renderBracket(bracket *tournament.Bracket, names []string) string {
+	var sb strings.Builder
+	for _, bt := range bracketTitles {
+		rounds := bracket.Rounds(bt.bracket)
+		if len(rounds) == 0 {
+			continue
+		}
+		if bracket.Kind == tournament.DoubleElimination {
+			sb.WriteString(fmt.Sprintf("<h4>%s</h4>\n", bt.title))
+		}
+		sb.WriteString("<table class=\"bracket\">\n<tr>")
+		for r := range rounds {
+			sb.WriteString(fmt.Sprintf("<th>Round %d</th>", r+1))
+		}
+		sb.WriteString("</tr>\n<tr>")
+		for _, matches := range rounds {
+			sb.WriteString("<td>")
+			for _, match := range matches {
+				if match.Skipped {
+					sb.WriteString("<p>Not needed\n")
+					continue
+				}
+				sb.WriteString("<p>")
+				for side := 1; side <= 2; side++ {
+					name := html.EscapeString(entrantName(match.Entrant(side), names))
+					if match.Winner == side {
+						name = "<b>" + name + "</b>"
+					}
+					if side == 2 {
+						sb.WriteString("<br>vs ")
+					}
+					sb.WriteString(name)
+				}
+				sb.WriteString("\n")
+			}
+			sb.WriteString("</td>")
+		}
+		sb.WriteString("</tr>\n</table>\n")
+	}
+	if champion := bracket.Champion(); champion != tournament.Unknown {
+		sb.WriteString(fmt.Sprintf("<p>Champion: %s\n", html.EscapeString(entrantName(champion, names))))
+	}
+	return sb.String()
+}
+
+// loadBracket loads the tournament of the event and the names of its entrants. The returned
+// bracket is nil if the event does not have a tournament. An error page is rendered if anything
+// goes wrong.
+func loadBracket(ctx *gin.Context, event gormmodel.Event, playerMap map[int]*util.PlayerWithCounter) (
+	*gormmodel.Tournament, *tournament.Bracket, [][]int, []string, bool) {
+	record, err := util.PopulateTournament(int(event.ID))
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to fetch the tournament under event %d", event.ID))
+		return nil, nil, nil, nil, false
+	}
+	if record == nil {
+		return nil, nil, nil, nil, true
+	}
+	bracket, entrants, err := util.DecodeTournament(*record)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError, err.Error())
+		return nil, nil, nil, nil, false
+	}
+	return record, bracket, entrants, util.EntrantNames(entrants, playerMap), true
+}
+
+// RenderEventBracket is the controller for the tournament bracket page of an event.
+func RenderEventBracket(ctx *gin.Context) {
+	if config.DB == nil {
+		RenderError(ctx, http.StatusInternalServerError, "Unable to connect to database. Please contact the admin.")
+		return
+	}
+
+	eventKey := ctx.Param("key")
+	var event gormmodel.Event
+	ret := config.DB.Where("`key` = ?", eventKey).First(&event)
+	if ret.Error != nil {
+		RenderError(ctx, http.StatusNotFound,
+			fmt.Sprintf("Unable to find an event with key %q", eventKey))
+		return
+	}
+
+	_, playerMap, err := util.PopulatePlayers(int(event.ID))
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list players under event %d", event.ID))
+		return
+	}
+	_, bracket, _, names, ok := loadBracket(ctx, event, playerMap)
+	if !ok {
+		return
+	}
+	if bracket == nil {
+		RenderError(ctx, http.StatusNotFound,
+			fmt.Sprintf("Event %q does not have a tournament", eventKey))
+		return
+	}
+
+	ctx.Writer.WriteString("<html><head><style> body { font-family: Courier New; font-weight: bold; } td, th { padding: 2px 8px; vertical-align: middle; } </style></head><body>\n")
+	ctx.Writer.WriteString(fmt.Sprintf("<h3>Tournament of %s, %s elimination</h3>\n",
+		html.EscapeString(event.Key), bracket.Kind))
+	ctx.Writer.WriteString(renderBracket(bracket, names))
+	ctx.Writer.WriteString("</body></html>\n")
+}
@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yushenli/badminton_match_table/pkg/model"
+	"github.com/yushenli/badminton_match_table/pkg/rotation"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"github.com/yushenli/badminton_match_table/web/lib/util"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// nextContinuousMatch arranges and creates the next match on a court of an event running continuous
+// scheduling, from the players who are not playing on the other courts. Every match is played in a
+// round of its own, after which the current round of the event moves on, so the history of the
+// players and the rounds they have waited are counted the same way as for lockstep rounds.
+// The event row is locked while the players are read and the match is created, so the courts of
+// an event reporting at the same time are arranged one after another and never take the same
+// free players.
+// Returns a nil match if not enough players are free, and false if an error page has been rendered.
+func nextContinuousMatch(ctx *gin.Context, event *gormmodel.Event, court int, state *gormmodel.CourtState) (*gormmodel.Match, bool) {
+	eid := int(event.ID)
+	var next *gormmodel.Match
+	rendered := false
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		ret := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(event, eid)
+		if ret.Error != nil {
+			return ret.Error
+		}
+		input, eventArranger, ok := loadArrangerInput(ctx, tx, *event)
+		if !ok {
+			rendered = true
+			return fmt.Errorf("failed to load the players to arrange court %d", court)
+		}
+		busy, err := playingPlayers(tx, eid, 0)
+		if err != nil {
+			return err
+		}
+
+		arranged, found, err := rotation.ArrangeCourt(eventArranger, input, busy)
+		if err != nil {
+			rendered = true
+			RenderError(ctx, http.StatusInternalServerError,
+				fmt.Sprintf("Error when making match arrangement for court %d based on free players: %v", court, err))
+			return err
+		}
+		if !found {
+			return nil
+		}
+
+		matches := util.FromArrangerMatchArrangement(model.MatchArrangement{arranged}, *event)
+		matches[0].Court = court
+		err = createMatches(tx, matches)
+		if err != nil {
+			return err
+		}
+		if state == nil {
+			state = &gormmodel.CourtState{Eid: eid, Court: court}
+		}
+		state.Mid, state.Stays = int(matches[0].ID), 0
+		ret = tx.Save(state)
+		if ret.Error != nil {
+			return ret.Error
+		}
+		event.CurrentRound++
+		next = &matches[0]
+		return tx.Save(event).Error
+	})
+	if rendered {
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Failed when creating the next match on court %d of event %d: %v", court, eid, err)
+		RenderError(ctx, http.StatusInternalServerError, "Failed when creating new matches/sides")
+		return nil, false
+	}
+	return next, true
+}
+
+// latestCourtMatches returns the latest match of every court, ordered by court, given the matches
+// ordered by round.
+func latestCourtMatches(matches []gormmodel.Match) []*gormmodel.Match {
+	latest := make(map[int]*gormmodel.Match)
+	for idx := range matches {
+		latest[matches[idx].Court] = &matches[idx]
+	}
+	var ret []*gormmodel.Match
+	for _, match := range latest {
+		ret = append(ret, match)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Court < ret[j].Court
+	})
+	return ret
+}
+
+// advanceContinuous handles a reported result in an event running continuous scheduling. The sides
+// of the match are credited right away, and if the match is the current one on its court, the next
+// match on the court is arranged. Returns a note of what has been done, and false if an error page
+// has been rendered.
+func advanceContinuous(ctx *gin.Context, event *gormmodel.Event, match gormmodel.Match) (string, bool) {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		return creditSides(tx, match)
+	})
+	if err != nil {
+		log.Printf("Failed when crediting the sides of match %d: %v", match.ID, err)
+		RenderError(ctx, http.StatusInternalServerError, "Failed when crediting the sides")
+		return "", false
+	}
+	if match.Status == gormmodel.PLAYING {
+		return "", true
+	}
+
+	states, err := util.PopulateCourtStates(int(event.ID))
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list court states under event %d", event.ID))
+		return "", false
+	}
+	state, ok := states[match.Court]
+	if !ok || state.Mid != int(match.ID) {
+		return fmt.Sprintf("Match %d is no longer the current match on court %d.", match.ID, match.Court), true
+	}
+
+	next, ok := nextContinuousMatch(ctx, event, match.Court, state)
+	if !ok {
+		return "", false
+	}
+	if next == nil {
+		return fmt.Sprintf("Not enough free players for the next match on court %d.", match.Court), true
+	}
+	return fmt.Sprintf("Next match on court %d is %d.", match.Court, next.ID), true
+}
+
+// ContinuousCourts shows the courts of an event running continuous scheduling. With proceed=1,
+// every idle court, i.e. one without a current match being played, gets a match arranged from the
+// free players, which is how the courts are filled at the start of the event.
+func ContinuousCourts(ctx *gin.Context) {
+	event, ok := loadAdminEvent(ctx)
+	if !ok {
+		return
+	}
+	eid := int(event.ID)
+	if rotation.Mode(event.Mode) != rotation.Continuous {
+		RenderError(ctx, http.StatusBadRequest, fmt.Sprintf("Event %d does not run continuous scheduling", eid))
+		return
+	}
+
+	_, playerMap, err := util.PopulatePlayers(eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list players under event %d", eid))
+		return
+	}
+	states, err := util.PopulateCourtStates(eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list court states under event %d", eid))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<table>\n<tr><th>Court</th><th>Side 1</th><th>Side 2</th><th>Round</th></tr>\n")
+	for _, court := range courtNumbers(*event, states) {
+		current, err := currentCourtMatch(config.DB, states[court])
+		if err != nil {
+			log.Printf("Failed to locate the current match on court %d of event %d: %v", court, eid, err)
+			RenderError(ctx, http.StatusInternalServerError, "Failed to locate the current match on court")
+			return
+		}
+		idle := current.ID == 0 || current.Status != gormmodel.PLAYING
+		if idle && court <= event.Courts && ctx.Query("proceed") == "1" {
+			next, ok := nextContinuousMatch(ctx, event, court, states[court])
+			if !ok {
+				return
+			}
+			if next != nil {
+				current, idle = *next, false
+			}
+		}
+
+		if idle {
+			sb.WriteString(fmt.Sprintf("<tr><td>%d</td><td colspan=\"3\">Idle</td></tr>\n", court))
+			continue
+		}
+		sides := util.ToRotationMatch(&current)
+		names := util.EntrantNames([][]int{sides.Side1, sides.Side2}, playerMap)
+		sb.WriteString(fmt.Sprintf("<tr><td>%d</td><td>%s</td><td>%s</td><td>%d</td></tr>\n",
+			court, html.EscapeString(names[0]), html.EscapeString(names[1]), current.Round))
+	}
+	sb.WriteString("</table>\n")
+
+	ctx.Writer.WriteString("<html><head><style> body { font-family: Courier New; font-weight: bold; } td, th { padding: 2px 8px; } </style></head><body>\n")
+	ctx.Writer.WriteString("<h3>Continuous scheduling, the next match on a court is arranged as soon as it reports a result</h3>\n")
+	if ctx.Query("proceed") != "1" {
+		ctx.Writer.WriteString(fmt.Sprintf("<p><a href=\"%s?proceed=1\">Fill the idle courts</a>\n", html.EscapeString(ctx.Request.URL.Path)))
+	}
+	ctx.Writer.WriteString(sb.String())
+	ctx.Writer.WriteString("</body></html>\n")
+}
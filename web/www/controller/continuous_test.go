@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+)
+
+func TestLatestCourtMatches(t *testing.T) {
+	// Matches of a continuous event, ordered by round, where court 2 has finished two matches
+	// while court 1 is still playing its first one.
+	matches := []gormmodel.Match{
+		{Round: 1, Court: 2},
+		{Round: 2, Court: 1},
+		{Round: 3, Court: 2},
+		{Round: 4, Court: 2},
+	}
+	var got []int
+	for _, match := range latestCourtMatches(matches) {
+		got = append(got, match.Round)
+	}
+	if expected := []int{2, 4}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected rounds of the latest matches, expected %v got %v", expected, got)
+	}
+}
@@ -2,12 +2,24 @@ package controller
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
-// RenderError renders error pages including 404 and 500 series.
+// RenderError renders error pages including 404 and 500 series. Requests to the JSON API get the
+// error as a JSON body instead, see renderAPIError.
 func RenderError(ctx *gin.Context, errorCode int, message string) {
+	if isAPIRequest(ctx) {
+		renderAPIError(ctx, errorCode, message)
+		return
+	}
 	ctx.AbortWithStatus(errorCode)
 	ctx.Writer.WriteString(fmt.Sprintf("%d %s", errorCode, message))
 }
+
+// RenderNotFound renders the error for a request to an unknown route.
+func RenderNotFound(ctx *gin.Context) {
+	RenderError(ctx, http.StatusNotFound,
+		fmt.Sprintf("No route for %s %s", ctx.Request.Method, ctx.Request.URL.Path))
+}
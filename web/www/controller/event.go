@@ -2,12 +2,15 @@ package controller
 
 import (
 	"fmt"
+	"html/template"
 	"net/http"
 	"sort"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/yushenli/badminton_match_table/pkg/rating"
+	"github.com/yushenli/badminton_match_table/pkg/rotation"
 	"github.com/yushenli/badminton_match_table/web/lib/config"
 	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
 	"github.com/yushenli/badminton_match_table/web/lib/util"
@@ -28,6 +31,9 @@ func matchTableColStyle(courts int) string {
 	return "w-col-3" // width: 25%
 }
 
+// sortPlayerSlice sorts the players into the standings: players in break go last, and the others
+// are ranked by score, then by the Swiss-system tiebreaks Buchholz, median-Buchholz and
+// Sonneborn-Berger, then by point difference and finally by priority.
 func sortPlayerSlice(players []*util.PlayerWithCounter) {
 	sort.Slice(players, func(i, j int) bool {
 		p1 := players[i]
@@ -43,6 +49,18 @@ func sortPlayerSlice(players []*util.PlayerWithCounter) {
 		if p1.Score != p2.Score {
 			return p1.Score > p2.Score
 		}
+		if p1.Tiebreaks.Buchholz != p2.Tiebreaks.Buchholz {
+			return p1.Tiebreaks.Buchholz > p2.Tiebreaks.Buchholz
+		}
+		if p1.Tiebreaks.MedianBuchholz != p2.Tiebreaks.MedianBuchholz {
+			return p1.Tiebreaks.MedianBuchholz > p2.Tiebreaks.MedianBuchholz
+		}
+		if p1.Tiebreaks.SonnebornBerger != p2.Tiebreaks.SonnebornBerger {
+			return p1.Tiebreaks.SonnebornBerger > p2.Tiebreaks.SonnebornBerger
+		}
+		if p1.PointDifference() != p2.PointDifference() {
+			return p1.PointDifference() > p2.PointDifference()
+		}
 		return p1.Priority > p2.Priority
 	})
 }
@@ -74,6 +92,23 @@ func findUnscheduledPlayers(matches []*gormmodel.Match, players []util.PlayerWit
 	return unscheduled
 }
 
+// fillPlayerRatings replaces the scores of the players with their ratings, if the event uses a
+// rating system other than the classic one. An error page is rendered if anything goes wrong.
+func fillPlayerRatings(ctx *gin.Context, event gormmodel.Event, playerMap map[int]*util.PlayerWithCounter,
+	matchesByRound [][]*gormmodel.Match) bool {
+	if event.Rating == "" || event.Rating == rating.DefaultSystemName {
+		return true
+	}
+	system, err := rating.Lookup(event.Rating)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Error when looking up the rating system of event %d: %v", event.ID, err))
+		return false
+	}
+	util.FillPlayerRatings(system, playerMap, matchesByRound)
+	return true
+}
+
 // RenderEvent is the controller for the event page.
 func RenderEvent(ctx *gin.Context) {
 	if config.DB == nil {
@@ -106,6 +141,27 @@ func RenderEvent(ctx *gin.Context) {
 	}
 
 	util.FillPlayerCounter(playerMap, sides)
+
+	matches, matchesByRound, err := util.PopulateMatches(int(event.ID), event.CurrentRound, sideMap)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list matches under event %d", event.ID))
+		return
+	}
+	util.FillPlayerPoints(playerMap, matches)
+	if !fillPlayerRatings(ctx, event, playerMap, matchesByRound) {
+		return
+	}
+	// The tiebreaks count every decided match, which continuous events finish in rounds of their own.
+	util.FillPlayerTiebreaks(playerMap, matches)
+
+	teams, err := util.PopulateTeams(int(event.ID))
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list teams under event %d", event.ID))
+		return
+	}
+	sortedTeams := util.FillTeamCounter(teams, playerMap, sides)
 	sortedPlayers := make([]*util.PlayerWithCounter, len(players))
 	for idx := range players {
 		sortedPlayers[idx] = &players[idx]
@@ -113,8 +169,6 @@ func RenderEvent(ctx *gin.Context) {
 	sortPlayerSlice(sortedPlayers)
 
 	// Fill the current round match table and match results
-	_, matchesByRound, err := util.PopulateMatches(int(event.ID), event.CurrentRound, sideMap)
-
 	round := event.CurrentRound
 	if ctx.Query("round") != "" {
 		round1, err := strconv.Atoi(ctx.Query("round"))
@@ -123,17 +177,43 @@ func RenderEvent(ctx *gin.Context) {
 		}
 	}
 
-	unscheduledPlayers := findUnscheduledPlayers(matchesByRound[round-1], players)
+	currentMatches := matchesByRound[round-1]
+	if rotation.Mode(event.Mode) == rotation.Continuous && ctx.Query("round") == "" {
+		// Every match of a continuous event is played in a round of its own, and the current round
+		// moves on once it is arranged, so the latest match of every court is shown instead.
+		currentMatches = latestCourtMatches(matches)
+	}
+	unscheduledPlayers := findUnscheduledPlayers(currentMatches, players)
+
+	_, tournamentBracket, _, entrantNames, ok := loadBracket(ctx, event, playerMap)
+	if !ok {
+		return
+	}
+	var bracket template.HTML
+	if tournamentBracket != nil {
+		bracket = template.HTML(renderBracket(tournamentBracket, entrantNames))
+	}
+	_, groupStage, _, groupEntrantNames, ok := loadGroupStage(ctx, event, playerMap)
+	if !ok {
+		return
+	}
+	var groups template.HTML
+	if groupStage != nil {
+		groups = template.HTML(renderGroups(groupStage, groupEntrantNames))
+	}
 
 	ctx.HTML(http.StatusOK, "event.html", gin.H{
 		"event":              event,
 		"players":            sortedPlayers,
+		"teams":              sortedTeams,
 		"displayRound":       round,
-		"currentMatches":     matchesByRound[round-1],
+		"currentMatches":     currentMatches,
 		"matchesByRound":     matchesByRound,
-		"matchTableColStyle": matchTableColStyle(len(matchesByRound[round-1])),
+		"matchTableColStyle": matchTableColStyle(len(currentMatches)),
 		"unscheduledPlayers": unscheduledPlayers,
 		"hasAdminPrivilege":  util.HasAdminPrivilege(ctx, event),
+		"bracket":            bracket,
+		"groups":             groups,
 	})
 }
 
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yushenli/badminton_match_table/pkg/tournament"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"github.com/yushenli/badminton_match_table/web/lib/util"
+)
+
+// renderGroups renders the standings of every group as HTML tables followed by their results,
+// where names are the names of the entrants in the order of their seeds. The qualifying places
+// are in bold.
+func renderGroups(stage *tournament.GroupStage, names []string) string {
+	var sb strings.Builder
+	for group := range stage.Groups {
+		sb.WriteString(fmt.Sprintf("<h4>Group %c</h4>\n<table class=\"group\">\n", 'A'+group))
+		sb.WriteString("<tr><th>#</th><th>Entrant</th><th>Played</th><th>Won</th><th>Lost</th><th>Points</th><th>+/-</th></tr>\n")
+		for idx, standing := range stage.Standings(group) {
+			name := html.EscapeString(entrantName(standing.Entrant, names))
+			if idx < stage.Advance {
+				name = "<b>" + name + "</b>"
+			}
+			sb.WriteString(fmt.Sprintf("<tr><td>%d</td><td>%s</td><td>%d</td><td>%d</td><td>%d</td><td>%d-%d</td><td>%+d</td></tr>\n",
+				idx+1, name, standing.Played, standing.Won, standing.Lost,
+				standing.PointsFor, standing.PointsAgainst, standing.PointDifference()))
+		}
+		sb.WriteString("</table>\n")
+
+		for _, fixture := range stage.Fixtures {
+			if fixture.Group != group || fixture.Winner == 0 {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("<p>Round %d: %s %d-%d %s\n", fixture.Round,
+				html.EscapeString(entrantName(fixture.Entrant1, names)), fixture.Points1, fixture.Points2,
+				html.EscapeString(entrantName(fixture.Entrant2, names))))
+		}
+	}
+	return sb.String()
+}
+
+// loadGroupStage loads the group stage of the event and the names of its entrants. The returned
+// stage is nil if the event does not have a group stage. An error page is rendered if anything
+// goes wrong.
+func loadGroupStage(ctx *gin.Context, event gormmodel.Event, playerMap map[int]*util.PlayerWithCounter) (
+	*gormmodel.GroupStage, *tournament.GroupStage, [][]int, []string, bool) {
+	record, err := util.PopulateGroupStage(int(event.ID))
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to fetch the group stage under event %d", event.ID))
+		return nil, nil, nil, nil, false
+	}
+	if record == nil {
+		return nil, nil, nil, nil, true
+	}
+	stage, entrants, err := util.DecodeGroupStage(*record)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError, err.Error())
+		return nil, nil, nil, nil, false
+	}
+	return record, stage, entrants, util.EntrantNames(entrants, playerMap), true
+}
+
+// RenderEventGroups is the controller for the group stage page of an event.
+func RenderEventGroups(ctx *gin.Context) {
+	if config.DB == nil {
+		RenderError(ctx, http.StatusInternalServerError, "Unable to connect to database. Please contact the admin.")
+		return
+	}
+
+	eventKey := ctx.Param("key")
+	var event gormmodel.Event
+	ret := config.DB.Where("`key` = ?", eventKey).First(&event)
+	if ret.Error != nil {
+		RenderError(ctx, http.StatusNotFound,
+			fmt.Sprintf("Unable to find an event with key %q", eventKey))
+		return
+	}
+
+	_, playerMap, err := util.PopulatePlayers(int(event.ID))
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list players under event %d", event.ID))
+		return
+	}
+	_, stage, _, names, ok := loadGroupStage(ctx, event, playerMap)
+	if !ok {
+		return
+	}
+	if stage == nil {
+		RenderError(ctx, http.StatusNotFound,
+			fmt.Sprintf("Event %q does not have a group stage", eventKey))
+		return
+	}
+
+	ctx.Writer.WriteString("<html><head><style> body { font-family: Courier New; font-weight: bold; } td, th { padding: 2px 8px; } </style></head><body>\n")
+	ctx.Writer.WriteString(fmt.Sprintf("<h3>Groups of %s, top %d advance</h3>\n", html.EscapeString(event.Key), stage.Advance))
+	ctx.Writer.WriteString(renderGroups(stage, names))
+	ctx.Writer.WriteString("</body></html>\n")
+}
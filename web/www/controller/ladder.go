@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yushenli/badminton_match_table/pkg/ladder"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"github.com/yushenli/badminton_match_table/web/lib/util"
+)
+
+// recentChallenges is how many of the latest played challenges are shown on the ladder page.
+const recentChallenges = 10
+
+// renderLadder renders the ranking of a ladder, its open challenges and the latest played ones as
+// HTML tables.
+func renderLadder(record gormmodel.Ladder, ranking ladder.Ladder, open, played []gormmodel.Challenge) string {
+	var sb strings.Builder
+	sb.WriteString("<table>\n<tr><th>Position</th><th>Player</th></tr>\n")
+	for idx, name := range ranking {
+		sb.WriteString(fmt.Sprintf("<tr><td>%d</td><td>%s</td></tr>\n", idx+1, html.EscapeString(name)))
+	}
+	sb.WriteString("</table>\n")
+
+	rules := util.LadderRules(record)
+	sb.WriteString("<h4>Open challenges</h4>\n<table>\n<tr><th>Challenger</th><th>Defender</th><th>Status</th></tr>\n")
+	for _, challenge := range open {
+		status := "Waiting to be scheduled"
+		switch c := util.ToLadderChallenge(challenge); {
+		case c.Scheduled:
+			status = fmt.Sprintf("Scheduled as match %d", challenge.Mid)
+		case rules.Expired(c, time.Now()):
+			status = "Lapsed"
+		case rules.Expiry > 0:
+			status = fmt.Sprintf("Lapses on %s", c.Created.Add(rules.Expiry).Format("2006-01-02"))
+		}
+		sb.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+			html.EscapeString(challenge.Challenger), html.EscapeString(challenge.Defender), status))
+	}
+	sb.WriteString("</table>\n")
+
+	sb.WriteString("<h4>Latest results</h4>\n<table>\n<tr><th>Challenger</th><th>Defender</th><th>Winner</th></tr>\n")
+	for idx := len(played) - 1; idx >= 0 && idx >= len(played)-recentChallenges; idx-- {
+		challenge := played[idx]
+		winner := challenge.Defender
+		if challenge.ChallengerWon {
+			winner = challenge.Challenger
+		}
+		sb.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+			html.EscapeString(challenge.Challenger), html.EscapeString(challenge.Defender), html.EscapeString(winner)))
+	}
+	sb.WriteString("</table>\n")
+	return sb.String()
+}
+
+// loadLadder loads the ranking, the open challenges and the played challenges of a ladder.
+// An error page is rendered if anything goes wrong.
+func loadLadder(ctx *gin.Context, lid int) (ladder.Ladder, []gormmodel.Challenge, []gormmodel.Challenge, bool) {
+	ranking, err := util.PopulateLadderRungs(lid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list the rungs of ladder %d", lid))
+		return nil, nil, nil, false
+	}
+	open, err := util.PopulateChallenges(lid, gormmodel.CHALLENGEOPEN)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list the open challenges of ladder %d", lid))
+		return nil, nil, nil, false
+	}
+	played, err := util.PopulateChallenges(lid, gormmodel.CHALLENGEPLAYED)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list the played challenges of ladder %d", lid))
+		return nil, nil, nil, false
+	}
+	return ranking, open, played, true
+}
+
+// RenderLadder is the controller for the page of a club ladder.
+func RenderLadder(ctx *gin.Context) {
+	if config.DB == nil {
+		RenderError(ctx, http.StatusInternalServerError, "Unable to connect to database. Please contact the admin.")
+		return
+	}
+
+	ladderKey := ctx.Param("key")
+	var record gormmodel.Ladder
+	ret := config.DB.Where("`key` = ?", ladderKey).First(&record)
+	if ret.Error != nil {
+		RenderError(ctx, http.StatusNotFound,
+			fmt.Sprintf("Unable to find a ladder with key %q", ladderKey))
+		return
+	}
+
+	ranking, open, played, ok := loadLadder(ctx, int(record.ID))
+	if !ok {
+		return
+	}
+
+	ctx.Writer.WriteString("<html><head><style> body { font-family: Courier New; font-weight: bold; } td, th { padding: 2px 8px; } </style></head><body>\n")
+	ctx.Writer.WriteString(fmt.Sprintf("<h3>%s, challenge up to %d place(s) above</h3>\n", html.EscapeString(record.Name), record.Reach))
+	ctx.Writer.WriteString(renderLadder(record, ranking, open, played))
+	ctx.Writer.WriteString("</body></html>\n")
+}
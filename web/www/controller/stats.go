@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yushenli/badminton_match_table/pkg/metrics"
+	"github.com/yushenli/badminton_match_table/pkg/rating"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"github.com/yushenli/badminton_match_table/web/lib/util"
+)
+
+// RenderEventStats is the controller for the statistics page of an event, showing how fair the
+// matches so far have been. With format=json, the metrics are returned in JSON instead.
+func RenderEventStats(ctx *gin.Context) {
+	if config.DB == nil {
+		RenderError(ctx, http.StatusInternalServerError, "Unable to connect to database. Please contact the admin.")
+		return
+	}
+
+	eventKey := ctx.Param("key")
+	var event gormmodel.Event
+	ret := config.DB.Where("`key` = ?", eventKey).First(&event)
+	if ret.Error != nil {
+		RenderError(ctx, http.StatusNotFound,
+			fmt.Sprintf("Unable to find an event with key %q", eventKey))
+		return
+	}
+
+	players, playerMap, err := util.PopulatePlayers(int(event.ID))
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list players under event %d", event.ID))
+		return
+	}
+	_, sideMap, err := util.PopulateSides(int(event.ID), playerMap, nil)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list sides under event %d", event.ID))
+		return
+	}
+	_, matchesByRound, err := util.PopulateMatches(int(event.ID), event.CurrentRound, sideMap)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list matches under event %d", event.ID))
+		return
+	}
+	system, err := rating.Lookup(event.Rating)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Error when looking up the rating system of event %d: %v", event.ID, err))
+		return
+	}
+
+	// Only the rounds which have been scheduled are measured.
+	for len(matchesByRound) > 0 && len(matchesByRound[len(matchesByRound)-1]) == 0 {
+		matchesByRound = matchesByRound[:len(matchesByRound)-1]
+	}
+	m := util.ComputeMetrics(event, system, playerMap, matchesByRound)
+
+	if ctx.Query("format") == "json" {
+		ctx.JSON(http.StatusOK, gin.H{
+			"event":   event.Key,
+			"metrics": m,
+		})
+		return
+	}
+
+	names := make(map[int]string)
+	for _, player := range players {
+		names[int(player.ID)] = player.Name
+	}
+	ctx.Writer.WriteString(renderMetrics(event, m, names))
+}
+
+// renderMetrics renders the statistics page of an event.
+func renderMetrics(event gormmodel.Event, m metrics.Metrics, names map[int]string) string {
+	var sb strings.Builder
+	sb.WriteString(`
+<html>
+<head>
+	<style>
+		body {
+			font-family: Courier New;
+			font-weight: bold;
+		}
+		td, th {
+			padding: 2px 8px;
+			text-align: left;
+		}
+	</style>
+</head>
+<body>
+`)
+	sb.WriteString(fmt.Sprintf("<h3>Statistics of event %s</h3>\n", html.EscapeString(event.Key)))
+	sb.WriteString("<table>\n")
+	rows := []struct {
+		name  string
+		value string
+	}{
+		{"Rounds", fmt.Sprintf("%d", m.Rounds)},
+		{"Matches", fmt.Sprintf("%d", m.Matches)},
+		{"Court utilisation", fmt.Sprintf("%.1f%%", m.CourtUtilisation*100)},
+		{"Games per player", fmt.Sprintf("min %d, max %d, mean %.2f, variance %.2f", m.GamesMin, m.GamesMax, m.GamesMean, m.GamesVariance)},
+		{"Longest sit-out streak", fmt.Sprintf("%d", m.MaxSitOutStreak)},
+		{"Partner repeats", fmt.Sprintf("%d", m.PartnerRepeats)},
+		{"Opponent repeats", fmt.Sprintf("%d", m.OpponentRepeats)},
+		{"Average score gap per match", fmt.Sprintf("%.2f", m.AverageScoreGap)},
+	}
+	for _, row := range rows {
+		sb.WriteString(fmt.Sprintf("<tr><th>%s</th><td>%s</td></tr>\n", row.name, row.value))
+	}
+	sb.WriteString("</table>\n")
+
+	sb.WriteString("<h4>Players</h4>\n<table>\n<tr><th>Name</th><th>Games</th><th>Longest sit-out streak</th>" +
+		"<th>Partners</th><th>Partner repeats</th><th>Opponents</th><th>Opponent repeats</th></tr>\n")
+	for _, player := range m.Players {
+		sb.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%d</td><td>%d</td><td>%d</td><td>%d</td><td>%d</td><td>%d</td></tr>\n",
+			html.EscapeString(names[player.ID]), player.Games, player.MaxSitOutStreak,
+			player.DistinctPartners, player.PartnerRepeats, player.DistinctOpponents, player.OpponentRepeats))
+	}
+	sb.WriteString("</table>\n</body>\n</html>\n")
+	return sb.String()
+}
@@ -0,0 +1,290 @@
+package controller
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yushenli/badminton_match_table/pkg/rotation"
+	"github.com/yushenli/badminton_match_table/web/lib/config"
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"github.com/yushenli/badminton_match_table/web/lib/util"
+	"gorm.io/gorm"
+)
+
+// creditSides sets the scores of the sides of a match from its status right away, as CompleteRound
+// would do for a round: 1 for the winners, -1 for the losers and 0 for both while it is played.
+func creditSides(tx *gorm.DB, match gormmodel.Match) error {
+	score1 := float32(0)
+	switch match.Status {
+	case gormmodel.SIDE1WON:
+		score1 = 1
+	case gormmodel.SIDE2WON:
+		score1 = -1
+	}
+	ret := tx.Model(&gormmodel.Side{}).Where("id = ?", match.Sid1).Update("score", score1)
+	if ret.Error != nil {
+		return ret.Error
+	}
+	return tx.Model(&gormmodel.Side{}).Where("id = ?", match.Sid2).Update("score", -score1).Error
+}
+
+// fillMatchSides fills the sides of the matches.
+func fillMatchSides(tx *gorm.DB, matches []gormmodel.Match) error {
+	var sids []int
+	for _, match := range matches {
+		sids = append(sids, match.Sid1, match.Sid2)
+	}
+	if len(sids) == 0 {
+		return nil
+	}
+	var sides []gormmodel.Side
+	ret := tx.Where("id IN ?", sids).Find(&sides)
+	if ret.Error != nil {
+		return ret.Error
+	}
+	sideMap := make(map[int]*gormmodel.Side)
+	for idx := range sides {
+		sideMap[int(sides[idx].ID)] = &sides[idx]
+	}
+	for idx := range matches {
+		matches[idx].Side1 = sideMap[matches[idx].Sid1]
+		matches[idx].Side2 = sideMap[matches[idx].Sid2]
+	}
+	return nil
+}
+
+// playingPlayers returns the IDs of the players in the matches of the event still being played,
+// other than the given one.
+func playingPlayers(tx *gorm.DB, eid int, exceptMid uint) (map[int]bool, error) {
+	var matches []gormmodel.Match
+	ret := tx.Where("eid = ?", eid).Where("status = ?", gormmodel.PLAYING).Where("id != ?", exceptMid).Find(&matches)
+	if ret.Error != nil {
+		return nil, ret.Error
+	}
+	err := fillMatchSides(tx, matches)
+	if err != nil {
+		return nil, err
+	}
+	playing := make(map[int]bool)
+	for idx := range matches {
+		for _, pid := range util.MatchPlayerIDs(&matches[idx]) {
+			playing[pid] = true
+		}
+	}
+	return playing, nil
+}
+
+// courtNumbers returns the courts of the event, along with any court beyond them which still has
+// a state, e.g. after the number of courts has been reduced.
+func courtNumbers(event gormmodel.Event, states map[int]*gormmodel.CourtState) []int {
+	var courts []int
+	for court := 1; court <= event.Courts; court++ {
+		courts = append(courts, court)
+	}
+	for court := range states {
+		if court > event.Courts {
+			courts = append(courts, court)
+		}
+	}
+	sort.Ints(courts)
+	return courts
+}
+
+// currentCourtMatch returns the current match on a court with its sides filled, or an empty match
+// if the court has no state or its match no longer exists.
+func currentCourtMatch(tx *gorm.DB, state *gormmodel.CourtState) (gormmodel.Match, error) {
+	if state == nil {
+		return gormmodel.Match{}, nil
+	}
+	var found []gormmodel.Match
+	ret := tx.Where("id = ?", state.Mid).Find(&found)
+	if ret.Error != nil {
+		return gormmodel.Match{}, ret.Error
+	}
+	err := fillMatchSides(tx, found)
+	if err != nil || len(found) == 0 {
+		return gormmodel.Match{}, err
+	}
+	return found[0], nil
+}
+
+// advanceStayOn handles a reported result in an event running winners stay on. The sides of the
+// match are credited right away, and if the match is the current one on its court, the next match
+// on the court is created from the waiting queue. Returns a note of what has been done.
+func advanceStayOn(event gormmodel.Event, match gormmodel.Match) (string, error) {
+	var note string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		err := creditSides(tx, match)
+		if err != nil {
+			return err
+		}
+		if match.Status == gormmodel.PLAYING {
+			return nil
+		}
+
+		var state gormmodel.CourtState
+		ret := tx.Where("eid = ?", event.ID).Where("court = ?", match.Court).Limit(1).Find(&state)
+		if ret.Error != nil {
+			return ret.Error
+		}
+		if state.Mid != int(match.ID) {
+			note = fmt.Sprintf("Match %d is no longer the current match on court %d.", match.ID, match.Court)
+			return nil
+		}
+
+		matches := []gormmodel.Match{match}
+		err = fillMatchSides(tx, matches)
+		if err != nil {
+			return err
+		}
+		match = matches[0]
+		_, playerMap, err := util.PopulatePlayersIn(tx, int(event.ID))
+		if err != nil {
+			return err
+		}
+		queue, err := util.PopulateQueueIn(tx, int(event.ID))
+		if err != nil {
+			return err
+		}
+		playing, err := playingPlayers(tx, int(event.ID), match.ID)
+		if err != nil {
+			return err
+		}
+		for _, pid := range util.MatchPlayerIDs(&match) {
+			playing[pid] = true
+		}
+		queue = util.SyncQueue(queue, playerMap, playing)
+
+		next, stays, queue, err := rotation.StayOn{MaxStays: event.MaxStays}.Next(
+			util.ToRotationMatch(&match), state.Stays, queue)
+		if err != nil {
+			return err
+		}
+		nextMatches := []gormmodel.Match{util.FromRotationMatch(next, event, match.Court)}
+		err = createMatches(tx, nextMatches)
+		if err != nil {
+			return err
+		}
+		state.Mid, state.Stays = int(nextMatches[0].ID), stays
+		ret = tx.Save(&state)
+		if ret.Error != nil {
+			return ret.Error
+		}
+		note = fmt.Sprintf("Next match on court %d is %d.", match.Court, nextMatches[0].ID)
+		return util.SaveQueue(tx, int(event.ID), queue)
+	})
+	return note, err
+}
+
+// StayOnCourts shows the courts and the waiting queue of an event running winners stay on. With
+// proceed=1, every idle court, i.e. one without a current match being played, gets a new match
+// from the front of the queue, of doubles when at least 4 players are waiting.
+func StayOnCourts(ctx *gin.Context) {
+	event, ok := loadAdminEvent(ctx)
+	if !ok {
+		return
+	}
+	eid := int(event.ID)
+	if rotation.Mode(event.Mode) != rotation.WinnersStayOn {
+		RenderError(ctx, http.StatusBadRequest, fmt.Sprintf("Event %d does not run winners stay on", eid))
+		return
+	}
+
+	_, playerMap, err := util.PopulatePlayers(eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list players under event %d", eid))
+		return
+	}
+	states, err := util.PopulateCourtStates(eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list court states under event %d", eid))
+		return
+	}
+	queue, err := util.PopulateQueue(eid)
+	if err != nil {
+		RenderError(ctx, http.StatusInternalServerError,
+			fmt.Sprintf("Failed to list the waiting queue under event %d", eid))
+		return
+	}
+
+	var sb strings.Builder
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		playing, err := playingPlayers(tx, eid, 0)
+		if err != nil {
+			return err
+		}
+		queue = util.SyncQueue(queue, playerMap, playing)
+
+		sb.WriteString("<table>\n<tr><th>Court</th><th>Side 1</th><th>Side 2</th><th>Stays</th></tr>\n")
+		for _, court := range courtNumbers(*event, states) {
+			state := states[court]
+			current, err := currentCourtMatch(tx, state)
+			if err != nil {
+				return err
+			}
+			idle := current.ID == 0 || current.Status != gormmodel.PLAYING
+			if idle && court <= event.Courts && ctx.Query("proceed") == "1" {
+				sideSize := 1
+				if len(queue) >= 4 {
+					sideSize = 2
+				}
+				next, rest, ok := rotation.Take(queue, sideSize)
+				if ok {
+					matches := []gormmodel.Match{util.FromRotationMatch(next, *event, court)}
+					err := createMatches(tx, matches)
+					if err != nil {
+						return err
+					}
+					if state == nil {
+						state = &gormmodel.CourtState{Eid: eid, Court: court}
+					}
+					state.Mid, state.Stays = int(matches[0].ID), 0
+					ret := tx.Save(state)
+					if ret.Error != nil {
+						return ret.Error
+					}
+					current, queue, idle = matches[0], rest, false
+				}
+			}
+
+			if idle {
+				sb.WriteString(fmt.Sprintf("<tr><td>%d</td><td colspan=\"3\">Idle</td></tr>\n", court))
+				continue
+			}
+			sides := util.ToRotationMatch(&current)
+			names := util.EntrantNames([][]int{sides.Side1, sides.Side2}, playerMap)
+			sb.WriteString(fmt.Sprintf("<tr><td>%d</td><td>%s</td><td>%s</td><td>%d</td></tr>\n",
+				court, html.EscapeString(names[0]), html.EscapeString(names[1]), state.Stays))
+		}
+		sb.WriteString("</table>\n")
+
+		if ctx.Query("proceed") != "1" {
+			return nil
+		}
+		return util.SaveQueue(tx, eid, queue)
+	})
+	if err != nil {
+		log.Printf("Failed when filling the courts of event %d: %v", eid, err)
+		RenderError(ctx, http.StatusInternalServerError, "Failed when filling the courts")
+		return
+	}
+
+	var waiting []string
+	for _, pid := range queue {
+		waiting = append(waiting, playerMap[pid].Name)
+	}
+	ctx.Writer.WriteString("<html><head><style> body { font-family: Courier New; font-weight: bold; } td, th { padding: 2px 8px; } </style></head><body>\n")
+	ctx.Writer.WriteString(fmt.Sprintf("<h3>Winners stay on, at most %d stay(s) in a row (0 for no limit)</h3>\n", event.MaxStays))
+	if ctx.Query("proceed") != "1" {
+		ctx.Writer.WriteString(fmt.Sprintf("<p><a href=\"%s?proceed=1\">Fill the idle courts</a>\n", html.EscapeString(ctx.Request.URL.Path)))
+	}
+	ctx.Writer.WriteString(sb.String())
+	ctx.Writer.WriteString(fmt.Sprintf("<p>Waiting: %s\n</body></html>\n", html.EscapeString(strings.Join(waiting, ", "))))
+}
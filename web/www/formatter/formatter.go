@@ -14,6 +14,8 @@ func RegisterFormatters(router *gin.Engine) {
 		"sideInMatchTable":          SideInMatchTable,
 		"sideInResults":             SideInResults,
 		"sideResult":                SideResult,
+		"gameScores":                GameScores,
+		"sideHandicap":              SideHandicap,
 		"commaSeparatedPlayerNames": CommaSeparatedPlayerNames,
 		"add1": func(n int) string {
 			return fmt.Sprintf("%d", n+1)
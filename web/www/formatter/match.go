@@ -0,0 +1,23 @@
+package formatter
+
+import (
+	"fmt"
+
+	"github.com/yushenli/badminton_match_table/web/lib/gormmodel"
+	"github.com/yushenli/badminton_match_table/web/lib/util"
+)
+
+// GameScores returns the recorded game scores of a given match in a single line, e.g.
+// "21-17, 19-21, 21-15", or an empty string if no scores were recorded.
+func GameScores(match *gormmodel.Match) string {
+	return util.MatchGames(match).String()
+}
+
+// SideHandicap returns the points a given side (1 or 2) of a match starts every game with,
+// e.g. "+4", or an empty string if the side has no handicap.
+func SideHandicap(match *gormmodel.Match, side int) string {
+	if match.HandicapSide != side || match.HandicapPoints == 0 {
+		return ""
+	}
+	return fmt.Sprintf("+%d", match.HandicapPoints)
+}